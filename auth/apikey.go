@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// An API key looks like sk_1a2b3c4d_<43 characters of base64url>. The prefix is stored in the clear to find the
+// row, only the SHA-256 of the whole key is stored, so a leaked table does not leak working keys. The random
+// part has 256 bits, a salted slow hash would add nothing against guessing it
+const (
+	apiKeyTag    = "sk_"
+	prefixLength = 8
+)
+
+// NewAPIKey ... a fresh key with its lookup prefix and hash, the key itself is shown once and never stored
+func NewAPIKey() (key, prefix, hash string, err error) {
+	raw := make([]byte, 4+32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", "", err
+	}
+	prefix = hex.EncodeToString(raw[:4])
+	key = apiKeyTag + prefix + "_" + base64.RawURLEncoding.EncodeToString(raw[4:])
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// APIKeyPrefix ... the lookup prefix of a key, ok is false when it is not shaped like one of ours
+func APIKeyPrefix(key string) (prefix string, ok bool) {
+	rest, found := strings.CutPrefix(key, apiKeyTag)
+	if !found || len(rest) < prefixLength+2 || rest[prefixLength] != '_' {
+		return "", false
+	}
+	return rest[:prefixLength], true
+}
+
+// HashAPIKey ... what is stored for a key
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyAPIKey compares key with a stored hash in constant time
+func VerifyAPIKey(key, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashAPIKey(key)), []byte(hash)) == 1
+}
+
+// SecretsEqual ... constant time comparison of two secrets, e.g. the bootstrap key
+func SecretsEqual(a, b string) bool {
+	// hashing first keeps the comparison constant time even when the lengths differ
+	ha, hb := sha256.Sum256([]byte(a)), sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(ha[:], hb[:]) == 1
+}
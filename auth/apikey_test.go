@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	key, prefix, hash, err := NewAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(key, "sk_"+prefix+"_") || len(prefix) != prefixLength {
+		t.Errorf("key %q with prefix %q", key, prefix)
+	}
+	if got, ok := APIKeyPrefix(key); !ok || got != prefix {
+		t.Errorf("APIKeyPrefix = %q, %v, want %q", got, ok, prefix)
+	}
+	if strings.Contains(hash, key) || !VerifyAPIKey(key, hash) {
+		t.Errorf("hash %q of %q", hash, key)
+	}
+	if again, _, _, _ := NewAPIKey(); again == key {
+		t.Error("two keys are the same")
+	}
+}
+
+func TestVerifyAPIKey(t *testing.T) {
+	key, _, hash, err := NewAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, key string
+		want      bool
+	}{
+		{"the key", key, true},
+		{"one character changed", key[:len(key)-1] + string(key[len(key)-1]^1), false},
+		{"truncated", key[:len(key)-1], false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		if got := VerifyAPIKey(tt.key, hash); got != tt.want {
+			t.Errorf("%s: VerifyAPIKey = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAPIKeyPrefix(t *testing.T) {
+	tests := []struct {
+		key, want string
+		ok        bool
+	}{
+		{"sk_1a2b3c4d_secret", "1a2b3c4d", true},
+		{"sk_1a2b3c4d_x", "1a2b3c4d", true},
+		{"sk_1a2b3c4d_", "", false},
+		{"sk_1a2b3c4dx_secret", "", false},
+		{"pk_1a2b3c4d_secret", "", false},
+		{"1a2b3c4d_secret", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := APIKeyPrefix(tt.key); got != tt.want || ok != tt.ok {
+			t.Errorf("APIKeyPrefix(%q) = %q, %v, want %q, %v", tt.key, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCheckScopes(t *testing.T) {
+	tests := []struct {
+		scopes  []string
+		wantErr bool
+	}{
+		{[]string{ScopeStudentsRead}, false},
+		{[]string{ScopeGradesWrite, ScopeAdmin}, false},
+		{nil, true},
+		{[]string{ScopeStudentsRead, "students:delete"}, true},
+	}
+	for _, tt := range tests {
+		if err := CheckScopes(tt.scopes); (err != nil) != tt.wantErr {
+			t.Errorf("CheckScopes(%v) = %v, want error %v", tt.scopes, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPrincipalHas(t *testing.T) {
+	tests := []struct {
+		p     *Principal
+		scope string
+		want  bool
+	}{
+		{&Principal{Scopes: []string{ScopeStudentsRead}}, ScopeStudentsRead, true},
+		// a read scope does not grant the write
+		{&Principal{Scopes: []string{ScopeStudentsRead}}, ScopeStudentsWrite, false},
+		{&Principal{Scopes: []string{ScopeAdmin}}, ScopeGuardiansWrite, true},
+		{nil, ScopeStudentsRead, false},
+	}
+	for _, tt := range tests {
+		if got := tt.p.Has(tt.scope); got != tt.want {
+			t.Errorf("%+v Has(%q) = %v, want %v", tt.p, tt.scope, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Package auth describes who is calling the API and what they may do. The
+// middleware in the handler package authenticates a request, puts its Principal
+// in the context and every route group checks for the scope it needs.
+package auth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Scopes, reads and writes are granted separately for every resource
+const (
+	ScopeStudentsRead    = "students:read"
+	ScopeStudentsWrite   = "students:write"
+	ScopeTeachersRead    = "teachers:read"
+	ScopeTeachersWrite   = "teachers:write"
+	ScopeCoursesRead     = "courses:read"
+	ScopeCoursesWrite    = "courses:write"
+	ScopeAttendanceRead  = "attendance:read"
+	ScopeAttendanceWrite = "attendance:write"
+	ScopeGradesRead      = "grades:read"
+	ScopeGradesWrite     = "grades:write"
+	ScopeGuardiansRead   = "guardians:read"
+	ScopeGuardiansWrite  = "guardians:write"
+	// ScopeAdmin ... grants every other scope and the management of API keys
+	ScopeAdmin = "admin"
+)
+
+// Scopes ... every scope there is, in the order they are documented
+var Scopes = []string{
+	ScopeStudentsRead, ScopeStudentsWrite,
+	ScopeTeachersRead, ScopeTeachersWrite,
+	ScopeCoursesRead, ScopeCoursesWrite,
+	ScopeAttendanceRead, ScopeAttendanceWrite,
+	ScopeGradesRead, ScopeGradesWrite,
+	ScopeGuardiansRead, ScopeGuardiansWrite,
+	ScopeAdmin,
+}
+
+// CheckScopes ... an error naming the first scope that does not exist
+func CheckScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is needed, choose from %s", strings.Join(Scopes, ", "))
+	}
+	for _, s := range scopes {
+		if !slices.Contains(Scopes, s) {
+			return fmt.Errorf("unknown scope %q, choose from %s", s, strings.Join(Scopes, ", "))
+		}
+	}
+	return nil
+}
+
+// Principal ... the authenticated caller of a request
+type Principal struct {
+	// Subject ... who it is, e.g. "apikey:3" for the API key with id 3 or "jwt:42" for a JWT with sub 42.
+	// Every kind of credential has its own prefix, so a sub chosen by a token issuer never names an API key
+	Subject string
+	Name    string
+	// Roles ... only set for staff logged in with a JWT
+	Roles  []string
+	Scopes []string
+}
+
+// Has ... whether the principal may act within scope
+func (p *Principal) Has(scope string) bool {
+	return p != nil && (slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, ScopeAdmin))
+}
+
+type principalKey struct{}
+
+// WithPrincipal ... ctx carrying p
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext ... the principal of the request, nil before authentication
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"school_api_postgres/models"
+	"school_api_postgres/store"
+)
+
+// KeysUsage ... help text for the keys subcommand
+const KeysUsage = `usage: server keys <command>
+
+commands:
+  create -name NAME -scopes SCOPE,... [-expires DURATION]   issue a key, the secret is printed once
+  list                                                        list every key
+  revoke ID                                                   stop a key from working at once
+  rotate ID [-overlap DURATION]                               issue a replacement, the old key keeps working
+                                                              for the overlap (default 24h)`
+
+// DefaultOverlap ... how long a rotated key keeps working unless asked otherwise
+const DefaultOverlap = 24 * time.Hour
+
+// KeysCommand runs `keys create|list|revoke|rotate` and prints the result to out
+func KeysCommand(ctx context.Context, keys store.APIKeyStore, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(KeysUsage)
+	}
+	createdBy := "cli"
+	if user := os.Getenv("USER"); user != "" {
+		createdBy += ":" + user
+	}
+
+	switch args[0] {
+	case "create":
+		flags := flag.NewFlagSet("keys create", flag.ContinueOnError)
+		flags.SetOutput(out)
+		name := flags.String("name", "", "what the key is for, e.g. the client using it")
+		scopes := flags.String("scopes", "", "comma separated scopes: "+strings.Join(Scopes, ", "))
+		expires := flags.Duration("expires", 0, "lifetime of the key, 0 never expires")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *name == "" || *scopes == "" {
+			return errors.New("create: -name and -scopes are required")
+		}
+		var expiresAt *time.Time
+		if *expires > 0 {
+			at := time.Now().Add(*expires)
+			expiresAt = &at
+		}
+
+		k, secret, err := IssueAPIKey(ctx, keys, *name, splitScopes(*scopes), expiresAt, createdBy)
+		if err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+		printSecret(out, k, secret)
+		return nil
+
+	case "list":
+		list, err := keys.List(ctx)
+		if err != nil {
+			return err
+		}
+		for _, k := range list {
+			fmt.Fprintf(out, "%4d  sk_%s  %-20s %-12s %s\n", k.ID, k.Prefix, k.Name, keyState(k), strings.Join(k.Scopes, ","))
+		}
+		return nil
+
+	case "revoke":
+		id, err := keyID(args)
+		if err != nil {
+			return err
+		}
+		k, err := keys.Revoke(ctx, id)
+		if err != nil {
+			return fmt.Errorf("revoke: %w", err)
+		}
+		fmt.Fprintf(out, "revoked %d (%s)\n", k.ID, k.Name)
+		return nil
+
+	case "rotate":
+		id, err := keyID(args)
+		if err != nil {
+			return err
+		}
+		flags := flag.NewFlagSet("keys rotate", flag.ContinueOnError)
+		flags.SetOutput(out)
+		overlap := flags.Duration("overlap", DefaultOverlap, "how long the old key keeps working")
+		if err := flags.Parse(args[2:]); err != nil {
+			return err
+		}
+		if *overlap < 0 {
+			return errors.New("rotate: -overlap cannot be negative")
+		}
+
+		k, secret, err := RotateAPIKey(ctx, keys, id, *overlap, createdBy)
+		if err != nil {
+			return fmt.Errorf("rotate: %w", err)
+		}
+		fmt.Fprintf(out, "key %d stops working within %s\n", id, *overlap)
+		printSecret(out, k, secret)
+		return nil
+	}
+
+	return fmt.Errorf("unknown keys command %q\n%s", args[0], KeysUsage)
+}
+
+// splitScopes ... "a, b" and "a,b" are the same list
+func splitScopes(list string) []string {
+	scopes := strings.Split(list, ",")
+	for i := range scopes {
+		scopes[i] = strings.TrimSpace(scopes[i])
+	}
+	return scopes
+}
+
+// keyID ... the id after the command, of a key or a user
+func keyID(args []string) (int, error) {
+	if len(args) < 2 {
+		return 0, fmt.Errorf("%s: the id of the key is required", args[0])
+	}
+	id, err := strconv.Atoi(args[1])
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("%s: id must be a positive number, got %q", args[0], args[1])
+	}
+	return id, nil
+}
+
+func printSecret(out io.Writer, k models.APIKey, secret string) {
+	fmt.Fprintf(out, "created %d (%s) with scopes %s\n", k.ID, k.Name, strings.Join(k.Scopes, ","))
+	fmt.Fprintf(out, "\n  %s\n\nstore the key now, it cannot be shown again\n", secret)
+}
+
+// keyState ... active, revoked or expired, for listings
+func keyState(k models.APIKey) string {
+	switch {
+	case k.Revoked:
+		return "revoked"
+	case k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt):
+		return "expired"
+	case k.ExpiresAt != nil:
+		return "until " + k.ExpiresAt.Format("2006-01-02")
+	}
+	return "active"
+}
+
+// UsersUsage ... help text for the users subcommand
+const UsersUsage = `usage: server users <command>
+
+commands:
+  create -username NAME -role ROLE [-name "FULL NAME"]   add a staff account, the password is read from stdin
+  list                                                  list every account
+  unlock ID                                             lift a lockout after too many failed logins`
+
+// UsersCommand runs `users create|list|unlock`, the password of create is the first line of in
+func UsersCommand(ctx context.Context, users store.UserStore, args []string, in io.Reader, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(UsersUsage)
+	}
+
+	switch args[0] {
+	case "create":
+		flags := flag.NewFlagSet("users create", flag.ContinueOnError)
+		flags.SetOutput(out)
+		username := flags.String("username", "", "what the user logs in with")
+		name := flags.String("name", "", "full name, shown in the portal")
+		role := flags.String("role", "", "one of admin, teacher, clerk, read-only")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *username == "" || *role == "" {
+			return errors.New("create: -username and -role are required")
+		}
+		if _, ok := RoleScopes[*role]; !ok {
+			return fmt.Errorf("create: unknown role %q, choose from admin, teacher, clerk, read-only", *role)
+		}
+
+		fmt.Fprintln(out, "password:")
+		password, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		hash, err := HashPassword(strings.TrimRight(password, "\r\n"))
+		if err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+
+		u, err := users.Create(ctx, models.User{Username: *username, Name: *name, PasswordHash: hash, Role: *role})
+		if err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+		fmt.Fprintf(out, "created user %d (%s) with role %s\n", u.ID, u.Username, u.Role)
+		return nil
+
+	case "list":
+		list, err := users.List(ctx)
+		if err != nil {
+			return err
+		}
+		for _, u := range list {
+			state := "active"
+			if u.LockedUntil != nil && time.Now().Before(*u.LockedUntil) {
+				state = "locked"
+			}
+			fmt.Fprintf(out, "%4d  %-20s %-10s %-7s %s\n", u.ID, u.Username, u.Role, state, u.Name)
+		}
+		return nil
+
+	case "unlock":
+		id, err := keyID(args)
+		if err != nil {
+			return err
+		}
+		if err := users.Unlock(ctx, id); err != nil {
+			return fmt.Errorf("unlock: %w", err)
+		}
+		fmt.Fprintf(out, "unlocked %d\n", id)
+		return nil
+	}
+
+	return fmt.Errorf("unknown users command %q\n%s", args[0], UsersUsage)
+}
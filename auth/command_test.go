@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"school_api_postgres/store"
+)
+
+func TestKeysCommand(t *testing.T) {
+	ctx := context.Background()
+	keys := store.NewMemoryAPIKeyStore()
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		err := KeysCommand(ctx, keys, args, &out)
+		return out.String(), err
+	}
+
+	out, err := run("create", "-name", "importer", "-scopes", "students:read, students:write", "-expires", "720h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := ""
+	for _, field := range strings.Fields(out) {
+		if strings.HasPrefix(field, "sk_") {
+			secret = field
+		}
+	}
+	k, err := keys.Get(ctx, 1)
+	if err != nil || !VerifyAPIKey(secret, k.SecretHash) || k.ExpiresAt == nil || len(k.Scopes) != 2 ||
+		!strings.HasPrefix(k.CreatedBy, "cli") {
+		t.Fatalf("create printed %q and stored %+v, %v", out, k, err)
+	}
+
+	if out, err := run("rotate", "1", "-overlap", "1h"); err != nil || !strings.Contains(out, "created 2 (importer)") {
+		t.Errorf("rotate: %q, %v", out, err)
+	}
+	if out, err := run("revoke", "2"); err != nil || out != "revoked 2 (importer)\n" {
+		t.Errorf("revoke: %q, %v", out, err)
+	}
+	out, err = run("list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 2 || !strings.Contains(lines[0], "until ") ||
+		!strings.Contains(lines[1], "revoked") || strings.Contains(out, secret) {
+		t.Errorf("list: %q", out)
+	}
+}
+
+func TestKeysCommandRefused(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"no command", nil},
+		{"unknown command", []string{"delete", "1"}},
+		{"create without scopes", []string{"create", "-name", "x"}},
+		{"create with an unknown scope", []string{"create", "-name", "x", "-scopes", "everything"}},
+		{"revoke without id", []string{"revoke"}},
+		{"revoke of an unknown key", []string{"revoke", "9"}},
+		{"rotate with a negative overlap", []string{"rotate", "1", "-overlap", "-1h"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := store.NewMemoryAPIKeyStore()
+			if err := KeysCommand(context.Background(), keys, tt.args, &bytes.Buffer{}); err == nil {
+				t.Error("no error")
+			}
+		})
+	}
+}
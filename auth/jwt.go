@@ -0,0 +1,309 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// clockSkew ... exp and nbf are checked with this much leeway for clocks that are a little off
+const clockSkew = time.Minute
+
+// ErrInvalidToken ... every reason a token is refused wraps this, the details are safe to show the client
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims ... the part of a JWT payload the API looks at
+type Claims struct {
+	Subject   string
+	Name      string
+	Issuer    string
+	Audience  []string
+	ExpiresAt time.Time
+	NotBefore time.Time
+	// Roles ... from the role claim, a string or an array of strings
+	Roles []string
+}
+
+// Verifier checks the signature and claims of bearer tokens. HS256 tokens are checked against a shared secret,
+// RS256 tokens against the public keys of a JWKS file, whichever of the two is configured
+type Verifier struct {
+	secret    []byte
+	keys      map[string]*rsa.PublicKey
+	issuer    string
+	audience  string
+	roleClaim string
+}
+
+// VerifierFromEnv reads JWT_SECRET (HS256), JWT_JWKS_FILE (RS256), JWT_ISSUER, JWT_AUDIENCE and JWT_ROLE_CLAIM
+// (default "role"). It returns nil when neither a secret nor a JWKS file is set, bearer tokens are refused then
+func VerifierFromEnv() (*Verifier, error) {
+	v := &Verifier{
+		secret:    []byte(os.Getenv("JWT_SECRET")),
+		issuer:    os.Getenv("JWT_ISSUER"),
+		audience:  os.Getenv("JWT_AUDIENCE"),
+		roleClaim: os.Getenv("JWT_ROLE_CLAIM"),
+	}
+	if v.roleClaim == "" {
+		v.roleClaim = "role"
+	}
+	if len(v.secret) > 0 && len(v.secret) < 32 {
+		return nil, errors.New("JWT_SECRET must be at least 32 bytes long")
+	}
+
+	if path := os.Getenv("JWT_JWKS_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWKS: %w", err)
+		}
+		if v.keys, err = ParseJWKS(data); err != nil {
+			return nil, fmt.Errorf("JWKS %s: %w", path, err)
+		}
+	}
+	if len(v.secret) == 0 && len(v.keys) == 0 {
+		return nil, nil
+	}
+	return v, nil
+}
+
+// ParseJWKS reads the RSA signing keys of a JSON Web Key Set, keys for other algorithms or uses are skipped
+func ParseJWKS(data []byte) (map[string]*rsa.PublicKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Alg string `json:"alg"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for i, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Alg != "" && k.Alg != "RS256") || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("key %d: n and e must be base64url encoded", i)
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if pub.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("key %d: RSA keys must have at least 2048 bits", i)
+		}
+		if _, dup := keys[k.Kid]; dup {
+			return nil, fmt.Errorf("key %d: kid %q is used twice", i, k.Kid)
+		}
+		keys[k.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no RS256 signing keys found")
+	}
+	return keys, nil
+}
+
+// Verify checks a compact JWT and returns its claims, exp is required
+func (v *Verifier) Verify(token string, now time.Time) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, invalidToken("the token is not a JWT")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Claims{}, invalidToken("the token header cannot be read")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, invalidToken("the token signature cannot be read")
+	}
+	if err := v.checkSignature(header.Alg, header.Kid, parts[0]+"."+parts[1], signature); err != nil {
+		return Claims{}, err
+	}
+
+	// only a token we signed gets its payload read
+	var payload map[string]json.RawMessage
+	if err := decodeSegment(parts[1], &payload); err != nil {
+		return Claims{}, invalidToken("the token payload cannot be read")
+	}
+	claims, err := v.readClaims(payload)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	switch {
+	case claims.ExpiresAt.IsZero():
+		return Claims{}, invalidToken("the token has no exp")
+	case now.After(claims.ExpiresAt.Add(clockSkew)):
+		return Claims{}, invalidToken("the token has expired")
+	case !claims.NotBefore.IsZero() && now.Add(clockSkew).Before(claims.NotBefore):
+		return Claims{}, invalidToken("the token is not valid yet")
+	case claims.Subject == "":
+		return Claims{}, invalidToken("the token has no sub")
+	case v.issuer != "" && claims.Issuer != v.issuer:
+		return Claims{}, invalidToken("the token was issued by someone else")
+	case v.audience != "" && !slices.Contains(claims.Audience, v.audience):
+		return Claims{}, invalidToken("the token is meant for someone else")
+	}
+	return claims, nil
+}
+
+// CanSign ... whether Sign works, it needs JWT_SECRET
+func (v *Verifier) CanSign() bool {
+	return v != nil && len(v.secret) > 0
+}
+
+// Sign issues an HS256 token with the subject, name, roles and expiry of c, the issuer and audience are the ones
+// tokens are checked for
+func (v *Verifier) Sign(c Claims, now time.Time) (string, error) {
+	if !v.CanSign() {
+		return "", errors.New("JWT_SECRET is not set, tokens cannot be issued")
+	}
+	payload := map[string]interface{}{
+		"sub": c.Subject,
+		"iat": now.Unix(),
+		"exp": c.ExpiresAt.Unix(),
+	}
+	if c.Name != "" {
+		payload["name"] = c.Name
+	}
+	if len(c.Roles) == 1 {
+		payload[v.roleClaim] = c.Roles[0]
+	} else if len(c.Roles) > 1 {
+		payload[v.roleClaim] = c.Roles
+	}
+	if v.issuer != "" {
+		payload["iss"] = v.issuer
+	}
+	if v.audience != "" {
+		payload["aud"] = v.audience
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// checkSignature ... the algorithm decides which kind of key is used, so an RS256 public key can never
+// be passed off as an HS256 secret
+func (v *Verifier) checkSignature(alg, kid, signed string, signature []byte) error {
+	switch alg {
+	case "HS256":
+		if len(v.secret) == 0 {
+			break
+		}
+		mac := hmac.New(sha256.New, v.secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return invalidToken("the token signature is wrong")
+		}
+		return nil
+
+	case "RS256":
+		if len(v.keys) == 0 {
+			break
+		}
+		key, ok := v.keys[kid]
+		if !ok && kid == "" && len(v.keys) == 1 {
+			for _, only := range v.keys {
+				key, ok = only, true
+			}
+		}
+		if !ok {
+			return invalidToken("the token was signed with an unknown key")
+		}
+		digest := sha256.Sum256([]byte(signed))
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) != nil {
+			return invalidToken("the token signature is wrong")
+		}
+		return nil
+	}
+	return invalidToken(fmt.Sprintf("tokens signed with %q are not accepted", alg))
+}
+
+func (v *Verifier) readClaims(payload map[string]json.RawMessage) (Claims, error) {
+	var c Claims
+	for name, target := range map[string]interface{}{"sub": &c.Subject, "name": &c.Name, "iss": &c.Issuer} {
+		if raw, ok := payload[name]; ok && json.Unmarshal(raw, target) != nil {
+			return c, invalidToken(name + " must be a string")
+		}
+	}
+	var err error
+	if c.Audience, err = stringOrList(payload["aud"]); err != nil {
+		return c, invalidToken("aud must be a string or a list of strings")
+	}
+	if c.Roles, err = stringOrList(payload[v.roleClaim]); err != nil {
+		return c, invalidToken(v.roleClaim + " must be a string or a list of strings")
+	}
+	if c.ExpiresAt, err = numericDate(payload["exp"]); err != nil {
+		return c, invalidToken("exp must be a number of seconds")
+	}
+	if c.NotBefore, err = numericDate(payload["nbf"]); err != nil {
+		return c, invalidToken("nbf must be a number of seconds")
+	}
+	return c, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// stringOrList ... nil when the claim is missing
+func stringOrList(raw json.RawMessage) ([]string, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	var one string
+	if json.Unmarshal(raw, &one) == nil {
+		return []string{one}, nil
+	}
+	var list []string
+	err := json.Unmarshal(raw, &list)
+	return list, err
+}
+
+// numericDate ... the zero time when the claim is missing, fractions of a second are allowed
+func numericDate(raw json.RawMessage) (time.Time, error) {
+	if raw == nil {
+		return time.Time{}, nil
+	}
+	var seconds float64
+	if err := json.Unmarshal(raw, &seconds); err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), nil
+}
+
+func invalidToken(reason string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidToken, reason)
+}
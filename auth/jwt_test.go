@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testSecret = []byte("0123456789abcdef0123456789abcdef")
+	testNow    = time.Unix(1_700_000_000, 0)
+)
+
+// testRSAKey ... one key for the whole package, generating them is slow
+var testRSAKey = func() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}()
+
+// makeToken ... a compact JWT with header and payload, sign gets the signing input and returns the signature
+func makeToken(header, payload map[string]interface{}, sign func(signed []byte) []byte) string {
+	h, _ := json.Marshal(header)
+	p, _ := json.Marshal(payload)
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(p)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+func hs256(secret []byte) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signed)
+		return mac.Sum(nil)
+	}
+}
+
+func rs256(key *rsa.PrivateKey) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			panic(err)
+		}
+		return sig
+	}
+}
+
+func unsigned([]byte) []byte { return nil }
+
+// claims ... a valid payload with changes applied, a nil value removes the claim
+func claims(changes map[string]interface{}) map[string]interface{} {
+	c := map[string]interface{}{"sub": "42", "name": "Rahim", "role": "teacher", "exp": testNow.Add(time.Hour).Unix()}
+	for k, v := range changes {
+		if v == nil {
+			delete(c, k)
+		} else {
+			c[k] = v
+		}
+	}
+	return c
+}
+
+func TestVerify(t *testing.T) {
+	hsOnly := &Verifier{secret: testSecret, roleClaim: "role"}
+	rsOnly := &Verifier{keys: map[string]*rsa.PublicKey{"k1": &testRSAKey.PublicKey}, roleClaim: "role"}
+	both := &Verifier{secret: testSecret, keys: rsOnly.keys, roleClaim: "role"}
+	strict := &Verifier{secret: testSecret, issuer: "school", audience: "school-api", roleClaim: "role"}
+	hs := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+	rs := map[string]interface{}{"alg": "RS256", "kid": "k1"}
+	// the RSA public key as an HS256 secret, the classic algorithm confusion
+	pubDER, _ := x509.MarshalPKIXPublicKey(&testRSAKey.PublicKey)
+
+	tests := []struct {
+		name     string
+		verifier *Verifier
+		token    string
+		wantErr  bool
+	}{
+		{"hs256", hsOnly, makeToken(hs, claims(nil), hs256(testSecret)), false},
+		{"rs256", rsOnly, makeToken(rs, claims(nil), rs256(testRSAKey)), false},
+		{"rs256 without kid and a single key", rsOnly, makeToken(map[string]interface{}{"alg": "RS256"}, claims(nil), rs256(testRSAKey)), false},
+		{"either algorithm when both are set", both, makeToken(rs, claims(nil), rs256(testRSAKey)), false},
+
+		{"alg none", hsOnly, makeToken(map[string]interface{}{"alg": "none"}, claims(nil), unsigned), true},
+		{"alg None", hsOnly, makeToken(map[string]interface{}{"alg": "None"}, claims(nil), unsigned), true},
+		{"no alg", hsOnly, makeToken(map[string]interface{}{}, claims(nil), hs256(testSecret)), true},
+		{"hs512", hsOnly, makeToken(map[string]interface{}{"alg": "HS512"}, claims(nil), hs256(testSecret)), true},
+		{"hs256 signed with the public key", rsOnly, makeToken(hs, claims(nil), hs256(pubDER)), true},
+		{"hs256 signed with the public key, secret set", both, makeToken(hs, claims(nil), hs256(pubDER)), true},
+		{"rs256 header on an hmac signature", both, makeToken(rs, claims(nil), hs256(testSecret)), true},
+		{"hs256 when only rs256 is set up", rsOnly, makeToken(hs, claims(nil), hs256(testSecret)), true},
+		{"rs256 when only hs256 is set up", hsOnly, makeToken(rs, claims(nil), rs256(testRSAKey)), true},
+		{"unknown kid", rsOnly, makeToken(map[string]interface{}{"alg": "RS256", "kid": "k2"}, claims(nil), rs256(testRSAKey)), true},
+		{"wrong secret", hsOnly, makeToken(hs, claims(nil), hs256([]byte("another secret of at least 32 bytes"))), true},
+		{"not a jwt", hsOnly, "abc.def", true},
+		{"garbage signature", hsOnly, makeToken(hs, claims(nil), hs256(testSecret)) + "!", true},
+
+		{"expired", hsOnly, makeToken(hs, claims(map[string]interface{}{"exp": testNow.Add(-2 * clockSkew).Unix()}), hs256(testSecret)), true},
+		{"expired within the skew", hsOnly, makeToken(hs, claims(map[string]interface{}{"exp": testNow.Add(-clockSkew / 2).Unix()}), hs256(testSecret)), false},
+		{"no exp", hsOnly, makeToken(hs, claims(map[string]interface{}{"exp": nil}), hs256(testSecret)), true},
+		{"exp is text", hsOnly, makeToken(hs, claims(map[string]interface{}{"exp": "tomorrow"}), hs256(testSecret)), true},
+		{"not valid yet", hsOnly, makeToken(hs, claims(map[string]interface{}{"nbf": testNow.Add(2 * clockSkew).Unix()}), hs256(testSecret)), true},
+		{"no sub", hsOnly, makeToken(hs, claims(map[string]interface{}{"sub": nil}), hs256(testSecret)), true},
+		{"sub is a number", hsOnly, makeToken(hs, claims(map[string]interface{}{"sub": 42}), hs256(testSecret)), true},
+
+		{"issuer and audience", strict, makeToken(hs, claims(map[string]interface{}{"iss": "school", "aud": []string{"other", "school-api"}}), hs256(testSecret)), false},
+		{"wrong issuer", strict, makeToken(hs, claims(map[string]interface{}{"iss": "evil", "aud": "school-api"}), hs256(testSecret)), true},
+		{"wrong audience", strict, makeToken(hs, claims(map[string]interface{}{"iss": "school", "aud": "other"}), hs256(testSecret)), true},
+		{"no audience", strict, makeToken(hs, claims(map[string]interface{}{"iss": "school"}), hs256(testSecret)), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.verifier.Verify(tt.token, testNow)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("%v does not wrap ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestVerifyTamperedPayload(t *testing.T) {
+	v := &Verifier{secret: testSecret, roleClaim: "role"}
+	header := map[string]interface{}{"alg": "HS256"}
+	token := strings.Split(makeToken(header, claims(nil), hs256(testSecret)), ".")
+	forged := strings.Split(makeToken(header, claims(map[string]interface{}{"role": "admin"}), unsigned), ".")
+
+	if _, err := v.Verify(token[0]+"."+forged[1]+"."+token[2], testNow); err == nil {
+		t.Fatal("a token with a swapped payload was accepted")
+	}
+}
+
+func TestSignRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		roleClaim string
+		roles     []string
+	}{
+		{"one role", "role", []string{"teacher"}},
+		{"several roles", "role", []string{"teacher", "admin"}},
+		{"custom role claim", "https://school.example/roles", []string{"staff"}},
+		{"no role", "role", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Verifier{secret: testSecret, issuer: "school", audience: "school-api", roleClaim: tt.roleClaim}
+			token, err := v.Sign(Claims{Subject: "42", Name: "Rahim", Roles: tt.roles, ExpiresAt: testNow.Add(time.Hour)}, testNow)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := v.Verify(token, testNow)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Subject != "42" || c.Name != "Rahim" || c.Issuer != "school" || !slices.Equal(c.Roles, tt.roles) {
+				t.Errorf("claims %+v", c)
+			}
+			if !c.ExpiresAt.Equal(testNow.Add(time.Hour)) {
+				t.Errorf("exp %v, want %v", c.ExpiresAt, testNow.Add(time.Hour))
+			}
+		})
+	}
+
+	if _, err := (&Verifier{keys: map[string]*rsa.PublicKey{"k1": &testRSAKey.PublicKey}}).Sign(Claims{Subject: "1"}, testNow); err == nil {
+		t.Error("Sign worked without JWT_SECRET")
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	n := b64(testRSAKey.N.Bytes())
+	e := b64(big.NewInt(int64(testRSAKey.E)).Bytes())
+	small, _ := rsa.GenerateKey(rand.Reader, 1024)
+
+	tests := []struct {
+		name     string
+		jwks     string
+		wantKids []string
+		wantErr  bool
+	}{
+		{"one key", `{"keys":[{"kty":"RSA","kid":"k1","alg":"RS256","use":"sig","n":"` + n + `","e":"` + e + `"}]}`, []string{"k1"}, false},
+		{"other keys are skipped", `{"keys":[{"kty":"EC","kid":"ec"},{"kty":"RSA","kid":"enc","use":"enc","n":"` + n + `","e":"` + e + `"},` +
+			`{"kty":"RSA","kid":"k1","n":"` + n + `","e":"` + e + `"}]}`, []string{"k1"}, false},
+		{"no usable key", `{"keys":[{"kty":"EC","kid":"ec"}]}`, nil, true},
+		{"short key", `{"keys":[{"kty":"RSA","kid":"k1","n":"` + b64(small.N.Bytes()) + `","e":"` + e + `"}]}`, nil, true},
+		{"kid twice", `{"keys":[{"kty":"RSA","kid":"k1","n":"` + n + `","e":"` + e + `"},{"kty":"RSA","kid":"k1","n":"` + n + `","e":"` + e + `"}]}`, nil, true},
+		{"bad base64", `{"keys":[{"kty":"RSA","kid":"k1","n":"***","e":"` + e + `"}]}`, nil, true},
+		{"not json", `{"keys":`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := ParseJWKS([]byte(tt.jwks))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseJWKS = %v, want error %v", err, tt.wantErr)
+			}
+			var kids []string
+			for kid := range keys {
+				kids = append(kids, kid)
+			}
+			if !slices.Equal(kids, tt.wantKids) {
+				t.Errorf("kids %v, want %v", kids, tt.wantKids)
+			}
+			if len(keys) == 1 && !keys["k1"].Equal(&testRSAKey.PublicKey) {
+				t.Error("the key read is not the key written")
+			}
+		})
+	}
+}
+
+func TestVerifierFromEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		wantNil   bool
+		wantErr   bool
+		wantClaim string
+	}{
+		{"nothing configured", "", true, false, ""},
+		{"secret", string(testSecret), false, false, "role"},
+		{"short secret", "too short", false, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JWT_SECRET", tt.secret)
+			t.Setenv("JWT_JWKS_FILE", "")
+			t.Setenv("JWT_ROLE_CLAIM", "")
+			v, err := VerifierFromEnv()
+			if (err != nil) != tt.wantErr || (v == nil) != (tt.wantNil || tt.wantErr) {
+				t.Fatalf("VerifierFromEnv = %v, %v", v, err)
+			}
+			if v != nil && v.roleClaim != tt.wantClaim {
+				t.Errorf("role claim %q, want %q", v.roleClaim, tt.wantClaim)
+			}
+		})
+	}
+}
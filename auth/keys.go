@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"school_api_postgres/models"
+	"school_api_postgres/store"
+)
+
+// prefixAttempts ... two keys sharing a random 32 bit prefix is rare, three in a row means something else is wrong
+const prefixAttempts = 3
+
+// IssueAPIKey creates a key named name with the scopes, expiresAt may be nil. It returns the stored key and
+// its secret, which is not kept anywhere and has to be handed to the client now
+func IssueAPIKey(ctx context.Context, keys store.APIKeyStore, name string, scopes []string, expiresAt *time.Time, createdBy string) (models.APIKey, string, error) {
+	if err := CheckScopes(scopes); err != nil {
+		return models.APIKey{}, "", err
+	}
+	return withNewSecret(func(k models.APIKey) (models.APIKey, error) {
+		k.Name, k.Scopes, k.ExpiresAt, k.CreatedBy = name, scopes, expiresAt, createdBy
+		return keys.Create(ctx, k)
+	})
+}
+
+// RotateAPIKey issues a replacement for key id with the same name, scopes and lifetime. The old key keeps working
+// for overlap so clients can switch over without downtime, an overlap of 0 ends it at once
+func RotateAPIKey(ctx context.Context, keys store.APIKeyStore, id int, overlap time.Duration, createdBy string) (models.APIKey, string, error) {
+	old, err := keys.Get(ctx, id)
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+	var expiresAt *time.Time
+	if old.ExpiresAt != nil {
+		at := time.Now().Add(old.ExpiresAt.Sub(old.CreatedAt))
+		expiresAt = &at
+	}
+	return withNewSecret(func(k models.APIKey) (models.APIKey, error) {
+		k.Name, k.Scopes, k.ExpiresAt, k.CreatedBy = old.Name, old.Scopes, expiresAt, createdBy
+		return keys.Rotate(ctx, id, k, time.Now().Add(overlap))
+	})
+}
+
+// withNewSecret calls create with a fresh prefix and hash until the prefix is not taken
+func withNewSecret(create func(k models.APIKey) (models.APIKey, error)) (models.APIKey, string, error) {
+	var err error
+	for range prefixAttempts {
+		secret, prefix, hash, genErr := NewAPIKey()
+		if genErr != nil {
+			return models.APIKey{}, "", genErr
+		}
+		var k models.APIKey
+		k, err = create(models.APIKey{Prefix: prefix, SecretHash: hash})
+		if errors.Is(err, store.ErrConflict) {
+			continue
+		}
+		if err != nil {
+			return models.APIKey{}, "", err
+		}
+		return k, secret, nil
+	}
+	return models.APIKey{}, "", err
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// passwordCost ... bcrypt work factor, about a quarter second per hash on a current server
+	passwordCost = 12
+	// MinPasswordLength ... bcrypt only looks at the first 72 bytes, so that is the upper limit
+	MinPasswordLength = 10
+	maxPasswordLength = 72
+	refreshTokenTag   = "rt_"
+)
+
+// dummyHash ... compared against when the username does not exist, so an unknown user takes as long as a wrong password
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("not a real password"), passwordCost)
+	return hash
+})
+
+// CheckPasswordPolicy ... an error saying what is wrong with a new password
+func CheckPasswordPolicy(password string) error {
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	}
+	if len(password) > maxPasswordLength {
+		return fmt.Errorf("password must be at most %d bytes", maxPasswordLength)
+	}
+	return nil
+}
+
+// HashPassword ... the bcrypt hash stored for a password
+func HashPassword(password string) (string, error) {
+	if err := CheckPasswordPolicy(password); err != nil {
+		return "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
+	return string(hash), err
+}
+
+// CheckPassword ... whether password matches hash, an empty hash stands for an unknown user and never matches
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NewRefreshToken ... a fresh refresh token and the hash that is stored for it
+func NewRefreshToken() (token, hash string, err error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token = refreshTokenTag + base64.RawURLEncoding.EncodeToString(raw)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken ... what is stored for a refresh token, the token has 256 random bits so SHA-256 is enough
+func HashRefreshToken(token string) string {
+	return HashAPIKey(token)
+}
+
+// NewTokenFamily ... the id shared by the refresh tokens of one login
+func NewTokenFamily() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
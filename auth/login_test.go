@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckPasswordPolicy(t *testing.T) {
+	tests := []struct {
+		password string
+		wantErr  bool
+	}{
+		{"", true},
+		{"short", true},
+		{strings.Repeat("x", MinPasswordLength), false},
+		{strings.Repeat("x", 72), false},
+		// bcrypt ignores anything after 72 bytes, so such a password is refused rather than cut
+		{strings.Repeat("x", 73), true},
+	}
+	for _, tt := range tests {
+		if err := CheckPasswordPolicy(tt.password); (err != nil) != tt.wantErr {
+			t.Errorf("CheckPasswordPolicy(%d bytes) = %v, want error %v", len(tt.password), err, tt.wantErr)
+		}
+	}
+}
+
+func TestPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse battery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, hash, password string
+		want                 bool
+	}{
+		{"right password", hash, "correct horse battery", true},
+		{"wrong password", hash, "correct horse battery!", false},
+		{"unknown user", "", "correct horse battery", false},
+	}
+	for _, tt := range tests {
+		if got := CheckPassword(tt.hash, tt.password); got != tt.want {
+			t.Errorf("%s: CheckPassword = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if _, err := HashPassword("short"); err == nil {
+		t.Error("HashPassword accepted a password against the policy")
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	token, hash, err := NewRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(token, refreshTokenTag) || hash != HashRefreshToken(token) || strings.Contains(hash, token) {
+		t.Errorf("token %q, hash %q", token, hash)
+	}
+	other, _, _ := NewRefreshToken()
+	if other == token {
+		t.Error("two refresh tokens are the same")
+	}
+	family, err := NewTokenFamily()
+	if err != nil || len(family) != 32 {
+		t.Errorf("NewTokenFamily = %q, %v", family, err)
+	}
+}
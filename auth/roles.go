@@ -0,0 +1,40 @@
+package auth
+
+import "slices"
+
+// Roles of staff logging in with a JWT, each one stands for a fixed set of scopes
+const (
+	RoleAdmin    = "admin"
+	RoleTeacher  = "teacher"
+	RoleClerk    = "clerk"
+	RoleReadOnly = "read-only"
+)
+
+// reads ... every :read scope, all staff may look at everything
+var reads = []string{
+	ScopeStudentsRead, ScopeTeachersRead, ScopeCoursesRead,
+	ScopeAttendanceRead, ScopeGradesRead, ScopeGuardiansRead,
+}
+
+// RoleScopes is the permission policy of the roles. Teachers take attendance and enter grades but cannot change
+// student records, clerks keep the records of students, teachers, courses and guardians but cannot grade
+var RoleScopes = map[string][]string{
+	RoleAdmin:   {ScopeAdmin},
+	RoleTeacher: append(slices.Clone(reads), ScopeAttendanceWrite, ScopeGradesWrite),
+	RoleClerk: append(slices.Clone(reads),
+		ScopeStudentsWrite, ScopeTeachersWrite, ScopeCoursesWrite, ScopeGuardiansWrite, ScopeAttendanceWrite),
+	RoleReadOnly: slices.Clone(reads),
+}
+
+// ScopesForRoles ... the union of the scopes of roles, roles we do not know grant nothing
+func ScopesForRoles(roles []string) []string {
+	var scopes []string
+	for _, role := range roles {
+		for _, s := range RoleScopes[role] {
+			if !slices.Contains(scopes, s) {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+	return scopes
+}
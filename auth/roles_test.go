@@ -0,0 +1,46 @@
+package auth
+
+import "testing"
+
+func TestScopesForRoles(t *testing.T) {
+	tests := []struct {
+		name  string
+		roles []string
+		scope string
+		want  bool
+	}{
+		{"admin grades", []string{RoleAdmin}, ScopeGradesWrite, true},
+		{"teacher grades", []string{RoleTeacher}, ScopeGradesWrite, true},
+		{"teacher takes attendance", []string{RoleTeacher}, ScopeAttendanceWrite, true},
+		{"teacher edits students", []string{RoleTeacher}, ScopeStudentsWrite, false},
+		{"clerk edits students", []string{RoleClerk}, ScopeStudentsWrite, true},
+		{"clerk grades", []string{RoleClerk}, ScopeGradesWrite, false},
+		{"read-only reads", []string{RoleReadOnly}, ScopeGuardiansRead, true},
+		{"read-only writes", []string{RoleReadOnly}, ScopeCoursesWrite, false},
+		// the scopes of several roles add up
+		{"teacher and clerk", []string{RoleTeacher, RoleClerk}, ScopeGradesWrite, true},
+		{"unknown role", []string{"janitor"}, ScopeStudentsRead, false},
+		{"no roles", nil, ScopeStudentsRead, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Principal{Scopes: ScopesForRoles(tt.roles)}
+			if got := p.Has(tt.scope); got != tt.want {
+				t.Errorf("%v with scopes %v Has(%q) = %v, want %v", tt.roles, p.Scopes, tt.scope, got, tt.want)
+			}
+		})
+	}
+
+	// a scope granted by two roles is listed once
+	scopes := ScopesForRoles([]string{RoleTeacher, RoleClerk, RoleTeacher})
+	seen := map[string]bool{}
+	for _, s := range scopes {
+		if seen[s] {
+			t.Errorf("%s listed twice in %v", s, scopes)
+		}
+		seen[s] = true
+	}
+	if err := CheckScopes(scopes); err != nil {
+		t.Errorf("roles grant a scope that does not exist: %v", err)
+	}
+}
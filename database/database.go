@@ -0,0 +1,37 @@
+// Package database opens the Postgres connection the rest of the service shares.
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+
+	_ "github.com/lib/pq" // postgres driver
+)
+
+// Open connects to Postgres with the DB_* environment variables and pings it
+func Open() (*sql.DB, error) {
+
+	// removed godotenv loading beacuse when using docker-compose I want to send the variables from
+	// docker compose environ, in kubernetes they come from the deployment
+
+	DB_USER := os.Getenv("DB_USER")
+	DB_PASSWORD := os.Getenv("DB_PASSWORD")
+	DB_NAME := os.Getenv("DB_NAME")
+	DB_HOST := os.Getenv("DB_HOST")
+	DB_PORT := os.Getenv("DB_PORT")
+
+	dbinfo := fmt.Sprintf("user=%s password=%s dbname=%s host=%s port=%s sslmode=disable",
+		DB_USER, DB_PASSWORD, DB_NAME, DB_HOST, DB_PORT)
+
+	db, err := sql.Open("postgres", dbinfo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the database: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping the database: %w", err)
+	}
+	return db, nil
+}
@@ -2,13 +2,10 @@ module school_api_postgres
 
 go 1.24.0
 
-require (
-	github.com/gorilla/mux v1.8.1
-	github.com/lib/pq v1.10.9
-)
+require github.com/lib/pq v1.10.9
 
 require (
 	github.com/go-chi/chi/v5 v5.2.1
 	github.com/joho/godotenv v1.5.1
-	golang.org/x/time v0.11.0
+	golang.org/x/crypto v0.36.0
 )
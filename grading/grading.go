@@ -0,0 +1,206 @@
+// Package grading turns exam scores into letter grades, grade points and class
+// ranks. The scale is data, not code: the default can be replaced with a JSON
+// file named by GRADING_SCALE_FILE without rebuilding the server.
+package grading
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"school_api_postgres/models"
+)
+
+// Band ... the letter and grade points earned by a percentage of at least Min
+type Band struct {
+	Letter string  `json:"letter"`
+	Min    float64 `json:"min"`
+	Points float64 `json:"points"`
+}
+
+// Scale ... bands ordered from the highest Min down, the last one always starts at 0
+type Scale []Band
+
+// DefaultScale ... the usual school scale, 33% is the pass mark
+var DefaultScale = Scale{
+	{Letter: "A+", Min: 80, Points: 5.0},
+	{Letter: "A", Min: 70, Points: 4.0},
+	{Letter: "A-", Min: 60, Points: 3.5},
+	{Letter: "B", Min: 50, Points: 3.0},
+	{Letter: "C", Min: 40, Points: 2.0},
+	{Letter: "D", Min: 33, Points: 1.0},
+	{Letter: "F", Min: 0, Points: 0},
+}
+
+// FromEnv ... the scale in GRADING_SCALE_FILE, DefaultScale when it is unset
+func FromEnv() (Scale, error) {
+	path := os.Getenv("GRADING_SCALE_FILE")
+	if path == "" {
+		return DefaultScale, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read grading scale: %w", err)
+	}
+	scale, err := ParseScale(data)
+	if err != nil {
+		return nil, fmt.Errorf("grading scale %s: %w", path, err)
+	}
+	return scale, nil
+}
+
+// ParseScale reads a JSON array of bands like [{"letter": "A", "min": 80, "points": 4}, ...] in any order
+func ParseScale(data []byte) (Scale, error) {
+	var scale Scale
+	if err := json.Unmarshal(data, &scale); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(scale, func(i, j int) bool { return scale[i].Min > scale[j].Min })
+	if err := scale.validate(); err != nil {
+		return nil, err
+	}
+	return scale, nil
+}
+
+func (s Scale) validate() error {
+	if len(s) == 0 {
+		return errors.New("needs at least one band")
+	}
+	letters := make(map[string]bool, len(s))
+	for i, b := range s {
+		if b.Letter == "" {
+			return errors.New("every band needs a letter")
+		}
+		if letters[b.Letter] {
+			return fmt.Errorf("letter %q is used twice", b.Letter)
+		}
+		letters[b.Letter] = true
+		if b.Min < 0 || b.Min > 100 {
+			return fmt.Errorf("band %s: min must be between 0 and 100", b.Letter)
+		}
+		if i > 0 && b.Min == s[i-1].Min {
+			return fmt.Errorf("bands %s and %s start at the same percentage", s[i-1].Letter, b.Letter)
+		}
+		if b.Points < 0 {
+			return fmt.Errorf("band %s: points must not be negative", b.Letter)
+		}
+	}
+	if s[len(s)-1].Min != 0 {
+		return errors.New("the lowest band must start at 0 so every percentage gets a letter")
+	}
+	return nil
+}
+
+// Grade ... the band a percentage falls into
+func (s Scale) Grade(percentage float64) Band {
+	for _, b := range s {
+		if percentage >= b.Min {
+			return b
+		}
+	}
+	return s[len(s)-1]
+}
+
+// ReportCards builds the report card of every student in scores, which should hold every grade
+// of the students being compared (one class, one term) so the ranks come out right
+// a course percentage is the total score over the total max score of its exams,
+// the overall percentage and points are the plain average over the courses
+func ReportCards(scores []models.ExamScore, scale Scale) map[int]*models.ReportCard {
+	type courseKey struct{ student, course int }
+	results := make(map[courseKey]*models.CourseResult)
+	cards := make(map[int]*models.ReportCard)
+
+	for _, sc := range scores {
+		card, ok := cards[sc.StudentID]
+		if !ok {
+			card = &models.ReportCard{StudentID: sc.StudentID}
+			cards[sc.StudentID] = card
+		}
+		key := courseKey{sc.StudentID, sc.CourseID}
+		cr, ok := results[key]
+		if !ok {
+			cr = &models.CourseResult{CourseID: sc.CourseID, Code: sc.CourseCode, Name: sc.CourseName}
+			results[key] = cr
+		}
+		cr.Exams++
+		cr.Score += sc.Score
+		cr.MaxScore += sc.MaxScore
+	}
+
+	for key, cr := range results {
+		cr.Score, cr.MaxScore = round(cr.Score), round(cr.MaxScore)
+		if cr.MaxScore > 0 {
+			cr.Result = scale.result(cr.Score / cr.MaxScore * 100)
+		}
+		cards[key.student].Courses = append(cards[key.student].Courses, *cr)
+	}
+
+	for _, card := range cards {
+		sort.Slice(card.Courses, func(i, j int) bool { return card.Courses[i].Code < card.Courses[j].Code })
+		var sum, points float64
+		var n int
+		for _, cr := range card.Courses {
+			if cr.Percentage != nil {
+				sum += *cr.Percentage
+				points += *cr.Points
+				n++
+			}
+		}
+		if n > 0 {
+			card.Overall = scale.result(sum / float64(n))
+			gpa := round(points / float64(n))
+			card.Overall.Points = &gpa
+		}
+	}
+
+	rank(cards, func(card *models.ReportCard) *models.Result { return &card.Overall })
+	courses := make(map[int]bool)
+	for key := range results {
+		courses[key.course] = true
+	}
+	for courseID := range courses {
+		rank(cards, func(card *models.ReportCard) *models.Result {
+			for i := range card.Courses {
+				if card.Courses[i].CourseID == courseID {
+					return &card.Courses[i].Result
+				}
+			}
+			return nil
+		})
+	}
+	return cards
+}
+
+// rank fills in Rank and OutOf of the result pick returns for each card, cards without one are skipped
+// ties share a rank and the next rank is skipped (1, 2, 2, 4)
+func rank(cards map[int]*models.ReportCard, pick func(*models.ReportCard) *models.Result) {
+	var ranked []*models.Result
+	for _, card := range cards {
+		if r := pick(card); r != nil && r.Percentage != nil {
+			ranked = append(ranked, r)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool { return *ranked[i].Percentage > *ranked[j].Percentage })
+	for i, r := range ranked {
+		r.Rank = i + 1
+		if i > 0 && *r.Percentage == *ranked[i-1].Percentage {
+			r.Rank = ranked[i-1].Rank
+		}
+		r.OutOf = len(ranked)
+	}
+}
+
+// result ... the percentage rounded to two decimals with its band
+func (s Scale) result(percentage float64) models.Result {
+	p := round(percentage)
+	band := s.Grade(p)
+	points := band.Points
+	return models.Result{Percentage: &p, Letter: band.Letter, Points: &points}
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
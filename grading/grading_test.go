@@ -0,0 +1,127 @@
+package grading
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"school_api_postgres/models"
+)
+
+func TestGrade(t *testing.T) {
+	tests := []struct {
+		percentage float64
+		want       string
+	}{
+		{100, "A+"},
+		{80, "A+"},
+		{79.99, "A"},
+		{60, "A-"},
+		{33, "D"},
+		{32.99, "F"},
+		{0, "F"},
+	}
+	for _, tt := range tests {
+		if got := DefaultScale.Grade(tt.percentage); got.Letter != tt.want {
+			t.Errorf("Grade(%v) = %s, want %s", tt.percentage, got.Letter, tt.want)
+		}
+	}
+}
+
+func TestParseScale(t *testing.T) {
+	tests := []struct {
+		name, json string
+		wantErr    bool
+	}{
+		{"any order", `[{"letter":"F","min":0,"points":0},{"letter":"P","min":50,"points":1}]`, false},
+		{"empty", `[]`, true},
+		{"no letter", `[{"min":0,"points":0}]`, true},
+		{"letter twice", `[{"letter":"A","min":50,"points":1},{"letter":"A","min":0,"points":0}]`, true},
+		{"same min", `[{"letter":"A","min":0,"points":1},{"letter":"B","min":0,"points":0}]`, true},
+		{"min over 100", `[{"letter":"A","min":101,"points":1},{"letter":"F","min":0,"points":0}]`, true},
+		{"negative points", `[{"letter":"F","min":0,"points":-1}]`, true},
+		{"nothing from 0", `[{"letter":"P","min":50,"points":1}]`, true},
+		{"not json", `{"A":80}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scale, err := ParseScale([]byte(tt.json))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseScale = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && (scale[0].Letter != "P" || scale.Grade(49).Letter != "F") {
+				t.Errorf("scale %+v is not sorted from the top", scale)
+			}
+		})
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("GRADING_SCALE_FILE", "")
+	if scale, err := FromEnv(); err != nil || len(scale) != len(DefaultScale) {
+		t.Errorf("FromEnv without a file = %v, %v", scale, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "scale.json")
+	if err := os.WriteFile(path, []byte(`[{"letter":"Pass","min":40,"points":1},{"letter":"Fail","min":0,"points":0}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GRADING_SCALE_FILE", path)
+	if scale, err := FromEnv(); err != nil || scale.Grade(40).Letter != "Pass" {
+		t.Errorf("FromEnv = %v, %v", scale, err)
+	}
+
+	t.Setenv("GRADING_SCALE_FILE", filepath.Join(t.TempDir(), "missing.json"))
+	if _, err := FromEnv(); err == nil {
+		t.Error("FromEnv with a missing file did not fail")
+	}
+}
+
+func TestReportCards(t *testing.T) {
+	score := func(student, course int, code string, score, max float64) models.ExamScore {
+		return models.ExamScore{StudentID: student, CourseID: course, CourseCode: code, Score: score, MaxScore: max}
+	}
+	cards := ReportCards([]models.ExamScore{
+		// student 1: math 45+35 of 50+50 = 80%, bangla 30 of 50 = 60%
+		score(1, 1, "MATH", 45, 50), score(1, 1, "MATH", 35, 50), score(1, 2, "BAN", 30, 50),
+		// student 2: math 80%, bangla 100%
+		score(2, 1, "MATH", 80, 100), score(2, 2, "BAN", 50, 50),
+		// student 3: math 20%, no bangla
+		score(3, 1, "MATH", 10, 50),
+	}, DefaultScale)
+
+	one := cards[1]
+	if len(one.Courses) != 2 || one.Courses[0].Code != "BAN" || one.Courses[1].Exams != 2 || one.Courses[1].Score != 80 {
+		t.Fatalf("courses of student 1: %+v", one.Courses)
+	}
+	tests := []struct {
+		name   string
+		result models.Result
+		want   string
+	}{
+		// the overall result is the plain average of the courses, the points too
+		{"student 1 overall", one.Overall, "70 A 4.25 rank 2/3"},
+		{"student 1 math", one.Courses[1].Result, "80 A+ 5 rank 1/3"},
+		{"student 2 overall", cards[2].Overall, "90 A+ 5 rank 1/3"},
+		// the same percentage shares a rank
+		{"student 2 math", cards[2].Courses[1].Result, "80 A+ 5 rank 1/3"},
+		{"student 3 overall", cards[3].Overall, "20 F 0 rank 3/3"},
+		{"student 3 math", cards[3].Courses[0].Result, "20 F 0 rank 3/3"},
+		{"student 1 bangla", one.Courses[0].Result, "60 A- 3.5 rank 2/2"},
+	}
+	for _, tt := range tests {
+		r := tt.result
+		if r.Percentage == nil || r.Points == nil {
+			t.Errorf("%s: %+v", tt.name, r)
+			continue
+		}
+		if got := fmtResult(r); got != tt.want {
+			t.Errorf("%s: %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func fmtResult(r models.Result) string {
+	return fmt.Sprintf("%v %s %v rank %d/%d", *r.Percentage, r.Letter, *r.Points, r.Rank, r.OutOf)
+}
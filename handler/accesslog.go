@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// maxRequestIDLength ... longer incoming ids are replaced, they end up in every log line of the request
+const maxRequestIDLength = 128
+
+// requestID keeps the X-Request-ID a client or the ingress sent, so one id follows a request through every hop,
+// and makes one up when there is none or it does not look like an id. It is put where middleware.RequestID would
+// put it, so middleware.GetReqID finds it, and handed back on the response
+func requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(middleware.RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(middleware.RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), middleware.RequestIDKey, id)))
+	})
+}
+
+// validRequestID ... letters, digits and -_.:/ only, nothing that could break a log line
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':', c == '/':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// accessEntry ... what the access log learns from further down the chain, authenticate fills in the subject
+type accessEntry struct {
+	subject string
+}
+
+type accessEntryKey struct{}
+
+// noteSubject ... tells the access log who the request was made by
+func noteSubject(r *http.Request, subject string) {
+	if e, ok := r.Context().Value(accessEntryKey{}).(*accessEntry); ok {
+		e.subject = subject
+	}
+}
+
+// accessLog writes one line per request with its status, latency, size and caller. Bodies and query strings are
+// left out, they carry the personal data of students and staff; the route pattern says which endpoint it was
+func (h *Handler) accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		entry := &accessEntry{}
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), accessEntryKey{}, entry)))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("route", chi.RouteContext(r.Context()).RoutePattern()),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", ww.BytesWritten()),
+			slog.String("ip", h.clientIP(r).String()),
+		}
+		if entry.subject != "" {
+			attrs = append(attrs, slog.String("principal", entry.subject))
+		}
+		slog.LogAttrs(r.Context(), level, "request", attrs...)
+	})
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"school_api_postgres/logging"
+)
+
+// captureLog makes slog write JSON lines into the returned buffer until the test ends
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(old) })
+	return &buf
+}
+
+// accessLines ... the access log lines in buf
+func accessLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, l := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(l), &m); err != nil {
+			t.Fatalf("decoding %q: %v", l, err)
+		}
+		if m["msg"] == "request" {
+			lines = append(lines, m)
+		}
+	}
+	return lines
+}
+
+func TestRequestIDHeader(t *testing.T) {
+	api := newTestAPI(t)
+	tests := []struct {
+		name, sent string
+		kept       bool
+	}{
+		{"kept", "ingress-42:a/b.c_d", true},
+		{"made up when missing", "", false},
+		{"replaced when it could break a log line", "a b\nc", false},
+		{"replaced when too long", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := api.do("GET", "/api/v1/students", "", "X-Request-ID", tt.sent)
+			got := rec.Header().Get("X-Request-ID")
+			if (got == tt.sent) != tt.kept || !validRequestID(got) {
+				t.Errorf("sent %q, got back %q", tt.sent, got)
+			}
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	api := newTestAPI(t)
+	buf := captureLog(t)
+
+	api.do("POST", "/api/v1/students?name=Rahim", `{"name":"Rahim","age":12,"class":6}`, "X-Request-ID", "req-1")
+	api.do("GET", "/api/v1/students/1", "", APIKeyHeader, "wrong")
+
+	lines := accessLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("%d access log lines, want 2: %s", len(lines), buf)
+	}
+	tests := []struct {
+		key         string
+		first, last any
+	}{
+		{"method", "POST", "GET"},
+		// a request refused by authenticate never gets further than the /api/v1 mount
+		{"route", "/api/v1/students", "/api/v1/*"},
+		{"path", "/api/v1/students", "/api/v1/students/1"},
+		{"status", float64(http.StatusCreated), float64(http.StatusUnauthorized)},
+		{"principal", "bootstrap", nil},
+		{"request_id", "req-1", lines[1]["request_id"]},
+		{"ip", "192.0.2.1", "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		if lines[0][tt.key] != tt.first || lines[1][tt.key] != tt.last {
+			t.Errorf("%s logged as %v and %v, want %v and %v", tt.key, lines[0][tt.key], lines[1][tt.key], tt.first, tt.last)
+		}
+	}
+	if lines[1]["request_id"] == nil {
+		t.Error("a request without X-Request-ID is logged without an id")
+	}
+	// neither the query string nor the body reach the log
+	if strings.Contains(buf.String(), "Rahim") {
+		t.Errorf("personal data in the log: %s", buf)
+	}
+}
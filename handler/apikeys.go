@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"school_api_postgres/auth"
+	"school_api_postgres/models"
+	"school_api_postgres/validation"
+)
+
+// apiKeyRequest ... body of POST /admin/keys, expires_at is optional
+type apiKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// rotateRequest ... optional body of POST /admin/keys/{id}/rotate, overlap is a duration like "24h"
+type rotateRequest struct {
+	Overlap string `json:"overlap"`
+}
+
+// issuedAPIKey ... a new key with its secret, the only response that ever carries it
+type issuedAPIKey struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// GET --every API key, without their secrets
+func (h *Handler) getAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.apiKeys.List(r.Context())
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	if keys == nil {
+		keys = []models.APIKey{}
+	}
+	writeJSON(w, http.StatusOK, keys)
+}
+
+// GET --one API key
+func (h *Handler) getAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	k, err := h.apiKeys.Get(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, r, err, "API key")
+		return
+	}
+	writeJSON(w, http.StatusOK, k)
+}
+
+// POST --issue a key: {"name": "report-service", "scopes": ["grades:read"], "expires_at": "2026-01-01T00:00:00Z"}
+// the response is the only place the secret is ever shown
+func (h *Handler) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req apiKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	if err := validateAPIKeyRequest(req); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	k, secret, err := auth.IssueAPIKey(r.Context(), h.apiKeys, req.Name, req.Scopes, req.ExpiresAt, auth.FromContext(r.Context()).Subject)
+	if err != nil {
+		writeStoreError(w, r, err, "API key")
+		return
+	}
+	writeJSON(w, http.StatusCreated, issuedAPIKey{APIKey: k, Key: secret})
+}
+
+// POST --rotate a key: a replacement with the same name, scopes and lifetime is issued and the old key keeps working
+// for the overlap, 24h unless the body says {"overlap": "1h"}
+func (h *Handler) rotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req rotateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		badRequest(w, r, err.Error())
+		return
+	}
+	overlap := auth.DefaultOverlap
+	if req.Overlap != "" {
+		var err error
+		if overlap, err = time.ParseDuration(req.Overlap); err != nil || overlap < 0 {
+			invalid(w, r, validation.FieldError{Field: "overlap", Code: validation.CodeInvalidFormat,
+				Message: "overlap must be a duration like 30m or 24h"})
+			return
+		}
+	}
+
+	k, secret, err := auth.RotateAPIKey(r.Context(), h.apiKeys, id, overlap, auth.FromContext(r.Context()).Subject)
+	if err != nil {
+		writeStoreError(w, r, err, "API key")
+		return
+	}
+	writeJSON(w, http.StatusCreated, issuedAPIKey{APIKey: k, Key: secret})
+}
+
+// DELETE --revoke a key, it stops working at once but stays listed
+func (h *Handler) revokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if _, err := h.apiKeys.Revoke(r.Context(), id); err != nil {
+		writeStoreError(w, r, err, "API key")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validateAPIKeyRequest(req apiKeyRequest) error {
+	var errs validation.Errors
+	switch {
+	case req.Name == "":
+		errs.Add("name", validation.CodeRequired, "name is required")
+	case len(req.Name) > 100:
+		errs.Add("name", validation.CodeTooLong, "name must be at most 100 characters")
+	}
+	if err := auth.CheckScopes(req.Scopes); err != nil {
+		errs.Add("scopes", validation.CodeInvalidChoice, err.Error())
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		errs.Add("expires_at", validation.CodeOutOfRange, "expires_at must be in the future")
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"school_api_postgres/auth"
+	"school_api_postgres/problem"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	api := newTestAPI(t)
+	rec := api.do("POST", "/api/v1/admin/keys", `{"name":"report-service","scopes":["grades:read"]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("got %d %s, want 201", rec.Code, rec.Body)
+	}
+	var issued issuedAPIKey
+	decode(t, rec, &issued)
+	if issued.Name != "report-service" || issued.CreatedBy != "bootstrap" || !strings.HasPrefix(issued.Key, "sk_"+issued.Prefix+"_") {
+		t.Fatalf("issued %+v", issued)
+	}
+
+	// the secret is shown once, neither the list nor the key itself carry it or its hash
+	for _, path := range []string{"/api/v1/admin/keys", fmt.Sprintf("/api/v1/admin/keys/%d", issued.ID)} {
+		rec := api.do("GET", path, "")
+		if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), issued.Key) ||
+			strings.Contains(rec.Body.String(), auth.HashAPIKey(issued.Key)) || !strings.Contains(rec.Body.String(), issued.Prefix) {
+			t.Errorf("GET %s: %d %s", path, rec.Code, rec.Body)
+		}
+	}
+
+	// and it works right away, within its scopes
+	if rec := api.do("GET", "/api/v1/exams", "", APIKeyHeader, issued.Key); rec.Code != http.StatusOK {
+		t.Errorf("new key refused: %d %s", rec.Code, rec.Body)
+	}
+	if rec := api.do("GET", "/api/v1/students", "", APIKeyHeader, issued.Key); rec.Code != http.StatusForbidden {
+		t.Errorf("new key outside its scopes: %d", rec.Code)
+	}
+}
+
+func TestCreateAPIKeyInvalid(t *testing.T) {
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	tests := []struct {
+		name, body string
+		want       int
+	}{
+		{"no name", `{"scopes":["grades:read"]}`, http.StatusBadRequest},
+		{"name too long", `{"name":"` + strings.Repeat("n", 101) + `","scopes":["grades:read"]}`, http.StatusBadRequest},
+		{"no scopes", `{"name":"x","scopes":[]}`, http.StatusBadRequest},
+		{"unknown scope", `{"name":"x","scopes":["grades:delete"]}`, http.StatusBadRequest},
+		{"expired already", `{"name":"x","scopes":["grades:read"],"expires_at":"` + past + `"}`, http.StatusBadRequest},
+		{"malformed body", `{"name":`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t)
+			rec := api.do("POST", "/api/v1/admin/keys", tt.body)
+			if rec.Code != tt.want {
+				t.Fatalf("got %d %s, want %d", rec.Code, rec.Body, tt.want)
+			}
+			if keys, _ := api.stores.APIKeys.List(t.Context()); len(keys) != 0 {
+				t.Errorf("a refused request stored %+v", keys)
+			}
+		})
+	}
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		oldWorks   bool
+		wantStatus int
+	}{
+		{"default overlap", "", true, http.StatusCreated},
+		{"overlap", `{"overlap":"1h"}`, true, http.StatusCreated},
+		{"no overlap", `{"overlap":"0s"}`, false, http.StatusCreated},
+		{"negative overlap", `{"overlap":"-1h"}`, true, http.StatusBadRequest},
+		{"not a duration", `{"overlap":"a day"}`, true, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t)
+			old, k := issueKey(t, api, nil, auth.ScopeStudentsRead)
+
+			rec := api.do("POST", fmt.Sprintf("/api/v1/admin/keys/%d/rotate", k.ID), tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got %d %s, want %d", rec.Code, rec.Body, tt.wantStatus)
+			}
+			if rec.Code == http.StatusCreated {
+				var issued issuedAPIKey
+				decode(t, rec, &issued)
+				if issued.ID == k.ID || issued.Name != k.Name || len(issued.Scopes) != 1 || issued.Scopes[0] != auth.ScopeStudentsRead {
+					t.Errorf("replacement %+v of %+v", issued, k)
+				}
+				if rec := api.do("GET", "/api/v1/students", "", APIKeyHeader, issued.Key); rec.Code != http.StatusOK {
+					t.Errorf("replacement refused: %d", rec.Code)
+				}
+			}
+			works := api.do("GET", "/api/v1/students", "", APIKeyHeader, old).Code == http.StatusOK
+			if works != tt.oldWorks {
+				t.Errorf("old key works %v, want %v", works, tt.oldWorks)
+			}
+		})
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	api := newTestAPI(t)
+	key, k := issueKey(t, api, nil, auth.ScopeStudentsRead)
+	path := fmt.Sprintf("/api/v1/admin/keys/%d", k.ID)
+
+	if rec := api.do("DELETE", path, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("revoke: %d %s", rec.Code, rec.Body)
+	}
+	if rec := api.do("GET", "/api/v1/students", "", APIKeyHeader, key); rec.Code != http.StatusUnauthorized {
+		t.Errorf("revoked key: %d, want 401", rec.Code)
+	}
+	// a revoked key stays listed and cannot be rotated back to life
+	var listed struct {
+		Revoked bool `json:"revoked"`
+	}
+	decode(t, api.do("GET", path, ""), &listed)
+	if !listed.Revoked {
+		t.Error("revoked key is not listed as revoked")
+	}
+	rec := api.do("POST", path+"/rotate", "")
+	if rec.Code != http.StatusConflict || problemType(t, rec) != problem.TypeKeyRevoked {
+		t.Errorf("rotating a revoked key: %d %s", rec.Code, rec.Body)
+	}
+
+	for _, method := range []string{"GET", "DELETE"} {
+		if rec := api.do(method, "/api/v1/admin/keys/999", ""); rec.Code != http.StatusNotFound {
+			t.Errorf("%s of an unknown key: %d, want 404", method, rec.Code)
+		}
+	}
+}
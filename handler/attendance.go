@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"school_api_postgres/models"
+	"school_api_postgres/store"
+	"school_api_postgres/validation"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// markRequest ... body of POST /attendance, one class on one day
+type markRequest struct {
+	Class   int                       `json:"class"`
+	Date    models.Date               `json:"date"`
+	Records []models.AttendanceRecord `json:"records"`
+}
+
+// POST --mark attendance for a class on a day:
+// {"class": 7, "date": "2025-03-03", "records": [{"student_id": 1, "status": "present"}, {"student_id": 2, "status": "late", "note": "bus"}]}
+// marking the same day again overwrites the earlier records
+func (h *Handler) markAttendance(w http.ResponseWriter, r *http.Request) {
+	var req markRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	var errs validation.Errors
+	errs.Check(validation.ValidateClass(req.Class))
+	errs.Check(validation.ValidateAttendanceDate(req.Date))
+
+	ids := make([]int, len(req.Records))
+	for i, rec := range req.Records {
+		errs.Nested(fmt.Sprintf("records[%d]", i), validation.ValidateAttendanceRecord(rec))
+		ids[i] = rec.StudentID
+	}
+	errs.Check(checkIDs("records", ids))
+	if err := errs.Err(); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	records, err := h.attendance.Mark(r.Context(), req.Class, req.Date, req.Records)
+	if err != nil {
+		writeStoreError(w, r, err, "Attendance")
+		return
+	}
+	slog.InfoContext(r.Context(), "attendance marked", "class", req.Class, "date", req.Date, "count", len(records))
+
+	writeJSON(w, http.StatusCreated, records)
+}
+
+// GET --attendance history of a student, ?from=2025-03-01&to=2025-03-31
+func (h *Handler) getStudentAttendance(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	dates, err := parseDateRange(r.URL.Query())
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	records, err := h.attendance.StudentHistory(r.Context(), id, dates)
+	if err != nil {
+		writeStoreError(w, r, err, "Student")
+		return
+	}
+	if records == nil {
+		records = []models.AttendanceRecord{}
+	}
+	writeJSON(w, http.StatusOK, records)
+}
+
+// GET --attendance counts and percentage of a student, same ?from=&to= as the history
+func (h *Handler) getStudentAttendanceSummary(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	dates, err := parseDateRange(r.URL.Query())
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	summary, err := h.attendance.StudentSummary(r.Context(), id, dates)
+	if err != nil {
+		writeStoreError(w, r, err, "Student")
+		return
+	}
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// GET --attendance of a whole class, overall and per student
+func (h *Handler) getClassAttendanceSummary(w http.ResponseWriter, r *http.Request) {
+	class, err := strconv.Atoi(chi.URLParam(r, "class"))
+	if err != nil {
+		badRequest(w, r, "Invalid class")
+		return
+	}
+	if err := validation.ValidateClass(class); err != nil {
+		invalid(w, r, err)
+		return
+	}
+	dates, err := parseDateRange(r.URL.Query())
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	summary, err := h.attendance.ClassSummary(r.Context(), class, dates)
+	if err != nil {
+		writeStoreError(w, r, err, "Class")
+		return
+	}
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// parseDateRange ... the optional, inclusive ?from= and ?to= of the attendance queries
+func parseDateRange(q url.Values) (store.DateRange, error) {
+	var dates store.DateRange
+	if err := checkParams(q, "from", "to"); err != nil {
+		return dates, err
+	}
+	var err error
+	if dates.From, err = optionalDate(q, "from"); err != nil {
+		return dates, err
+	}
+	if dates.To, err = optionalDate(q, "to"); err != nil {
+		return dates, err
+	}
+	if dates.From != nil && dates.To != nil && dates.From.After(dates.To.Time) {
+		return dates, errors.New("from must not be after to")
+	}
+	return dates, nil
+}
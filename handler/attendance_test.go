@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"school_api_postgres/models"
+	"school_api_postgres/problem"
+)
+
+// newAttendanceAPI ... a test API with Rahim (1) and Karim (2) in class 6 and Sadia (3) in class 8
+func newAttendanceAPI(t *testing.T) *testAPI {
+	t.Helper()
+	api := newTestAPI(t)
+	for _, s := range []string{
+		`{"name":"Rahim","age":12,"class":6}`,
+		`{"name":"Karim","age":12,"class":6}`,
+		`{"name":"Sadia","age":14,"class":8}`,
+	} {
+		if rec := api.do("POST", "/api/v1/students", s); rec.Code != http.StatusCreated {
+			t.Fatalf("student: %d %s", rec.Code, rec.Body)
+		}
+	}
+	return api
+}
+
+// mark ... marks class 6 on date with the status of Rahim and Karim
+func (a *testAPI) mark(date, rahim, karim string) {
+	a.t.Helper()
+	body := fmt.Sprintf(`{"class":6,"date":%q,"records":[{"student_id":1,"status":%q},{"student_id":2,"status":%q}]}`, date, rahim, karim)
+	if rec := a.do("POST", "/api/v1/attendance", body); rec.Code != http.StatusCreated {
+		a.t.Fatalf("mark %s: %d %s", date, rec.Code, rec.Body)
+	}
+}
+
+func TestAttendance(t *testing.T) {
+	api := newAttendanceAPI(t)
+	api.mark("2025-03-03", "present", "absent")
+	api.mark("2025-03-04", "late", "excused")
+	api.mark("2025-03-05", "absent", "present")
+	// marking a day again overwrites it
+	api.mark("2025-03-05", "present", "present")
+
+	var history []models.AttendanceRecord
+	decode(t, api.do("GET", "/api/v1/students/1/attendance?from=2025-03-04", ""), &history)
+	if len(history) != 2 || history[0].Date.String() != "2025-03-04" || history[0].Status != "late" || history[1].Status != "present" {
+		t.Errorf("history from 2025-03-04: %+v", history)
+	}
+
+	// late counts as attended, excused days are left out
+	percent := func(p *float64) string {
+		if p == nil {
+			return "none"
+		}
+		return fmt.Sprint(*p)
+	}
+	var karim models.AttendanceSummary
+	decode(t, api.do("GET", "/api/v1/students/2/attendance/summary", ""), &karim)
+	if karim.Days != 3 || karim.Present != 1 || karim.Absent != 1 || karim.Excused != 1 || percent(karim.Percentage) != "50" {
+		t.Errorf("summary of Karim: %+v, %s%%", karim, percent(karim.Percentage))
+	}
+	var sadia models.AttendanceSummary
+	decode(t, api.do("GET", "/api/v1/students/3/attendance/summary", ""), &sadia)
+	if sadia.Days != 0 || sadia.Percentage != nil {
+		t.Errorf("summary of a student never marked: %+v", sadia)
+	}
+
+	var class models.ClassAttendanceSummary
+	decode(t, api.do("GET", "/api/v1/classes/6/attendance/summary?from=2025-03-01&to=2025-03-31", ""), &class)
+	if class.Overall.Days != 6 || percent(class.Overall.Percentage) != "80" || len(class.Students) != 2 ||
+		percent(class.Students[0].Percentage) != "100" || class.From.String() != "2025-03-01" {
+		t.Errorf("class summary: %+v", class)
+	}
+}
+
+func TestMarkAttendanceRefused(t *testing.T) {
+	tests := []struct {
+		name, body string
+		want       int
+		wantType   string
+	}{
+		{"student of another class", `{"class":6,"date":"2025-03-03","records":[{"student_id":3,"status":"present"}]}`,
+			http.StatusUnprocessableEntity, problem.TypeInvalidReference},
+		{"unknown student", `{"class":6,"date":"2025-03-03","records":[{"student_id":9,"status":"present"}]}`,
+			http.StatusUnprocessableEntity, problem.TypeInvalidReference},
+		{"unknown status", `{"class":6,"date":"2025-03-03","records":[{"student_id":1,"status":"sick"}]}`,
+			http.StatusBadRequest, problem.TypeValidation},
+		{"student twice", `{"class":6,"date":"2025-03-03","records":[{"student_id":1,"status":"present"},{"student_id":1,"status":"absent"}]}`,
+			http.StatusBadRequest, problem.TypeValidation},
+		{"no records", `{"class":6,"date":"2025-03-03","records":[]}`, http.StatusBadRequest, problem.TypeValidation},
+		{"in the future", `{"class":6,"date":"2999-03-03","records":[{"student_id":1,"status":"present"}]}`,
+			http.StatusBadRequest, problem.TypeValidation},
+		{"no date", `{"class":6,"records":[{"student_id":1,"status":"present"}]}`, http.StatusBadRequest, problem.TypeValidation},
+		{"no class", `{"date":"2025-03-03","records":[{"student_id":1,"status":"present"}]}`, http.StatusBadRequest, problem.TypeValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newAttendanceAPI(t)
+			rec := api.do("POST", "/api/v1/attendance", tt.body)
+			if rec.Code != tt.want || problemType(t, rec) != tt.wantType {
+				t.Fatalf("got %d %s, want %d %s", rec.Code, rec.Body, tt.want, tt.wantType)
+			}
+			var history []models.AttendanceRecord
+			decode(t, api.do("GET", "/api/v1/students/1/attendance", ""), &history)
+			if len(history) != 0 {
+				t.Errorf("a refused request marked %+v", history)
+			}
+		})
+	}
+}
+
+func TestAttendanceQueriesRefused(t *testing.T) {
+	api := newAttendanceAPI(t)
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/api/v1/students/1/attendance?from=2025-03-05&to=2025-03-01", http.StatusBadRequest},
+		{"/api/v1/students/1/attendance?from=March", http.StatusBadRequest},
+		{"/api/v1/students/1/attendance?since=2025-03-01", http.StatusBadRequest},
+		{"/api/v1/students/9/attendance", http.StatusNotFound},
+		{"/api/v1/students/9/attendance/summary", http.StatusNotFound},
+		{"/api/v1/classes/six/attendance/summary", http.StatusBadRequest},
+		{"/api/v1/classes/0/attendance/summary", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if rec := api.do("GET", tt.path, ""); rec.Code != tt.want {
+			t.Errorf("GET %s: %d %s, want %d", tt.path, rec.Code, rec.Body, tt.want)
+		}
+	}
+}
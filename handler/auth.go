@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"school_api_postgres/auth"
+	"school_api_postgres/problem"
+	"school_api_postgres/store"
+)
+
+// lastUsedResolution ... last_used_at is only written when it is older than this, not on every request
+const lastUsedResolution = time.Minute
+
+// errBadCredentials ... the key is unknown or wrong, the client is told no more than that
+var errBadCredentials = errors.New("Invalid API Key")
+
+// authenticate puts the principal of the request into its context, or answers 401. Staff send the JWT from
+// their login as Authorization: Bearer, services send an X-API-Key. The bootstrap key from ValidAPIKey is only
+// honoured when that variable is set, it holds every scope and is meant for creating the first real keys
+func (h *Handler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p *auth.Principal
+		var err error
+		var failure string
+		token, bearer := bearerToken(r)
+		key := r.Header.Get(APIKeyHeader)
+		switch {
+		case bearer:
+			p, err = h.tokenPrincipal(token)
+			if err != nil {
+				// RFC 6750, tells the client to get a new token
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			}
+			failure = "bad_token"
+		case key != "":
+			p, err = h.apiKeyPrincipal(r.Context(), key)
+			failure = "bad_api_key"
+		default:
+			authFailures.Inc("missing_credentials")
+			unauthorized(w, r, "Send an API key in the "+APIKeyHeader+" header or a bearer token in the Authorization header")
+			return
+		}
+
+		if err != nil {
+			var reason authError
+			if errors.As(err, &reason) {
+				authFailures.Inc(failure)
+				unauthorized(w, r, reason.Error())
+				return
+			}
+			internalError(w, r, err)
+			return
+		}
+		noteSubject(r, p.Subject)
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), p)))
+	})
+}
+
+// bearerToken ... the token of an Authorization: Bearer header, the scheme is case-insensitive
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// tokenPrincipal ... the staff member a JWT was issued to, with the scopes of their roles
+func (h *Handler) tokenPrincipal(token string) (*auth.Principal, error) {
+	if h.tokens == nil {
+		return nil, authError{errors.New("Bearer tokens are not accepted, send an API key")}
+	}
+	claims, err := h.tokens.Verify(token, time.Now())
+	if err != nil {
+		return nil, authError{err}
+	}
+	return &auth.Principal{
+		// the sub is whatever the issuer put there, the prefix keeps it apart from the subjects of API keys
+		Subject: "jwt:" + claims.Subject,
+		Name:    claims.Name,
+		Roles:   claims.Roles,
+		Scopes:  auth.ScopesForRoles(claims.Roles),
+	}, nil
+}
+
+// authError ... a reason to refuse the credentials that is safe to tell the client
+type authError struct{ error }
+
+func (h *Handler) apiKeyPrincipal(ctx context.Context, key string) (*auth.Principal, error) {
+	if h.bootstrapKey != "" && auth.SecretsEqual(key, h.bootstrapKey) {
+		return &auth.Principal{Subject: "bootstrap", Name: "bootstrap key", Scopes: []string{auth.ScopeAdmin}}, nil
+	}
+
+	prefix, ok := auth.APIKeyPrefix(key)
+	if !ok {
+		return nil, authError{errBadCredentials}
+	}
+	k, err := h.apiKeys.FindByPrefix(ctx, prefix)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, authError{errBadCredentials}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !auth.VerifyAPIKey(key, k.SecretHash) {
+		return nil, authError{errBadCredentials}
+	}
+
+	// the key is genuine from here on, so it is fine to say why it no longer works
+	now := time.Now()
+	if k.Revoked {
+		return nil, authError{errors.New("API key was revoked")}
+	}
+	if k.ExpiresAt != nil && now.After(*k.ExpiresAt) {
+		return nil, authError{errors.New("API key has expired")}
+	}
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) > lastUsedResolution {
+		if err := h.apiKeys.MarkUsed(ctx, k.ID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return &auth.Principal{Subject: fmt.Sprintf("apikey:%d", k.ID), Name: k.Name, Scopes: k.Scopes}, nil
+}
+
+// requireScope ... 403 unless the principal of the request holds scope
+func requireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !auth.FromContext(r.Context()).Has(scope) {
+				authFailures.Inc("missing_scope")
+				problem.Write(w, r, problem.New(http.StatusForbidden, problem.TypeForbidden, "Your credentials lack the "+scope+" scope"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireReadWrite ... requireScope with read for GET and HEAD and write for everything else
+func requireReadWrite(read, write string) func(http.Handler) http.Handler {
+	readOnly, readWrite := requireScope(read), requireScope(write)
+	return func(next http.Handler) http.Handler {
+		reads, writes := readOnly(next), readWrite(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				reads.ServeHTTP(w, r)
+				return
+			}
+			writes.ServeHTTP(w, r)
+		})
+	}
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, detail string) {
+	problem.Write(w, r, problem.New(http.StatusUnauthorized, problem.TypeUnauthorized, detail))
+}
@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"school_api_postgres/auth"
+	"school_api_postgres/models"
+	"school_api_postgres/problem"
+)
+
+const testJWTSecret = "0123456789abcdef0123456789abcdef"
+
+// bearer ... an Authorization header value for a token with roles, signed with secret and expiring at exp
+func bearer(t *testing.T, secret string, exp time.Time, roles ...string) string {
+	t.Helper()
+	t.Setenv("JWT_SECRET", secret)
+	v, err := auth.VerifierFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := v.Sign(auth.Claims{Subject: "7", Name: "Rahim", Roles: roles, ExpiresAt: exp}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + token
+}
+
+func TestBearerRoles(t *testing.T) {
+	const student = `{"name":"Rahim","age":12,"class":6}`
+	hour := time.Now().Add(time.Hour)
+	tests := []struct {
+		name, method, path, body string
+		roles                    []string
+		want                     int
+	}{
+		{"teacher reads students", "GET", "/api/v1/students/1", "", []string{auth.RoleTeacher}, http.StatusOK},
+		{"teacher cannot create students", "POST", "/api/v1/students", student, []string{auth.RoleTeacher}, http.StatusForbidden},
+		{"clerk creates students", "POST", "/api/v1/students", student, []string{auth.RoleClerk}, http.StatusCreated},
+		{"read-only cannot delete", "DELETE", "/api/v1/students/1", "", []string{auth.RoleReadOnly}, http.StatusForbidden},
+		{"admin deletes", "DELETE", "/api/v1/students/1", "", []string{auth.RoleAdmin}, http.StatusNoContent},
+		{"unknown role grants nothing", "GET", "/api/v1/students/1", "", []string{"janitor"}, http.StatusForbidden},
+		{"roles add up", "POST", "/api/v1/students", student, []string{auth.RoleTeacher, auth.RoleClerk}, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := bearer(t, testJWTSecret, hour, tt.roles...)
+			api := newTestAPI(t)
+			api.do("POST", "/api/v1/students", student)
+
+			rec := api.do(tt.method, tt.path, tt.body, "Authorization", token, APIKeyHeader, "")
+			if rec.Code != tt.want {
+				t.Fatalf("got %d %s, want %d", rec.Code, rec.Body, tt.want)
+			}
+			if rec.Code == http.StatusForbidden && problemType(t, rec) != problem.TypeForbidden {
+				t.Errorf("403 is a %q problem", problemType(t, rec))
+			}
+		})
+	}
+}
+
+func TestBearerRefused(t *testing.T) {
+	tests := []struct {
+		name  string
+		token func(t *testing.T) string
+	}{
+		{"expired", func(t *testing.T) string {
+			return bearer(t, testJWTSecret, time.Now().Add(-time.Hour), auth.RoleAdmin)
+		}},
+		{"signed with another secret", func(t *testing.T) string {
+			return bearer(t, strings.Repeat("z", 32), time.Now().Add(time.Hour), auth.RoleAdmin)
+		}},
+		{"alg none", func(t *testing.T) string {
+			// {"alg":"none"}.{"sub":"7","role":"admin","exp":4102444800}.
+			return "Bearer eyJhbGciOiJub25lIn0.eyJzdWIiOiI3Iiwicm9sZSI6ImFkbWluIiwiZXhwIjo0MTAyNDQ0ODAwfQ."
+		}},
+		{"not a token", func(t *testing.T) string { return "Bearer nonsense" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := tt.token(t)
+			t.Setenv("JWT_SECRET", testJWTSecret)
+			api := newTestAPI(t)
+
+			rec := api.do("GET", "/api/v1/students", "", "Authorization", token, APIKeyHeader, "")
+			if rec.Code != http.StatusUnauthorized || problemType(t, rec) != problem.TypeUnauthorized {
+				t.Fatalf("got %d %s, want 401", rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); got != `Bearer error="invalid_token"` {
+				t.Errorf("WWW-Authenticate = %q", got)
+			}
+		})
+	}
+}
+
+func TestBearerWithoutJWTConfigured(t *testing.T) {
+	token := bearer(t, testJWTSecret, time.Now().Add(time.Hour), auth.RoleAdmin)
+	t.Setenv("JWT_SECRET", "")
+	api := newTestAPI(t)
+	if rec := api.do("GET", "/api/v1/students", "", "Authorization", token, APIKeyHeader, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("got %d, want 401", rec.Code)
+	}
+}
+
+func TestBearerSubject(t *testing.T) {
+	token := bearer(t, testJWTSecret, time.Now().Add(time.Hour), auth.RoleTeacher)
+	api := newTestAPI(t)
+	p, err := New(api.stores).tokenPrincipal(strings.TrimPrefix(token, "Bearer "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the sub is chosen by the issuer, it gets a namespace of its own so rate limits, idempotency keys and logs
+	// never mix it up with an API key or another kind of caller
+	if p.Subject != "jwt:7" {
+		t.Errorf("subject of a token with sub 7 is %q, want jwt:7", p.Subject)
+	}
+}
+
+// issueKey ... the secret of a new API key with scopes, expiring at expiresAt unless that is nil
+func issueKey(t *testing.T, api *testAPI, expiresAt *time.Time, scopes ...string) (string, models.APIKey) {
+	t.Helper()
+	k, secret, err := auth.IssueAPIKey(t.Context(), api.stores.APIKeys, "test", scopes, expiresAt, "bootstrap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return secret, k
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	const student = `{"name":"Rahim","age":12,"class":6}`
+	tests := []struct {
+		name, method, path, body string
+		scopes                   []string
+		want                     int
+	}{
+		{"read scope reads", "GET", "/api/v1/students/1", "", []string{auth.ScopeStudentsRead}, http.StatusOK},
+		{"read scope lists", "GET", "/api/v1/students", "", []string{auth.ScopeStudentsRead}, http.StatusOK},
+		{"read scope cannot write", "DELETE", "/api/v1/students/1", "", []string{auth.ScopeStudentsRead}, http.StatusForbidden},
+		{"write scope cannot read", "GET", "/api/v1/students/1", "", []string{auth.ScopeStudentsWrite}, http.StatusForbidden},
+		{"write scope creates", "POST", "/api/v1/students", student, []string{auth.ScopeStudentsWrite}, http.StatusCreated},
+		{"other resource", "GET", "/api/v1/teachers", "", []string{auth.ScopeStudentsRead}, http.StatusForbidden},
+		{"admin reads everything", "GET", "/api/v1/students/1/guardians", "", []string{auth.ScopeAdmin}, http.StatusOK},
+		{"only admin manages keys", "GET", "/api/v1/admin/keys", "", []string{auth.ScopeStudentsRead, auth.ScopeStudentsWrite}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t)
+			api.do("POST", "/api/v1/students", student)
+			key, _ := issueKey(t, api, nil, tt.scopes...)
+
+			rec := api.do(tt.method, tt.path, tt.body, APIKeyHeader, key)
+			if rec.Code != tt.want {
+				t.Fatalf("got %d %s, want %d", rec.Code, rec.Body, tt.want)
+			}
+			if rec.Code == http.StatusForbidden && problemType(t, rec) != problem.TypeForbidden {
+				t.Errorf("403 is a %q problem", problemType(t, rec))
+			}
+		})
+	}
+}
+
+func TestAPIKeyRefused(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        func(t *testing.T, api *testAPI) string
+		wantDetail string
+	}{
+		{"no credentials", func(*testing.T, *testAPI) string { return "" }, ""},
+		{"not shaped like a key", func(*testing.T, *testAPI) string { return "let-me-in" }, "Invalid API Key"},
+		{"unknown prefix", func(*testing.T, *testAPI) string { return "sk_00000000_secret" }, "Invalid API Key"},
+		{"wrong secret", func(t *testing.T, api *testAPI) string {
+			key, _ := issueKey(t, api, nil, auth.ScopeStudentsRead)
+			return key[:len(key)-2] + "xx"
+		}, "Invalid API Key"},
+		// a genuine key may be told why it stopped working
+		{"revoked", func(t *testing.T, api *testAPI) string {
+			key, k := issueKey(t, api, nil, auth.ScopeStudentsRead)
+			if _, err := api.stores.APIKeys.Revoke(t.Context(), k.ID); err != nil {
+				t.Fatal(err)
+			}
+			return key
+		}, "API key was revoked"},
+		{"expired", func(t *testing.T, api *testAPI) string {
+			past := time.Now().Add(-time.Minute)
+			key, _ := issueKey(t, api, &past, auth.ScopeStudentsRead)
+			return key
+		}, "API key has expired"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t)
+			key := tt.key(t, api)
+			rec := &testResponse{api.do("GET", "/api/v1/students", "", APIKeyHeader, key)}
+			if rec.Code != http.StatusUnauthorized || problemType(t, rec.ResponseRecorder) != problem.TypeUnauthorized {
+				t.Fatalf("got %d %s, want 401", rec.Code, rec.Body)
+			}
+			if tt.wantDetail != "" && rec.detail(t) != tt.wantDetail {
+				t.Errorf("detail %q, want %q", rec.detail(t), tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestBootstrapKeyUnset(t *testing.T) {
+	api := newTestAPI(t)
+	t.Setenv("ValidAPIKey", "")
+	api.routes = New(api.stores).Routes()
+	// without ValidAPIKey there is no bootstrap key, not even an empty one
+	if rec := api.do("GET", "/api/v1/students", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("bootstrap key without ValidAPIKey: %d, want 401", rec.Code)
+	}
+}
+
+func TestAPIKeyLastUsed(t *testing.T) {
+	api := newTestAPI(t)
+	key, k := issueKey(t, api, nil, auth.ScopeStudentsRead)
+	api.do("GET", "/api/v1/students", "", APIKeyHeader, key)
+	if k, _ = api.stores.APIKeys.Get(t.Context(), k.ID); k.LastUsedAt == nil {
+		t.Error("last_used_at not set by a request")
+	}
+}
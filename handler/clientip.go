@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// parseTrustedProxies reads TRUSTED_PROXIES, a comma separated list of CIDRs like 10.42.0.0/16, a bare address
+// stands for itself
+func parseTrustedProxies(s string) ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !strings.Contains(field, "/") {
+			addr, err := netip.ParseAddr(field)
+			if err != nil {
+				return nil, fmt.Errorf("TRUSTED_PROXIES: %q is not an address or a CIDR", field)
+			}
+			field = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()).String()
+		}
+		prefix, err := netip.ParsePrefix(field)
+		if err != nil {
+			return nil, fmt.Errorf("TRUSTED_PROXIES: %q is not an address or a CIDR", field)
+		}
+		proxies = append(proxies, prefix.Masked())
+	}
+	return proxies, nil
+}
+
+// trusted ... whether addr belongs to one of the proxies in front of the API
+func (h *Handler) trusted(addr netip.Addr) bool {
+	for _, p := range h.trustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP ... the address the request came from. The forwarding headers are only believed when the peer is a
+// trusted proxy, then the chain is walked from the right and the first hop that is not a proxy is the client,
+// anything further left could have been made up by the client. Forwarded wins over X-Forwarded-For, which wins
+// over X-Real-IP
+func (h *Handler) clientIP(r *http.Request) netip.Addr {
+	peer, ok := parseNode(r.RemoteAddr)
+	if !ok || !h.trusted(peer) {
+		return peer
+	}
+
+	var chain []string
+	if values := r.Header.Values("Forwarded"); len(values) > 0 {
+		chain = forwardedFor(strings.Join(values, ","))
+	} else if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+		chain = strings.Split(strings.Join(values, ","), ",")
+	} else if real := r.Header.Get("X-Real-IP"); real != "" {
+		chain = []string{real}
+	}
+
+	client := peer
+	for i := len(chain) - 1; i >= 0; i-- {
+		addr, ok := parseNode(chain[i])
+		if !ok {
+			// unknown, obfuscated or garbage: the last hop we could read is as far as we can tell
+			break
+		}
+		client = addr
+		if !h.trusted(addr) {
+			break
+		}
+	}
+	return client
+}
+
+// forwardedFor ... the for= parameters of a Forwarded header (RFC 7239), one per hop
+func forwardedFor(header string) []string {
+	var nodes []string
+	for _, element := range strings.Split(header, ",") {
+		node := ""
+		for _, pair := range strings.Split(element, ";") {
+			name, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+			if strings.EqualFold(name, "for") {
+				node = strings.Trim(value, `"`)
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// parseNode reads 192.0.2.1, 192.0.2.1:4711, 2001:db8::1 or [2001:db8::1]:4711, IPv4 mapped IPv6 addresses come out as IPv4
+func parseNode(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// clientKey ... what the rate limits of an unauthenticated caller are counted under. An IPv6 client usually has a
+// whole /64 to pick addresses from, so the /64 is counted as one client
+func (h *Handler) clientKey(r *http.Request) string {
+	addr := h.clientIP(r)
+	switch {
+	case !addr.IsValid():
+		return "ip:unknown"
+	case addr.Is6():
+		prefix, _ := addr.Prefix(64)
+		return "ip:" + prefix.String()
+	default:
+		return "ip:" + addr.String()
+	}
+}
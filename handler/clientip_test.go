@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"10.42.0.0/16", []string{"10.42.0.0/16"}, false},
+		{" 10.42.7.9/16 , 192.0.2.1,, 2001:db8::/32 ", []string{"10.42.0.0/16", "192.0.2.1/32", "2001:db8::/32"}, false},
+		{"::ffff:192.0.2.1", []string{"192.0.2.1/32"}, false},
+		{"2001:db8::1", []string{"2001:db8::1/128"}, false},
+		{"10.42.0.0/33", nil, true},
+		{"proxy.internal", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			proxies, err := parseTrustedProxies(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTrustedProxies = %v, want error %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, p := range proxies {
+				got = append(got, p.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseTrustedProxies = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, err := parseTrustedProxies("10.0.0.0/8, 2001:db8:ffff::/48")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &Handler{trustedProxies: proxies}
+
+	tests := []struct {
+		name    string
+		peer    string
+		headers []string
+		want    string
+	}{
+		{"no proxy", "198.51.100.7:4711", nil, "198.51.100.7"},
+		{"untrusted peer, headers ignored", "198.51.100.7:4711", []string{"X-Forwarded-For", "203.0.113.9"}, "198.51.100.7"},
+		{"trusted proxy, no headers", "10.0.0.1:4711", nil, "10.0.0.1"},
+		{"trusted proxy", "10.0.0.1:4711", []string{"X-Forwarded-For", "203.0.113.9"}, "203.0.113.9"},
+		// the client made up the left entry, the right one was written by our proxy
+		{"spoofed entry left of the client", "10.0.0.1:4711", []string{"X-Forwarded-For", "1.2.3.4, 203.0.113.9"}, "203.0.113.9"},
+		{"chain of proxies", "10.0.0.1:4711", []string{"X-Forwarded-For", "203.0.113.9, 10.1.1.1,10.2.2.2"}, "203.0.113.9"},
+		{"only proxies", "10.0.0.1:4711", []string{"X-Forwarded-For", "10.1.1.1, 10.2.2.2"}, "10.1.1.1"},
+		{"garbage stops the walk", "10.0.0.1:4711", []string{"X-Forwarded-For", "203.0.113.9, garbage, 10.2.2.2"}, "10.2.2.2"},
+		{"real ip", "10.0.0.1:4711", []string{"X-Real-IP", "203.0.113.9"}, "203.0.113.9"},
+		{"forwarded for over real ip", "10.0.0.1:4711", []string{"X-Forwarded-For", "203.0.113.9", "X-Real-IP", "192.0.2.5"}, "203.0.113.9"},
+		{"forwarded over forwarded for", "10.0.0.1:4711",
+			[]string{"Forwarded", `for=192.0.2.60;proto=https, for="10.3.3.3:80"`, "X-Forwarded-For", "203.0.113.9"}, "192.0.2.60"},
+		{"forwarded ipv6", "10.0.0.1:4711", []string{"Forwarded", `For="[2001:db8:cafe::17]:4711"`}, "2001:db8:cafe::17"},
+		{"forwarded unknown", "10.0.0.1:4711", []string{"Forwarded", "for=unknown"}, "10.0.0.1"},
+		{"ipv6 proxy", "[2001:db8:ffff::1]:4711", []string{"X-Forwarded-For", "2001:db8:cafe::17"}, "2001:db8:cafe::17"},
+		{"ipv4 mapped peer", "[::ffff:10.0.0.1]:4711", []string{"X-Forwarded-For", "::ffff:203.0.113.9"}, "203.0.113.9"},
+		{"unreadable peer", "somewhere", []string{"X-Forwarded-For", "203.0.113.9"}, "invalid IP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.peer
+			for i := 0; i+1 < len(tt.headers); i += 2 {
+				r.Header.Set(tt.headers[i], tt.headers[i+1])
+			}
+			if got := h.clientIP(r); got.String() != tt.want {
+				t.Errorf("clientIP = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientKey(t *testing.T) {
+	h := &Handler{}
+	tests := []struct {
+		peer, want string
+	}{
+		{"198.51.100.7:4711", "ip:198.51.100.7"},
+		{"[::ffff:198.51.100.7]:4711", "ip:198.51.100.7"},
+		// every address of an IPv6 /64 is the same client
+		{"[2001:db8:cafe:1::17]:4711", "ip:2001:db8:cafe:1::/64"},
+		{"[2001:db8:cafe:1:ffff::1]:4711", "ip:2001:db8:cafe:1::/64"},
+		{"[2001:db8:cafe:2::17]:4711", "ip:2001:db8:cafe:2::/64"},
+		{"", "ip:unknown"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tt.peer
+		if got := h.clientKey(r); got != tt.want {
+			t.Errorf("clientKey with peer %q = %q, want %q", tt.peer, got, tt.want)
+		}
+	}
+}
+
+func TestParseNode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want netip.Addr
+		ok   bool
+	}{
+		{"192.0.2.1", netip.MustParseAddr("192.0.2.1"), true},
+		{" 192.0.2.1:4711 ", netip.MustParseAddr("192.0.2.1"), true},
+		{"2001:db8::1", netip.MustParseAddr("2001:db8::1"), true},
+		{"[2001:db8::1]", netip.MustParseAddr("2001:db8::1"), true},
+		{"[2001:db8::1]:4711", netip.MustParseAddr("2001:db8::1"), true},
+		{"::ffff:192.0.2.1", netip.MustParseAddr("192.0.2.1"), true},
+		{"_hidden", netip.Addr{}, false},
+		{"", netip.Addr{}, false},
+	}
+	for _, tt := range tests {
+		if got, ok := parseNode(tt.in); got != tt.want || ok != tt.ok {
+			t.Errorf("parseNode(%q) = %v, %v, want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"school_api_postgres/models"
+	"school_api_postgres/problem"
+	"school_api_postgres/store"
+	"school_api_postgres/validation"
+	"strconv"
+	"strings"
+)
+
+// GET --get all courses, ?teacher_id=3&name=math&sort=code with page/limit or cursor/limit
+func (h *Handler) getCoursesAll(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if err := checkParams(q, "page", "limit", "cursor", "sort", "teacher_id", "name"); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	var filter store.CourseFilter
+	var err error
+	if filter.TeacherID, err = optionalInt(q, "teacher_id"); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	filter.NameContains = q.Get("name")
+
+	sort, err := parseSort(q.Get("sort"), store.CourseSortFields)
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	courses, ok := listPage(w, r, h.cursors, sort,
+		func(opts store.ListOptions) ([]models.Course, int, error) {
+			return h.courses.List(r.Context(), filter, opts)
+		}, store.CourseCursor)
+	if !ok {
+		return
+	}
+	slog.DebugContext(r.Context(), "courses listed", "count", len(courses))
+}
+
+// POST --insert a course
+func (h *Handler) createCourse(w http.ResponseWriter, r *http.Request) {
+	var c models.Course
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	if err := validation.ValidateCourse(c); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	c, err := h.courses.Create(r.Context(), c)
+	if err != nil {
+		writeStoreError(w, r, err, "Course")
+		return
+	}
+	slog.InfoContext(r.Context(), "course created", "course_id", c.ID)
+
+	writeJSON(w, http.StatusCreated, c)
+}
+
+// GET --get a single course
+func (h *Handler) getCourseOne(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	c, err := h.courses.Get(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, r, err, "Course")
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+// PUT --replace a course
+func (h *Handler) updateCourse(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var c models.Course
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	if err := validation.ValidateCourse(c); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	if err := h.courses.Update(r.Context(), id, c); err != nil {
+		writeStoreError(w, r, err, "Course")
+		return
+	}
+	slog.InfoContext(r.Context(), "course updated", "course_id", id)
+
+	c.ID = id
+	writeJSON(w, http.StatusOK, c)
+}
+
+// DELETE --delete a course together with its enrollments
+func (h *Handler) deleteCourse(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.courses.Delete(r.Context(), id); err != nil {
+		writeStoreError(w, r, err, "Course")
+		return
+	}
+	slog.InfoContext(r.Context(), "course deleted", "course_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// enrollRequest ... body of the enrollment endpoints, student_id for one and student_ids for many
+type enrollRequest struct {
+	StudentID  int   `json:"student_id"`
+	StudentIDs []int `json:"student_ids"`
+}
+
+// POST --enroll one student: {"student_id": 4}
+func (h *Handler) enrollStudent(w http.ResponseWriter, r *http.Request) {
+	var req enrollRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	if req.StudentID <= 0 {
+		invalid(w, r, validation.FieldError{Field: "student_id", Code: validation.CodeRequired, Message: "provide student_id"})
+		return
+	}
+
+	enrollments, ok := h.enroll(w, r, []int{req.StudentID})
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusCreated, enrollments[0])
+}
+
+// POST --enroll many students at once: {"student_ids": [4, 5, 6]}, all or nothing
+func (h *Handler) enrollStudentsBulk(w http.ResponseWriter, r *http.Request) {
+	var req enrollRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	if err := checkIDs("student_ids", req.StudentIDs); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	enrollments, ok := h.enroll(w, r, req.StudentIDs)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusCreated, enrollments)
+}
+
+// enroll ... shared by the single and bulk endpoints, writes the error response itself
+func (h *Handler) enroll(w http.ResponseWriter, r *http.Request, studentIDs []int) ([]models.Enrollment, bool) {
+	courseID, ok := pathID(w, r, "id")
+	if !ok {
+		return nil, false
+	}
+
+	enrollments, err := h.courses.Enroll(r.Context(), courseID, studentIDs)
+	switch {
+	case errors.Is(err, store.ErrConflict):
+		problem.Write(w, r, problem.New(http.StatusConflict, problem.TypeConflict, "Student is already enrolled in this course"))
+		return nil, false
+	case errors.Is(err, store.ErrInvalidReference):
+		problem.Write(w, r, problem.New(http.StatusUnprocessableEntity, problem.TypeInvalidReference, "Student not found"))
+		return nil, false
+	case err != nil:
+		writeStoreError(w, r, err, "Course")
+		return nil, false
+	}
+	slog.InfoContext(r.Context(), "students enrolled", "course_id", courseID, "count", len(enrollments))
+	return enrollments, true
+}
+
+// DELETE --unenroll one student from a course
+func (h *Handler) unenrollStudent(w http.ResponseWriter, r *http.Request) {
+	courseID, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	studentID, ok := pathID(w, r, "studentID")
+	if !ok {
+		return
+	}
+
+	if err := h.courses.Unenroll(r.Context(), courseID, []int{studentID}); err != nil {
+		writeStoreError(w, r, err, "Enrollment")
+		return
+	}
+	slog.InfoContext(r.Context(), "student unenrolled", "course_id", courseID, "student_id", studentID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DELETE --unenroll many students: ?student_ids=4,5,6, all or nothing
+func (h *Handler) unenrollStudentsBulk(w http.ResponseWriter, r *http.Request) {
+	courseID, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	if err := checkParams(q, "student_ids"); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	var ids []int
+	for _, part := range strings.Split(q.Get("student_ids"), ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			badRequest(w, r, "student_ids must be a comma separated list of ids")
+			return
+		}
+		ids = append(ids, id)
+	}
+	if err := checkIDs("student_ids", ids); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	if err := h.courses.Unenroll(r.Context(), courseID, ids); err != nil {
+		writeStoreError(w, r, err, "Enrollment")
+		return
+	}
+	slog.InfoContext(r.Context(), "students unenrolled", "course_id", courseID, "count", len(ids))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET --roster of a course
+func (h *Handler) getCourseStudents(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	students, err := h.courses.Roster(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, r, err, "Course")
+		return
+	}
+	if students == nil {
+		students = []models.Student{}
+	}
+	writeJSON(w, http.StatusOK, students)
+}
+
+// GET --courses a student takes
+func (h *Handler) getStudentCourses(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	courses, err := h.courses.StudentCourses(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, r, err, "Student")
+		return
+	}
+	if courses == nil {
+		courses = []models.Course{}
+	}
+	writeJSON(w, http.StatusOK, courses)
+}
+
+// checkIDs ... a non-empty list of positive ids without repeats, field names the list in the error
+func checkIDs(field string, ids []int) error {
+	if len(ids) == 0 {
+		return validation.FieldError{Field: field, Code: validation.CodeRequired, Message: "provide at least one student id"}
+	}
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if id <= 0 {
+			return validation.FieldError{Field: field, Code: validation.CodeOutOfRange, Message: fmt.Sprintf("invalid student id %d", id)}
+		}
+		if seen[id] {
+			return validation.FieldError{Field: field, Code: validation.CodeInvalid, Message: fmt.Sprintf("student id %d is listed twice", id)}
+		}
+		seen[id] = true
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"school_api_postgres/models"
+	"school_api_postgres/problem"
+)
+
+// newCourseAPI ... a test API with students 1 to 3, teacher 1 and course 1 taught by them
+func newCourseAPI(t *testing.T) *testAPI {
+	t.Helper()
+	api := newTestAPI(t)
+	for _, name := range []string{"Rahim", "Karim", "Sadia"} {
+		if rec := api.do("POST", "/api/v1/students", `{"name":"`+name+`","age":12,"class":6}`); rec.Code != http.StatusCreated {
+			t.Fatalf("student: %d %s", rec.Code, rec.Body)
+		}
+	}
+	if rec := api.do("POST", "/api/v1/teachers", `{"name":"Nasrin","email":"nasrin@school.test","subjects":["math"],"hire_date":"2019-08-01"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("teacher: %d %s", rec.Code, rec.Body)
+	}
+	if rec := api.do("POST", "/api/v1/courses", `{"code":"MATH-6","name":"Mathematics","teacher_id":1}`); rec.Code != http.StatusCreated {
+		t.Fatalf("course: %d %s", rec.Code, rec.Body)
+	}
+	return api
+}
+
+// names ... the names in a JSON array of students or courses
+func names(t *testing.T, api *testAPI, path string) string {
+	t.Helper()
+	rec := api.do("GET", path, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s: %d %s", path, rec.Code, rec.Body)
+	}
+	var items []struct {
+		Name string `json:"name"`
+	}
+	decode(t, rec, &items)
+	var out []string
+	for _, it := range items {
+		out = append(out, it.Name)
+	}
+	return fmt.Sprint(out)
+}
+
+func TestCourseCRUD(t *testing.T) {
+	api := newCourseAPI(t)
+
+	var c models.Course
+	decode(t, api.do("GET", "/api/v1/courses/1", ""), &c)
+	if c.Code != "MATH-6" || c.TeacherID == nil || *c.TeacherID != 1 {
+		t.Fatalf("get: %+v", c)
+	}
+	if rec := api.do("PUT", "/api/v1/courses/1", `{"code":"MATH-6","name":"Mathematics 6","teacher_id":null}`); rec.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", rec.Code, rec.Body)
+	}
+	decode(t, api.do("GET", "/api/v1/courses/1", ""), &c)
+	if c.Name != "Mathematics 6" || c.TeacherID != nil {
+		t.Errorf("after update: %+v", c)
+	}
+
+	api.do("POST", "/api/v1/courses/1/enrollments", `{"student_id":1}`)
+	if rec := api.do("DELETE", "/api/v1/courses/1", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: %d %s", rec.Code, rec.Body)
+	}
+	// the enrollments went with the course
+	if got := names(t, api, "/api/v1/students/1/courses"); got != "[]" {
+		t.Errorf("courses of a student after the course was deleted: %s", got)
+	}
+}
+
+func TestCourseRefused(t *testing.T) {
+	tests := []struct {
+		name, method, path, body string
+		want                     int
+		wantType                 string
+	}{
+		{"code taken", "POST", "/api/v1/courses", `{"code":"MATH-6","name":"Again"}`, http.StatusConflict, problem.TypeConflict},
+		{"unknown teacher", "POST", "/api/v1/courses", `{"code":"BAN-6","name":"Bangla","teacher_id":9}`,
+			http.StatusUnprocessableEntity, problem.TypeInvalidReference},
+		{"space in code", "POST", "/api/v1/courses", `{"code":"BAN 6","name":"Bangla"}`, http.StatusBadRequest, problem.TypeValidation},
+		{"no name", "POST", "/api/v1/courses", `{"code":"BAN-6"}`, http.StatusBadRequest, problem.TypeValidation},
+		{"unknown course", "GET", "/api/v1/courses/9", "", http.StatusNotFound, problem.TypeNotFound},
+		{"bad teacher filter", "GET", "/api/v1/courses?teacher_id=one", "", http.StatusBadRequest, problem.TypeBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newCourseAPI(t)
+			rec := api.do(tt.method, tt.path, tt.body)
+			if rec.Code != tt.want || problemType(t, rec) != tt.wantType {
+				t.Errorf("got %d %s, want %d %s", rec.Code, rec.Body, tt.want, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestEnrollments(t *testing.T) {
+	api := newCourseAPI(t)
+
+	if rec := api.do("POST", "/api/v1/courses/1/enrollments", `{"student_id":2}`); rec.Code != http.StatusCreated {
+		t.Fatalf("enroll: %d %s", rec.Code, rec.Body)
+	}
+	if rec := api.do("POST", "/api/v1/courses/1/enrollments/bulk", `{"student_ids":[1,3]}`); rec.Code != http.StatusCreated {
+		t.Fatalf("bulk enroll: %d %s", rec.Code, rec.Body)
+	}
+	// the roster is sorted by name
+	if got := names(t, api, "/api/v1/courses/1/students"); got != "[Karim Rahim Sadia]" {
+		t.Errorf("roster %s", got)
+	}
+	if got := names(t, api, "/api/v1/students/2/courses"); got != "[Mathematics]" {
+		t.Errorf("courses of Karim %s", got)
+	}
+
+	if rec := api.do("DELETE", "/api/v1/courses/1/enrollments/2", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("unenroll: %d %s", rec.Code, rec.Body)
+	}
+	// all or nothing, 2 is no longer enrolled so 3 stays
+	if rec := api.do("DELETE", "/api/v1/courses/1/enrollments?student_ids=3,2", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("bulk unenroll with a student not enrolled: %d", rec.Code)
+	}
+	if rec := api.do("DELETE", "/api/v1/courses/1/enrollments?student_ids=1,3", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("bulk unenroll: %d %s", rec.Code, rec.Body)
+	}
+	if got := names(t, api, "/api/v1/courses/1/students"); got != "[]" {
+		t.Errorf("roster after unenrolling everyone %s", got)
+	}
+}
+
+func TestEnrollRefused(t *testing.T) {
+	tests := []struct {
+		name, path, body string
+		want             int
+		wantType         string
+	}{
+		{"already enrolled", "/api/v1/courses/1/enrollments", `{"student_id":1}`, http.StatusConflict, problem.TypeConflict},
+		{"enrolled in the batch", "/api/v1/courses/1/enrollments/bulk", `{"student_ids":[2,1]}`, http.StatusConflict, problem.TypeConflict},
+		{"unknown student", "/api/v1/courses/1/enrollments", `{"student_id":9}`, http.StatusUnprocessableEntity, problem.TypeInvalidReference},
+		{"unknown course", "/api/v1/courses/9/enrollments", `{"student_id":2}`, http.StatusNotFound, problem.TypeNotFound},
+		{"no student", "/api/v1/courses/1/enrollments", `{}`, http.StatusBadRequest, problem.TypeValidation},
+		{"empty batch", "/api/v1/courses/1/enrollments/bulk", `{"student_ids":[]}`, http.StatusBadRequest, problem.TypeValidation},
+		{"repeated id", "/api/v1/courses/1/enrollments/bulk", `{"student_ids":[2,2]}`, http.StatusBadRequest, problem.TypeValidation},
+		{"negative id", "/api/v1/courses/1/enrollments/bulk", `{"student_ids":[-2]}`, http.StatusBadRequest, problem.TypeValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newCourseAPI(t)
+			api.do("POST", "/api/v1/courses/1/enrollments", `{"student_id":1}`)
+			rec := api.do("POST", tt.path, tt.body)
+			if rec.Code != tt.want || problemType(t, rec) != tt.wantType {
+				t.Fatalf("got %d %s, want %d %s", rec.Code, rec.Body, tt.want, tt.wantType)
+			}
+			// a refused batch enrolls nobody
+			if got := names(t, api, "/api/v1/students/2/courses"); got != "[]" {
+				t.Errorf("Karim enrolled in %s", got)
+			}
+		})
+	}
+}
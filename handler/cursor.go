@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"school_api_postgres/store"
+)
+
+var errBadCursor = errors.New("invalid or tampered cursor")
+
+// cursorSigner turns store cursors into opaque tokens and back
+// the HMAC stops clients from forging positions, the sort is embedded so a cursor cannot be replayed under another sort
+type cursorSigner struct {
+	key []byte
+}
+
+// cursorPayload ... what is inside the token
+type cursorPayload struct {
+	Sort   string        `json:"s"`
+	Values []interface{} `json:"v"`
+}
+
+// minCursorSecret ... shorter CURSOR_SECRETs are accepted but logged, they can be guessed
+const minCursorSecret = 32
+
+// newCursorSigner ... every replica needs the same secret or cursors only work on the pod that issued them.
+// A placeholder like change-me-cursor-secret is refused, it is public and anyone could forge cursors with it
+func newCursorSigner(secret string) (*cursorSigner, error) {
+	if secret == "" {
+		slog.Warn("CURSOR_SECRET is not set, using a random key: cursors will not survive a restart or work across replicas")
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate cursor key: %w", err)
+		}
+		return &cursorSigner{key: key}, nil
+	}
+	if strings.HasPrefix(strings.ToLower(secret), "change-me") {
+		return nil, errors.New("CURSOR_SECRET is still the change-me placeholder, set a random value of at least 32 bytes")
+	}
+	if len(secret) < minCursorSecret {
+		slog.Warn("CURSOR_SECRET is shorter than 32 bytes, cursors signed with it can be forged by guessing it")
+	}
+	return &cursorSigner{key: []byte(secret)}, nil
+}
+
+// encode ... base64url(payload) + "." + base64url(hmac)
+func (c *cursorSigner) encode(cur store.Cursor, sort []store.SortField) string {
+	payload, _ := json.Marshal(cursorPayload{Sort: sortKey(sort), Values: cur.Values})
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(c.sign(payload))
+}
+
+// decode verifies the signature and that the cursor was issued for the same sort
+func (c *cursorSigner) decode(token string, sort []store.SortField) (*store.Cursor, error) {
+	payloadPart, sigPart, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, errBadCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(payloadPart)
+	if err != nil {
+		return nil, errBadCursor
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(sigPart)
+	if err != nil || !hmac.Equal(sig, c.sign(payload)) {
+		return nil, errBadCursor
+	}
+
+	var p cursorPayload
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err := dec.Decode(&p); err != nil {
+		return nil, errBadCursor
+	}
+	if p.Sort != sortKey(sort) {
+		return nil, errors.New("cursor was issued for a different sort, start again without a cursor")
+	}
+
+	// json.Number back to int so both stores compare like with like
+	for i, v := range p.Values {
+		if n, ok := v.(json.Number); ok {
+			iv, err := n.Int64()
+			if err != nil {
+				return nil, errBadCursor
+			}
+			p.Values[i] = int(iv)
+		}
+	}
+	return &store.Cursor{Values: p.Values}, nil
+}
+
+func (c *cursorSigner) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// sortKey ... canonical text form of the effective sort, e.g. "class,-name,id"
+func sortKey(sort []store.SortField) string {
+	var parts []string
+	for _, f := range store.KeysetSort(sort) {
+		if f.Desc {
+			parts = append(parts, "-"+f.Field)
+		} else {
+			parts = append(parts, f.Field)
+		}
+	}
+	return strings.Join(parts, ",")
+}
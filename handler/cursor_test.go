@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"school_api_postgres/store"
+)
+
+const testCursorSecret = "0123456789abcdef0123456789abcdef"
+
+func TestNewCursorSigner(t *testing.T) {
+	tests := []struct {
+		secret  string
+		wantErr bool
+	}{
+		{"", false},
+		{testCursorSecret, false},
+		{"short", false},
+		{"change-me-cursor-secret", true},
+		{"CHANGE-ME", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.secret, func(t *testing.T) {
+			if _, err := newCursorSigner(tt.secret); (err != nil) != tt.wantErr {
+				t.Errorf("newCursorSigner(%q) = %v, want error %v", tt.secret, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	signer, _ := newCursorSigner(testCursorSecret)
+	other, _ := newCursorSigner(strings.Repeat("x", 32))
+	byName := []store.SortField{{Field: "name"}}
+	byAge := []store.SortField{{Field: "age", Desc: true}}
+	token := signer.encode(store.Cursor{Values: []interface{}{"Rahim", 7}}, byName)
+
+	cur, err := signer.decode(token, byName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(cur.Values) != "[Rahim 7]" {
+		t.Errorf("values = %#v, want Rahim and the int 7", cur.Values)
+	}
+	if _, ok := cur.Values[1].(int); !ok {
+		t.Errorf("id came back as %T, want int", cur.Values[1])
+	}
+
+	payload, sig, _ := strings.Cut(token, ".")
+	tests := []struct {
+		name   string
+		signer *cursorSigner
+		token  string
+		sort   []store.SortField
+	}{
+		{"other sort", signer, token, byAge},
+		{"other secret", other, token, byName},
+		{"no signature", signer, payload, byName},
+		{"tampered payload", signer, "x" + payload + "." + sig, byName},
+		{"garbage", signer, "!!.!!", byName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.signer.decode(tt.token, tt.sort); err == nil {
+				t.Error("decode accepted the cursor")
+			}
+		})
+	}
+}
+
+func TestStudentListCursorPaging(t *testing.T) {
+	t.Setenv("CURSOR_SECRET", testCursorSecret)
+	api := newTestAPI(t)
+	for _, name := range []string{"Esha", "Arif", "Dina", "Bina", "Chayan"} {
+		api.do("POST", "/api/v1/students", `{"name":"`+name+`","age":12,"class":6}`)
+	}
+
+	var names []string
+	path := "/api/v1/students?sort=name&limit=2&cursor="
+	for pages := 0; path != ""; pages++ {
+		if pages > 5 {
+			t.Fatal("cursor paging does not end")
+		}
+		rec := api.do("GET", path, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: %d %s", path, rec.Code, rec.Body)
+		}
+		var env struct {
+			Data []struct {
+				Name string `json:"name"`
+			} `json:"data"`
+			Next string `json:"next"`
+		}
+		decode(t, rec, &env)
+		for _, s := range env.Data {
+			names = append(names, s.Name)
+		}
+		path = env.Next
+	}
+	if got := strings.Join(names, ","); got != "Arif,Bina,Chayan,Dina,Esha" {
+		t.Errorf("paged through %s", got)
+	}
+
+	rec := api.do("GET", "/api/v1/students?sort=name&cursor="+url.QueryEscape("bm9wZQ.bm9wZQ"), "")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("forged cursor got %d, want 400", rec.Code)
+	}
+	rec = api.do("GET", "/api/v1/students?page=2&cursor=", "")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("page and cursor together got %d, want 400", rec.Code)
+	}
+}
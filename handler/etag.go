@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"school_api_postgres/problem"
+)
+
+// etag ... the strong ETag of a versioned row, its version in quotes
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// bodyETag ... weak ETag of a list response, the hash of what would be sent
+func bodyETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// ifMatch reads the version a write is conditional on, 0 when the client did not ask for a check
+// with REQUIRE_IF_MATCH set a write without If-Match gets 428, an If-Match that names no version of
+// ours can never match and gets 412 straight away
+func (h *Handler) ifMatch(w http.ResponseWriter, r *http.Request) (int, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		if h.requireIfMatch {
+			problem.Write(w, r, problem.New(http.StatusPreconditionRequired, problem.TypePreconditionRequired,
+				"Send the ETag you last read in an If-Match header"))
+			return 0, false
+		}
+		return 0, true
+	}
+	if header == "*" {
+		return 0, true
+	}
+
+	// If-Match uses the strong comparison, so weak tags never match
+	version, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(header, `"`), `"`))
+	if err != nil || version <= 0 || !strings.HasPrefix(header, `"`) {
+		preconditionFailed(w, r)
+		return 0, false
+	}
+	return version, true
+}
+
+// preconditionFailed ... 412, the client has to read the resource again before it can change it
+func preconditionFailed(w http.ResponseWriter, r *http.Request) {
+	problem.Write(w, r, problem.New(http.StatusPreconditionFailed, problem.TypePreconditionFailed,
+		"The resource was changed since you read it, fetch it again and retry"))
+}
+
+// notModified answers a read with 304 when If-None-Match already names tag, which is set as the ETag either way
+// the weak comparison applies, so W/"3" matches "3"
+func notModified(w http.ResponseWriter, r *http.Request, tag string) bool {
+	w.Header().Set("ETag", tag)
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(tag, "W/") {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
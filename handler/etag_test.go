@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"school_api_postgres/problem"
+)
+
+func TestStudentIfMatch(t *testing.T) {
+	tests := []struct {
+		name, method, body, ifMatch string
+		requireIfMatch              bool
+		wantStatus                  int
+		wantType                    string
+	}{
+		{"put at the current version", "PUT", `{"name":"Rahim","age":13,"class":6}`, `"2"`, false, http.StatusOK, ""},
+		{"put at a stale version", "PUT", `{"name":"Rahim","age":13,"class":6}`, `"1"`, false, http.StatusPreconditionFailed, problem.TypePreconditionFailed},
+		{"put with any version", "PUT", `{"name":"Rahim","age":13,"class":6}`, `*`, false, http.StatusOK, ""},
+		{"put without If-Match", "PUT", `{"name":"Rahim","age":13,"class":6}`, "", false, http.StatusOK, ""},
+		{"weak tag never matches", "PUT", `{"name":"Rahim","age":13,"class":6}`, `W/"2"`, false, http.StatusPreconditionFailed, problem.TypePreconditionFailed},
+		{"tag that is no version", "PUT", `{"name":"Rahim","age":13,"class":6}`, `"abc"`, false, http.StatusPreconditionFailed, problem.TypePreconditionFailed},
+		{"patch at a stale version", "PATCH", `{"age":13}`, `"1"`, false, http.StatusPreconditionFailed, problem.TypePreconditionFailed},
+		{"delete at the current version", "DELETE", "", `"2"`, false, http.StatusNoContent, ""},
+		{"delete at a stale version", "DELETE", "", `"1"`, false, http.StatusPreconditionFailed, problem.TypePreconditionFailed},
+		{"required and missing", "PUT", `{"name":"Rahim","age":13,"class":6}`, "", true, http.StatusPreconditionRequired, problem.TypePreconditionRequired},
+		{"required and sent", "PATCH", `{"age":13}`, `"2"`, true, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.requireIfMatch {
+				t.Setenv("REQUIRE_IF_MATCH", "true")
+			}
+			api := newTestAPI(t)
+			api.do("POST", "/api/v1/students", `{"name":"Rahim","age":12,"class":6}`)
+			api.do("PATCH", "/api/v1/students/1", `{"class":6}`, "If-Match", `"1"`) // now at version 2
+
+			rec := api.do(tt.method, "/api/v1/students/1", tt.body, "If-Match", tt.ifMatch)
+			if rec.Code != tt.wantStatus || problemType(t, rec) != tt.wantType {
+				t.Fatalf("got %d %s, want %d %s", rec.Code, rec.Body, tt.wantStatus, tt.wantType)
+			}
+			if rec.Code == http.StatusOK && rec.Header().Get("ETag") != `"3"` {
+				t.Errorf("ETag after the write = %q, want %q", rec.Header().Get("ETag"), `"3"`)
+			}
+		})
+	}
+}
+
+func TestIfNoneMatch(t *testing.T) {
+	api := newTestAPI(t)
+	api.do("POST", "/api/v1/students", `{"name":"Rahim","age":12,"class":6}`)
+	list := api.do("GET", "/api/v1/students", "").Header().Get("ETag")
+	if list == "" {
+		t.Fatal("list response has no ETag")
+	}
+
+	tests := []struct {
+		name, path, ifNoneMatch string
+		want                    int
+	}{
+		{"student unchanged", "/api/v1/students/1", `"1"`, http.StatusNotModified},
+		{"weak form of the same tag", "/api/v1/students/1", `W/"1"`, http.StatusNotModified},
+		{"one of several", "/api/v1/students/1", `"7", "1"`, http.StatusNotModified},
+		{"student changed", "/api/v1/students/1", `"0"`, http.StatusOK},
+		{"list unchanged", "/api/v1/students", list, http.StatusNotModified},
+		{"list of another page", "/api/v1/students?page=2", list, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := api.do("GET", tt.path, "", "If-None-Match", tt.ifNoneMatch); rec.Code != tt.want {
+				t.Errorf("got %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
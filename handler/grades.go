@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"school_api_postgres/grading"
+	"school_api_postgres/models"
+	"school_api_postgres/store"
+	"school_api_postgres/validation"
+)
+
+// GET --get all exams, ?course_id=3&term=2025-spring&sort=-date with page/limit or cursor/limit
+func (h *Handler) getExamsAll(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if err := checkParams(q, "page", "limit", "cursor", "sort", "course_id", "term"); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	var filter store.ExamFilter
+	var err error
+	if filter.CourseID, err = optionalInt(q, "course_id"); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	filter.Term = q.Get("term")
+
+	sort, err := parseSort(q.Get("sort"), store.ExamSortFields)
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	exams, ok := listPage(w, r, h.cursors, sort,
+		func(opts store.ListOptions) ([]models.Exam, int, error) {
+			return h.grades.ListExams(r.Context(), filter, opts)
+		}, store.ExamCursor)
+	if !ok {
+		return
+	}
+	slog.DebugContext(r.Context(), "exams listed", "count", len(exams))
+}
+
+// POST --create an exam: {"course_id": 2, "name": "Midterm", "term": "2025-spring", "max_score": 50, "date": "2025-03-10"}
+func (h *Handler) createExam(w http.ResponseWriter, r *http.Request) {
+	var e models.Exam
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	if err := validation.ValidateExam(e); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	e, err := h.grades.CreateExam(r.Context(), e)
+	if err != nil {
+		writeStoreError(w, r, err, "Exam")
+		return
+	}
+	slog.InfoContext(r.Context(), "exam created", "exam_id", e.ID)
+
+	writeJSON(w, http.StatusCreated, e)
+}
+
+// GET --get a single exam
+func (h *Handler) getExamOne(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	e, err := h.grades.GetExam(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, r, err, "Exam")
+		return
+	}
+	writeJSON(w, http.StatusOK, e)
+}
+
+// DELETE --delete an exam together with its grades
+func (h *Handler) deleteExam(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.grades.DeleteExam(r.Context(), id); err != nil {
+		writeStoreError(w, r, err, "Exam")
+		return
+	}
+	slog.InfoContext(r.Context(), "exam deleted", "exam_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET --every grade of an exam
+func (h *Handler) getExamGrades(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	grades, err := h.grades.Grades(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, r, err, "Exam")
+		return
+	}
+	if grades == nil {
+		grades = []models.Grade{}
+	}
+	writeJSON(w, http.StatusOK, grades)
+}
+
+// POST --enter the scores of an exam in one go: [{"student_id": 1, "score": 42.5}, {"student_id": 2, "score": 38}]
+// entering a score again overwrites it
+func (h *Handler) recordGradesBulk(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var grades []models.Grade
+	if err := json.NewDecoder(r.Body).Decode(&grades); err != nil {
+		badRequest(w, r, "Invalid request payload")
+		return
+	}
+
+	recorded, ok := h.recordGrades(w, r, id, grades, true)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusCreated, recorded)
+}
+
+// PUT --set the score of one student: {"score": 45}
+func (h *Handler) putGrade(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	studentID, ok := pathID(w, r, "studentID")
+	if !ok {
+		return
+	}
+
+	var g models.Grade
+	if err := json.NewDecoder(r.Body).Decode(&g); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	g.StudentID = studentID
+
+	recorded, ok := h.recordGrades(w, r, id, []models.Grade{g}, false)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, recorded[0])
+}
+
+// recordGrades ... validates the scores against the exam and stores them, shared by the bulk and single routes
+// bulk errors carry the index of the grade they belong to
+func (h *Handler) recordGrades(w http.ResponseWriter, r *http.Request, examID int, grades []models.Grade, bulk bool) ([]models.Grade, bool) {
+	exam, err := h.grades.GetExam(r.Context(), examID)
+	if err != nil {
+		writeStoreError(w, r, err, "Exam")
+		return nil, false
+	}
+
+	var errs validation.Errors
+	ids := make([]int, len(grades))
+	for i, g := range grades {
+		if bulk {
+			errs.At(i, validation.ValidateScore(g.Score, exam.MaxScore))
+		} else {
+			errs.Check(validation.ValidateScore(g.Score, exam.MaxScore))
+		}
+		ids[i] = g.StudentID
+	}
+	errs.Check(checkIDs("student_id", ids))
+	if err := errs.Err(); err != nil {
+		invalid(w, r, err)
+		return nil, false
+	}
+
+	recorded, err := h.grades.RecordGrades(r.Context(), examID, grades)
+	if err != nil {
+		writeStoreError(w, r, err, "Grade")
+		return nil, false
+	}
+	slog.InfoContext(r.Context(), "grades recorded", "exam_id", examID, "count", len(recorded))
+	return recorded, true
+}
+
+// DELETE --remove the score of one student
+func (h *Handler) deleteGrade(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	studentID, ok := pathID(w, r, "studentID")
+	if !ok {
+		return
+	}
+
+	if err := h.grades.DeleteGrade(r.Context(), id, studentID); err != nil {
+		writeStoreError(w, r, err, "Grade")
+		return
+	}
+	slog.InfoContext(r.Context(), "grade removed", "exam_id", id, "student_id", studentID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET --report card of a student, ?term=2025-spring or every term when left out
+// ranks compare the student with the rest of their current class
+func (h *Handler) getReportCard(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	if err := checkParams(q, "term"); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	term := q.Get("term")
+
+	s, err := h.students.Get(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, r, err, "Student")
+		return
+	}
+
+	scores, err := h.grades.ClassScores(r.Context(), s.Class, term)
+	if err != nil {
+		writeStoreError(w, r, err, "Grade")
+		return
+	}
+
+	card, ok := grading.ReportCards(scores, h.scale)[s.ID]
+	if !ok {
+		card = &models.ReportCard{}
+	}
+	card.StudentID, card.Name, card.Class, card.Term = s.ID, s.Name, s.Class, term
+	if card.Courses == nil {
+		card.Courses = []models.CourseResult{}
+	}
+	writeJSON(w, http.StatusOK, card)
+}
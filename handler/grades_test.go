@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"school_api_postgres/models"
+	"school_api_postgres/problem"
+)
+
+// newGradesAPI ... newCourseAPI with Rahim and Karim enrolled in course 1 and exam 1 of it, out of 50
+func newGradesAPI(t *testing.T) *testAPI {
+	t.Helper()
+	api := newCourseAPI(t)
+	if rec := api.do("POST", "/api/v1/courses/1/enrollments/bulk", `{"student_ids":[1,2]}`); rec.Code != http.StatusCreated {
+		t.Fatalf("enroll: %d %s", rec.Code, rec.Body)
+	}
+	exam := `{"course_id":1,"name":"Midterm","term":"2025-spring","max_score":50,"date":"2025-03-10"}`
+	if rec := api.do("POST", "/api/v1/exams", exam); rec.Code != http.StatusCreated {
+		t.Fatalf("exam: %d %s", rec.Code, rec.Body)
+	}
+	return api
+}
+
+func TestGrades(t *testing.T) {
+	api := newGradesAPI(t)
+
+	if rec := api.do("POST", "/api/v1/exams/1/grades", `[{"student_id":1,"score":40},{"student_id":2,"score":25}]`); rec.Code != http.StatusCreated {
+		t.Fatalf("record: %d %s", rec.Code, rec.Body)
+	}
+	// entering a score again overwrites it
+	if rec := api.do("PUT", "/api/v1/exams/1/grades/2", `{"score":30}`); rec.Code != http.StatusOK {
+		t.Fatalf("put: %d %s", rec.Code, rec.Body)
+	}
+	var grades []models.Grade
+	decode(t, api.do("GET", "/api/v1/exams/1/grades", ""), &grades)
+	if len(grades) != 2 || grades[1].StudentID != 2 || grades[1].Score != 30 {
+		t.Fatalf("grades %+v", grades)
+	}
+
+	var card models.ReportCard
+	decode(t, api.do("GET", "/api/v1/students/2/report-card?term=2025-spring", ""), &card)
+	if card.Name != "Karim" || card.Term != "2025-spring" || len(card.Courses) != 1 || card.Overall.Percentage == nil ||
+		*card.Overall.Percentage != 60 || card.Overall.Letter != "A-" || card.Overall.Rank != 2 || card.Overall.OutOf != 2 {
+		t.Errorf("report card %+v, overall %+v", card, card.Overall)
+	}
+	// another term has no grades yet
+	decode(t, api.do("GET", "/api/v1/students/2/report-card?term=2025-fall", ""), &card)
+	if len(card.Courses) != 0 || card.Overall.Percentage != nil {
+		t.Errorf("report card of an empty term %+v", card)
+	}
+
+	if rec := api.do("DELETE", "/api/v1/exams/1/grades/2", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete grade: %d %s", rec.Code, rec.Body)
+	}
+	if rec := api.do("DELETE", "/api/v1/exams/1/grades/2", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("delete a grade twice: %d", rec.Code)
+	}
+	if rec := api.do("DELETE", "/api/v1/exams/1", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete exam: %d %s", rec.Code, rec.Body)
+	}
+	if rec := api.do("GET", "/api/v1/exams/1/grades", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("grades of a deleted exam: %d", rec.Code)
+	}
+}
+
+func TestGradesRefused(t *testing.T) {
+	tests := []struct {
+		name, method, path, body string
+		want                     int
+		wantType                 string
+	}{
+		{"score over max", "POST", "/api/v1/exams/1/grades", `[{"student_id":1,"score":40},{"student_id":2,"score":51}]`,
+			http.StatusBadRequest, problem.TypeValidation},
+		{"negative score", "PUT", "/api/v1/exams/1/grades/1", `{"score":-1}`, http.StatusBadRequest, problem.TypeValidation},
+		{"student twice", "POST", "/api/v1/exams/1/grades", `[{"student_id":1,"score":40},{"student_id":1,"score":41}]`,
+			http.StatusBadRequest, problem.TypeValidation},
+		{"not enrolled", "POST", "/api/v1/exams/1/grades", `[{"student_id":3,"score":40}]`,
+			http.StatusUnprocessableEntity, problem.TypeInvalidReference},
+		{"unknown exam", "POST", "/api/v1/exams/9/grades", `[{"student_id":1,"score":40}]`, http.StatusNotFound, problem.TypeNotFound},
+		{"exam twice", "POST", "/api/v1/exams", `{"course_id":1,"name":"Midterm","term":"2025-spring","max_score":50,"date":"2025-03-10"}`,
+			http.StatusConflict, problem.TypeConflict},
+		{"exam of an unknown course", "POST", "/api/v1/exams", `{"course_id":9,"name":"Final","term":"2025-spring","max_score":50,"date":"2025-06-10"}`,
+			http.StatusUnprocessableEntity, problem.TypeInvalidReference},
+		{"exam without a term", "POST", "/api/v1/exams", `{"course_id":1,"name":"Final","max_score":50,"date":"2025-06-10"}`,
+			http.StatusBadRequest, problem.TypeValidation},
+		{"report card of an unknown student", "GET", "/api/v1/students/9/report-card", "", http.StatusNotFound, problem.TypeNotFound},
+		{"report card with an unknown parameter", "GET", "/api/v1/students/1/report-card?semester=1", "", http.StatusBadRequest, problem.TypeBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newGradesAPI(t)
+			rec := api.do(tt.method, tt.path, tt.body)
+			if rec.Code != tt.want || problemType(t, rec) != tt.wantType {
+				t.Fatalf("got %d %s, want %d %s", rec.Code, rec.Body, tt.want, tt.wantType)
+			}
+			var grades []models.Grade
+			decode(t, api.do("GET", "/api/v1/exams/1/grades", ""), &grades)
+			if len(grades) != 0 {
+				t.Errorf("a refused request recorded %+v", grades)
+			}
+		})
+	}
+}
+
+func TestBulkGradeErrorsCarryTheIndex(t *testing.T) {
+	api := newGradesAPI(t)
+	rec := api.do("POST", "/api/v1/exams/1/grades", `[{"student_id":1,"score":40},{"student_id":2,"score":51}]`)
+	var p struct {
+		Errors []struct {
+			Index *int   `json:"index"`
+			Field string `json:"field"`
+		} `json:"errors"`
+	}
+	decode(t, rec, &p)
+	if len(p.Errors) != 1 || p.Errors[0].Index == nil || *p.Errors[0].Index != 1 || p.Errors[0].Field != "score" {
+		t.Errorf("errors %+v", p.Errors)
+	}
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"school_api_postgres/models"
+	"school_api_postgres/store"
+	"school_api_postgres/validation"
+)
+
+// guardianRequest ... body of POST /students/{id}/guardians, either a new guardian or the guardian_id of an existing one
+type guardianRequest struct {
+	GuardianID int `json:"guardian_id"`
+	models.Guardian
+}
+
+// GET --guardians of a student
+func (h *Handler) getStudentGuardians(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	guardians, err := h.guardians.StudentGuardians(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, r, err, "Student")
+		return
+	}
+	if guardians == nil {
+		guardians = []models.Guardian{}
+	}
+	writeJSON(w, http.StatusOK, guardians)
+}
+
+// GET --one guardian of a student
+func (h *Handler) getStudentGuardian(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	guardianID, ok := pathID(w, r, "guardianID")
+	if !ok {
+		return
+	}
+
+	g, err := h.guardians.Get(r.Context(), id, guardianID)
+	if err != nil {
+		writeStoreError(w, r, err, "Guardian")
+		return
+	}
+	writeJSON(w, http.StatusOK, g)
+}
+
+// POST --add a guardian to a student:
+// {"name": "Rahima Begum", "relationship": "mother", "phone": "+8801712345678"} creates a new one,
+// {"guardian_id": 3} links one that already exists, e.g. the parent of a sibling
+func (h *Handler) addStudentGuardian(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req guardianRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	var g models.Guardian
+	var err error
+	if req.GuardianID != 0 {
+		if req.Guardian != (models.Guardian{}) {
+			badRequest(w, r, "Send either guardian_id or the guardian's details, not both")
+			return
+		}
+		if req.GuardianID < 0 {
+			badRequest(w, r, "Invalid guardian_id")
+			return
+		}
+		g, err = h.guardians.Link(r.Context(), id, req.GuardianID)
+	} else {
+		if err := validation.ValidateGuardian(req.Guardian); err != nil {
+			invalid(w, r, err)
+			return
+		}
+		g, err = h.guardians.Create(r.Context(), id, req.Guardian)
+	}
+	if err != nil {
+		resource := "Student"
+		if req.GuardianID != 0 && !errors.Is(err, store.ErrNotFound) {
+			resource = "Guardian" // the guardian is missing or already linked
+		}
+		writeStoreError(w, r, err, resource)
+		return
+	}
+	slog.InfoContext(r.Context(), "guardian added", "guardian_id", g.ID, "student_id", id)
+
+	writeJSON(w, http.StatusCreated, g)
+}
+
+// PUT --replace the details of a guardian, every student linked to them sees the change
+func (h *Handler) updateStudentGuardian(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	guardianID, ok := pathID(w, r, "guardianID")
+	if !ok {
+		return
+	}
+
+	var g models.Guardian
+	if err := json.NewDecoder(r.Body).Decode(&g); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	if err := validation.ValidateGuardian(g); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	if err := h.guardians.Update(r.Context(), id, guardianID, g); err != nil {
+		writeStoreError(w, r, err, "Guardian")
+		return
+	}
+	slog.InfoContext(r.Context(), "guardian updated", "guardian_id", guardianID)
+
+	g.ID = guardianID
+	writeJSON(w, http.StatusOK, g)
+}
+
+// DELETE --unlink a guardian from a student, the guardian stays for any siblings
+func (h *Handler) removeStudentGuardian(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	guardianID, ok := pathID(w, r, "guardianID")
+	if !ok {
+		return
+	}
+
+	if err := h.guardians.Unlink(r.Context(), id, guardianID); err != nil {
+		writeStoreError(w, r, err, "Guardian")
+		return
+	}
+	slog.InfoContext(r.Context(), "guardian removed", "guardian_id", guardianID, "student_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"school_api_postgres/models"
+	"school_api_postgres/problem"
+)
+
+// newGuardianAPI ... a test API with the siblings Rahim (1) and Rahima (2) and their mother as guardian 1 of Rahim
+func newGuardianAPI(t *testing.T) *testAPI {
+	t.Helper()
+	api := newTestAPI(t)
+	for _, s := range []string{`{"name":"Rahim","age":12,"class":6}`, `{"name":"Rahima","age":9,"class":3}`} {
+		if rec := api.do("POST", "/api/v1/students", s); rec.Code != http.StatusCreated {
+			t.Fatalf("student: %d %s", rec.Code, rec.Body)
+		}
+	}
+	rec := api.do("POST", "/api/v1/students/1/guardians", `{"name":"Rahela Begum","relationship":"mother","phone":"+8801712345678"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("guardian: %d %s", rec.Code, rec.Body)
+	}
+	return api
+}
+
+func TestGuardians(t *testing.T) {
+	api := newGuardianAPI(t)
+
+	// the sibling is linked to the same guardian, not a copy
+	rec := api.do("POST", "/api/v1/students/2/guardians", `{"guardian_id":1}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("link: %d %s", rec.Code, rec.Body)
+	}
+	update := `{"name":"Rahela Begum","relationship":"mother","phone":"+8801812345678","email":"rahela@example.test"}`
+	if rec := api.do("PUT", "/api/v1/students/1/guardians/1", update); rec.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", rec.Code, rec.Body)
+	}
+	var g models.Guardian
+	decode(t, api.do("GET", "/api/v1/students/2/guardians/1", ""), &g)
+	if g.Phone != "+8801812345678" || g.Email != "rahela@example.test" {
+		t.Errorf("the sibling sees %+v", g)
+	}
+
+	// unlinking one student keeps the guardian for the other
+	if rec := api.do("DELETE", "/api/v1/students/1/guardians/1", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("unlink: %d %s", rec.Code, rec.Body)
+	}
+	var guardians []models.Guardian
+	decode(t, api.do("GET", "/api/v1/students/1/guardians", ""), &guardians)
+	if len(guardians) != 0 {
+		t.Errorf("guardians of Rahim after unlinking %+v", guardians)
+	}
+	decode(t, api.do("GET", "/api/v1/students/2/guardians", ""), &guardians)
+	if len(guardians) != 1 || guardians[0].ID != 1 {
+		t.Errorf("guardians of Rahima %+v", guardians)
+	}
+	if rec := api.do("GET", "/api/v1/students/1/guardians/1", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("guardian of another student: %d", rec.Code)
+	}
+}
+
+func TestGuardiansRefused(t *testing.T) {
+	tests := []struct {
+		name, method, path, body string
+		want                     int
+		wantType                 string
+	}{
+		{"no phone or email", "POST", "/api/v1/students/2/guardians", `{"name":"Karim","relationship":"father"}`,
+			http.StatusBadRequest, problem.TypeValidation},
+		{"bad phone", "POST", "/api/v1/students/2/guardians", `{"name":"Karim","relationship":"father","phone":"call me"}`,
+			http.StatusBadRequest, problem.TypeValidation},
+		{"bad email", "POST", "/api/v1/students/2/guardians", `{"name":"Karim","relationship":"father","email":"karim"}`,
+			http.StatusBadRequest, problem.TypeValidation},
+		{"id and details", "POST", "/api/v1/students/2/guardians", `{"guardian_id":1,"name":"Karim"}`,
+			http.StatusBadRequest, problem.TypeBadRequest},
+		{"linked already", "POST", "/api/v1/students/1/guardians", `{"guardian_id":1}`, http.StatusConflict, problem.TypeConflict},
+		{"unknown guardian", "POST", "/api/v1/students/2/guardians", `{"guardian_id":9}`,
+			http.StatusUnprocessableEntity, problem.TypeInvalidReference},
+		{"unknown student", "POST", "/api/v1/students/9/guardians", `{"guardian_id":1}`, http.StatusNotFound, problem.TypeNotFound},
+		{"update through another student", "PUT", "/api/v1/students/2/guardians/1",
+			`{"name":"Someone","relationship":"aunt","phone":"+8801712345678"}`, http.StatusNotFound, problem.TypeNotFound},
+		{"unlink what is not linked", "DELETE", "/api/v1/students/2/guardians/1", "", http.StatusNotFound, problem.TypeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newGuardianAPI(t)
+			rec := api.do(tt.method, tt.path, tt.body)
+			if rec.Code != tt.want || problemType(t, rec) != tt.wantType {
+				t.Errorf("got %d %s, want %d %s", rec.Code, rec.Body, tt.want, tt.wantType)
+			}
+		})
+	}
+}
@@ -3,241 +3,219 @@ package handler
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
-	"fmt"
-	"io"
 	"log"
-	"net"
+	"log/slog"
 	"net/http"
+	"net/netip"
 	"os"
 	"os/signal"
-	"school_api_postgres/models"
+	"school_api_postgres/auth"
+	"school_api_postgres/database"
+	"school_api_postgres/grading"
+	"school_api_postgres/logging"
+	"school_api_postgres/metrics"
+	"school_api_postgres/migrations"
+	"school_api_postgres/problem"
+	"school_api_postgres/ratelimit"
+	"school_api_postgres/store"
 	"school_api_postgres/validation"
-	"strconv"
-	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/gorilla/mux"
-	"golang.org/x/time/rate"
-
-	//"github.com/gorilla/mux"
-
-	_ "github.com/lib/pq" //
 )
 
-var db *sql.DB
-
-// simple token bucket limter didn't use
-/*
-var limiter = rate.NewLimiter(5, 10) // 10 requests per second, burst of 20
-func rateLimitMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !limiter.Allow() {
-			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
+// Handler ... carries the dependencies of every route, the store is injected instead of living in a global
+type Handler struct {
+	students   store.StudentStore
+	teachers   store.TeacherStore
+	courses    store.CourseStore
+	attendance store.AttendanceStore
+	grades     store.GradeStore
+	guardians  store.GuardianStore
+	cursors    *cursorSigner
+	scale      grading.Scale
+	// requireIfMatch ... REQUIRE_IF_MATCH=true makes If-Match mandatory on student writes
+	requireIfMatch bool
+	idempotency    store.IdempotencyStore
+	idempotencyTTL time.Duration
+	apiKeys        store.APIKeyStore
+	// bootstrapKey ... ValidAPIKey, read once at start, empty means there is none
+	bootstrapKey string
+	// tokens ... verifies bearer JWTs, nil when none are configured
+	tokens        *auth.Verifier
+	users         store.UserStore
+	refreshTokens store.RefreshTokenStore
+	accessTTL     time.Duration
+	refreshTTL    time.Duration
+	limiter       ratelimit.Limiter
+	limits        ratelimit.Config
+	// trustedProxies ... TRUSTED_PROXIES, the peers whose forwarding headers are believed
+	trustedProxies []netip.Prefix
 }
-*/
-
-// ip based limiter that I used
-func rateLimitMiddlewareClientIP(next http.Handler) http.Handler {
 
-	type client struct {
-		limiter  *rate.Limiter
-		lastSeen time.Time
+// New ... CURSOR_SECRET signs the keyset pagination cursors and must be the same on every replica,
+// GRADING_SCALE_FILE optionally replaces the default grading scale and VALIDATION_RULES_FILE the validation rules,
+// IDEMPOTENCY_TTL (a duration like 24h) is how long Idempotency-Keys are remembered, the JWT_* variables
+// configure bearer tokens, see auth.VerifierFromEnv, and ACCESS_TOKEN_TTL and REFRESH_TOKEN_TTL their lifetimes.
+// RATE_LIMIT_FILE sets the rate limit policies, see ratelimit.FromEnv, they are counted in memory until Handle
+// picks the backend RATE_LIMIT_BACKEND names. TRUSTED_PROXIES lists the proxies whose forwarding headers are believed
+func New(stores store.Stores) *Handler {
+	scale, err := grading.FromEnv()
+	if err != nil {
+		logging.Fatal("invalid configuration", "err", err)
 	}
+	if err := validation.FromEnv(); err != nil {
+		logging.Fatal("invalid configuration", "err", err)
+	}
+	tokens, err := auth.VerifierFromEnv()
+	if err != nil {
+		logging.Fatal("invalid configuration", "err", err)
+	}
+	ttl := durationEnv("IDEMPOTENCY_TTL", defaultIdempotencyTTL)
+	limits, err := ratelimit.FromEnv()
+	if err != nil {
+		logging.Fatal("invalid configuration", "err", err)
+	}
+	if err := checkRateLimits(limits); err != nil {
+		logging.Fatal("invalid configuration", "err", err)
+	}
+	proxies, err := parseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		logging.Fatal("invalid configuration", "err", err)
+	}
+	cursors, err := newCursorSigner(os.Getenv("CURSOR_SECRET"))
+	if err != nil {
+		logging.Fatal("invalid configuration", "err", err)
+	}
+	return &Handler{
+		students:       stores.Students,
+		teachers:       stores.Teachers,
+		courses:        stores.Courses,
+		attendance:     stores.Attendance,
+		grades:         stores.Grades,
+		guardians:      stores.Guardians,
+		cursors:        cursors,
+		scale:          scale,
+		requireIfMatch: os.Getenv("REQUIRE_IF_MATCH") == "true",
+		idempotency:    stores.Idempotency,
+		idempotencyTTL: ttl,
+		apiKeys:        stores.APIKeys,
+		bootstrapKey:   os.Getenv("ValidAPIKey"),
+		tokens:         tokens,
+		users:          stores.Users,
+		refreshTokens:  stores.RefreshTokens,
+		accessTTL:      durationEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL),
+		refreshTTL:     durationEnv("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL),
+		limiter:        ratelimit.NewMemory(),
+		limits:         limits,
+		trustedProxies: proxies,
+	}
+}
 
-	var (
-		mu      sync.Mutex
-		clients = make(map[string]*client)
-	)
-
-	// ei go routine delete korteche map theke client er ip ar details
-	go func() {
-		for {
-
-			time.Sleep(time.Minute)
-			mu.Lock()
-
-			for ip, client := range clients {
-				if time.Since(client.lastSeen) > 3*time.Minute {
-					delete(clients, ip)
-				}
-			}
-			mu.Unlock()
-
-		}
-	}()
-
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-
-		ip, _, err := net.SplitHostPort(r.RemoteAddr)
-		if err != nil {
-			log.Printf("Error parsing IP: %v", err)
-			http.Error(w, "Internal IP Parsing error", http.StatusInternalServerError)
-			return
-		}
-
-		_, found := clients[ip]
-
-		mu.Lock()
-		if !found {
-			clients[ip] = &client{limiter: rate.NewLimiter(3, 5)}
-		}
-		clients[ip].lastSeen = time.Now()
-
-		if !clients[ip].limiter.Allow() {
-			mu.Unlock()
-			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
-			return
-		}
-
-		mu.Unlock()
-		next.ServeHTTP(w, r)
-	})
+// durationEnv ... the positive duration like 24h in the variable, def when it is not set
+func durationEnv(name string, def time.Duration) time.Duration {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return def
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		logging.Fatal(name+" must be a positive duration like 24h", "value", raw)
+	}
+	return d
 }
 
 const (
 	// APIKeyHeader ... za holo request er header
 	APIKeyHeader = "X-API-Key"
-	// ValidAPIKey ... holo amar api key zar maddhome validate kortechi
-	// ValidAPIKey = "sadat-api-key-1123" //in kubernetes I used secret volume or configmap za pod use korbe In production, use env variable or database
+	// ValidAPIKey ... holo amar bootstrap api key, sob scope ache, set na thakle kono bootstrap key nai
+	// ValidAPIKey = "sadat-api-key-1123" //in kubernetes I used secret volume or configmap za pod use korbe, the real keys live hashed in api_keys
 )
 
-func apiKeyMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		apiKey := r.Header.Get(APIKeyHeader)
-
-		/*
-			        // this is for .env file but in docker we are using environment variables
-					errEnv := godotenv.Load()
-					if errEnv != nil {
-						log.Fatal("Error loading .env file")
-					}
-		*/
-
-		ValidAPIKey := os.Getenv("ValidAPIKey")
-		if apiKey != ValidAPIKey {
-			http.Error(w, "Invalid API Key", http.StatusUnauthorized)
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
-}
-
-func initDB() {
-
-	// removed this beacuse when using docker-compose I want to send the variables from
-	// docker compose environ tachara eta compose korte dicchilo na karon log.Fatal .env file na paile
-
-	/*
-		errEnv := godotenv.Load()
-		if errEnv != nil {
-			log.Fatal("Error loading .env file")
-		}
-			// loading from .env
-	*/
-
-	DB_USER := os.Getenv("DB_USER")
-	DB_PASSWORD := os.Getenv("DB_PASSWORD")
-	DB_NAME := os.Getenv("DB_NAME")
-	DB_HOST := os.Getenv("DB_HOST")
-	DB_PORT := os.Getenv("DB_PORT")
-
-	dbinfo := fmt.Sprintf("user=%s password=%s dbname=%s host=%s port=%s sslmode=disable",
-		DB_USER, DB_PASSWORD, DB_NAME, DB_HOST, DB_PORT)
-
-	var err error
-	db, err = sql.Open("postgres", dbinfo)
+func initDB() *sql.DB {
+	db, err := database.Open()
 	if err != nil {
-		log.Fatal("Failed to connect to the database:", err)
+		logging.Fatal("failed to connect to the database", "err", err)
 	}
+	slog.Info("connected to the database")
 
-	errPing := db.Ping()
-	if errPing != nil {
-		log.Fatal("Failed to ping the database:", errPing)
+	// every replica runs this on start, the advisory lock in the runner makes them take turns
+	// set MIGRATE_ON_START=false to only migrate through `server migrate up`
+	if os.Getenv("MIGRATE_ON_START") != "false" {
+		runner, err := migrations.NewRunner(db)
+		if err != nil {
+			logging.Fatal("failed to load migrations", "err", err)
+		}
+		applied, err := runner.Up(context.Background())
+		if err != nil {
+			logging.Fatal("failed to migrate the database", "err", err)
+		}
+		for _, m := range applied {
+			slog.Info("applied migration", "version", m.Version, "migration", m.Name)
+		}
 	}
-	fmt.Println("Successfully connected to the school_db database!")
+	return db
 }
 
 var wg = sync.WaitGroup{}
 
-// Handle ...
+// Handle ... LOG_LEVEL and LOG_FORMAT configure the logs, see logging.Setup, METRICS_ADDR where /metrics is served
 func Handle() {
+	if err := logging.Setup(); err != nil {
+		log.Fatal(err)
+	}
 
-	fmt.Println("Server initialization starting...")
-	initDB()
-
-	/*
-		r := mux.NewRouter()
-		v1 := r.PathPrefix("/api/v1").Subrouter()
-		v1.HandleFunc("/students", getStudentsAll).Methods("GET")
-		v1.HandleFunc("/students", createStudentSingle).Methods("POST")
-		v1.HandleFunc("/students/bulk", createStudentBulk).Methods("POST")
-		v1.HandleFunc("/students/{id}", updateStudent).Methods("PUT")
-		v1.HandleFunc("/students/{id}", deleteStudent).Methods("DELETE")
-		v1.HandleFunc("/students/{id}", patchStudent).Methods("PATCH")
-		v1.HandleFunc("/students/{id}", getStudentOne).Methods("GET")
-	*/
-	// routes
-	r := chi.NewRouter()
-	r.Route("/api/v1", func(r chi.Router) { // Versioned routes under /api/v1
-
-		//r.Use(rateLimitMiddleware) // Apply rate limiting to all routes under /api/v1
-
-		// eida use korchi authentication er jonno fir all routes below
-		r.Use(apiKeyMiddleware)
-		// Group routes that need rate limiting
-		r.Group(func(r chi.Router) {
-			// Apply rate limiting only to these routes
-			r.Use(rateLimitMiddlewareClientIP)
-			//r.Use(rateLimitMiddleware)
-			r.Get("/students", getStudentsAll) //r.With(rateLimitMiddleware).Get("/students", getStudentsAll) // apply rate limiting to specific route
-
-		})
-
-		// Group for student creation (without rate limiting)
-		r.Group(func(r chi.Router) {
-			r.Post("/students", createStudentSingle)
-			r.Post("/students/bulk", createStudentBulk)
-		})
+	slog.Info("server initialization starting")
+	db := initDB()
+	defer db.Close()
 
-		// Group for student modifications (without rate limiting)
-		r.Group(func(r chi.Router) {
-			r.Put("/students/{id}", updateStudent)
-			r.Delete("/students/{id}", deleteStudent)
-			r.Patch("/students/{id}", patchStudent)
-			r.Get("/students/{id}", getStudentOne)
-		})
+	h := New(store.NewPostgresStores(db))
+	metrics.RegisterDBStats(db)
+	limiter, err := ratelimit.LimiterFromEnv(db)
+	if err != nil {
+		logging.Fatal("invalid configuration", "err", err)
+	}
+	h.limiter = limiter
 
-	})
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	defer stopPurge()
+	go h.purgeExpired(purgeCtx)
+	go ratelimit.RunJanitor(purgeCtx, h.limiter, time.Minute)
 
 	// Create a server with a timeout for graceful shutdown
 	server := &http.Server{
 		Addr:    ":8080", // port
-		Handler: r,
+		Handler: h.Routes(),
+		// errors of net/http itself, like TLS handshakes or panics in a handler
+		ErrorLog: logging.Std(slog.LevelWarn),
 	}
 	// starting server on a port
 	wg.Add(1)
 	go func() {
-		log.Println("Server running on port 8080...")
+		slog.Info("server running", "addr", server.Addr)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatal("Server failed to start:", err)
+			logging.Fatal("server failed to start", "err", err)
 		}
 		wg.Done()
 
 	}()
+	ms := metricsServer()
+	if ms != nil {
+		go func() {
+			slog.Info("metrics server running", "addr", ms.Addr)
+			if err := ms.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logging.Fatal("metrics server failed to start", "err", err)
+			}
+		}()
+	}
 
 	// Graceful shutdown
 	stopChan := make(chan os.Signal, 1)
 	signal.Notify(stopChan, syscall.SIGINT, syscall.SIGTERM)
 	<-stopChan // Wait for interrupt signal
-	log.Println("Shutting down server...")
+	slog.Info("shutting down server")
 
 	// Create a context with a timeout to allow ongoing requests to complete
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -245,503 +223,149 @@ func Handle() {
 
 	// Shutdown the server
 	if err := server.Shutdown(ctx); err != nil { // this waits here while context not done if all request are processed then stops the server immediately else wait for context time and then executes
-		log.Fatal("Server forced to shutdown:", err)
+		logging.Fatal("server forced to shutdown", "err", err)
+	}
+	if ms != nil {
+		ms.Shutdown(ctx)
 	}
 
-	log.Println("Server gracefully stopped")
+	stopPurge()
+	slog.Info("server gracefully stopped")
 
 	// fmt.Println("This waitgroup and goroutine is used to excute code after ListenAndServe as it is blcoking and falls into infinite loop for taking request")
 	wg.Wait()
 }
 
-// Fetch multiple rows --- db.Query
-// Fetch a single row --- db.QueryRow
-// Insert, update, delete --- db.Exec
-
-// GET --get all students
-func getStudentsAll(w http.ResponseWriter, r *http.Request) {
-
-	// Get page and limit from query parameters
-	pageStr := r.URL.Query().Get("page")
-	limitStr := r.URL.Query().Get("limit")
-
-	// Set default values
-	page := 1
-	limit := 3
-
-	// Parse page number
-	if pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
-	}
-
-	// Parse limit
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
-	}
-
-	// Calculate offset
-	offset := (page - 1) * limit
-
-	rows, err := db.Query("SELECT * FROM students LIMIT $1 OFFSET $2", limit, offset)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	defer rows.Close()
-
-	var students []models.Student
-	for rows.Next() {
-		var s models.Student
-		if err := rows.Scan(&s.ID, &s.Name, &s.Age, &s.Class); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		students = append(students, s)
-	}
-
-	// Log the JSON response before sending it to the client
-	jsonResponse, err := json.Marshal(students)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	log.Println("All students list will be sent\n", string(jsonResponse))
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(students)
-
-	//time.Sleep(5 * time.Second) // for graceful shutdown cheking
-
-}
-
-// POST --insert a student
-
-func createStudentSingle(w http.ResponseWriter, r *http.Request) {
-
-	var s models.Student
-	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	if err := validation.ValidateStudent(s); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	// Fetch multiple rows --- db.Query
-	// Fetch a single row --- db.QueryRow
-	// Insert, update, delete --- db.Exec
-
-	// kintu eitate kaj korche karon database supports RETURNING (e.g., PostgreSQL
-	// but eita ar concern na karon this time I have modified the code so that multiple students can be inserted
-	err := db.QueryRow("INSERT INTO students (name, age, class) VALUES ($1, $2, $3) RETURNING id", s.Name, s.Age, s.Class).Scan(&s.ID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	// Log the JSON response before sending it to the client
-	jsonResponse, err := json.Marshal(s)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	log.Println("Student created is\n", string(jsonResponse))
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	//json.NewEncoder(w).Encode(s) //w.Write(jsonResponse)
-
-}
-
-// func createStudent(w http.ResponseWriter, r *http.Request) {
-
-// 	/*
-// 		var students []Student
-// 			// zodi multiple object pathay [[{}]
-// 			err := json.NewDecoder(r.Body).Decode(&students)
-// 			if err != nil {
-// 				// {} - if decoding as an array fails tahole decode kortechias a single student object
-// 				var singleStudent Student
-// 				if err := json.NewDecoder(r.Body).Decode(&singleStudent); err != nil {
-// 					http.Error(w, "Invalid request payload", http.StatusBadRequest)
-// 					return
-// 				}
-// 				students = append(students, singleStudent) // Convert single student to array format
-// 			}
-// 	*/
-
-// 	//Read the request body once and store it because the previous won't work beacuse
-// 	//When you attempt to decode the request body the first time (as an array)
-// 	//it consumes the body, and the second attempt to decode it (as a single object) fails
-// 	//because the body is already empty.
-
-// 	body, err := io.ReadAll(r.Body)
-// 	if err != nil {
-// 		http.Error(w, "Failed to read request body", http.StatusBadRequest)
-// 		return
-// 	}
-// 	defer r.Body.Close()
-
-// 	var students []Student
-
-// 	// Try to decode the stored body as an array of students
-// 	err = json.Unmarshal(body, &students)
-// 	if err != nil {
-// 		// If decoding as an array fails, try to decode as a single student object
-// 		var singleStudent Student
-// 		if err := json.Unmarshal(body, &singleStudent); err != nil {
-// 			http.Error(w, "Invalid request payload", http.StatusBadRequest)
-// 			return
-// 		}
-// 		students = append(students, singleStudent) // Convert single student to array format
-// 	}
-
-// 	if len(students) == 0 {
-// 		http.Error(w, "No student data provided", http.StatusBadRequest)
-// 		return
-// 	}
-
-// 	tx, err := db.Begin()
-// 	if err != nil {
-// 		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
-// 		return
-// 	}
-// 	defer tx.Rollback()
-
-// 	stmt, err := tx.Prepare("INSERT INTO students (name, age, class) VALUES ($1, $2, $3) RETURNING id")
-// 	if err != nil {
-// 		http.Error(w, "Failed to prepare statement", http.StatusInternalServerError)
-// 		return
-// 	}
-// 	defer stmt.Close()
-
-// 	var insertedStudents []Student
-// 	for _, s := range students {
-// 		var id int
-// 		err := stmt.QueryRow(s.Name, s.Age, s.Class).Scan(&id)
-// 		if err != nil {
-// 			http.Error(w, fmt.Sprintf("Failed to insert student: %v", err), http.StatusInternalServerError)
-// 			return
-// 		}
-// 		s.ID = id
-// 		insertedStudents = append(insertedStudents, s)
-// 	}
-
-// 	if err := tx.Commit(); err != nil {
-// 		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
-// 		return
-// 	}
-
-// 	jsonResponse, err := json.Marshal(insertedStudents)
-// 	if err != nil {
-// 		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
-// 		return
-// 	}
-// 	log.Println("Inserted students:\n", string(jsonResponse))
-
-// 	w.Header().Set("Content-Type", "application/json")
-// 	w.WriteHeader(http.StatusCreated)
-// 	w.Write(jsonResponse)
-
-// }
-
-// Bulk with batch because query size matters
-func createStudentBulk(w http.ResponseWriter, r *http.Request) {
-
-	// Read the request body once and store it
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		http.Error(w, "Failed to read request body", http.StatusBadRequest)
-		return
-	}
-	defer r.Body.Close()
-
-	var students []models.Student
-
-	err = json.Unmarshal(body, &students)
-	if err != nil {
-		var singleStudent models.Student
-		if err := json.Unmarshal(body, &singleStudent); err != nil {
-			http.Error(w, "Invalid request payload", http.StatusBadRequest)
-			return
-		}
-		// Convert single student to array format
-		students = append(students, singleStudent)
-	}
-
-	if len(students) == 0 {
-		http.Error(w, "No student data provided", http.StatusBadRequest)
-		return
-	}
-
-	// Validate each student in the bulk data
-	for _, student := range students {
-		if err := validation.ValidateStudent(student); err != nil {
-			http.Error(w, fmt.Sprintf("Invalid student data: %v", err), http.StatusBadRequest)
-			return
-		}
-	}
-
-	tx, err := db.Begin()
-	if err != nil {
-		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
-		return
-	}
-	defer tx.Rollback() // Ensure the transaction is rolled back if anything goes wrong
-
-	batchSize := 3 // batch size dietchi to bulk insert
-	var insertedStudents []models.Student
-
-	// Insert students in batches
-	for i := 0; i < len(students); i += batchSize {
-
-		end := i + batchSize
-		//
-		if end > len(students) { // this is for last batch
-			end = len(students)
-		}
-
-		batch := students[i:end] // Get the current batch of students
-
-		// Build the VALUES clause dynamically for the current batch
-		var valueStrings []string
-		var valueArgs []interface{}
-
-		for j, student := range batch {
-
-			valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d)", j*3+1, j*3+2, j*3+3)) // Create placeholders for each student example = ($1, $2, $3), ($4, $5, $6), )
-			valueArgs = append(valueArgs, student.Name, student.Age, student.Class)                  // Append the student data to the valueArgs slice
-		}
-
-		// Combine the query and VALUES clause
-		query := `
-			INSERT INTO students (name, age, class)
-			VALUES %s
-			RETURNING id
-		`
-		finalQuery := fmt.Sprintf(query, strings.Join(valueStrings, ", "))
-
-		// Execute the bulk insert query for the current batch
-		rows, err := tx.Query(finalQuery, valueArgs...)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to execute bulk insert: %v", err), http.StatusInternalServerError)
-			return
-		}
-
-		j := 0 // batch
-		// Collect the inserted IDs for the current batch
-		for rows.Next() {
-			var id int
-			if err := rows.Scan(&id); err != nil {
-				http.Error(w, fmt.Sprintf("Failed to scan inserted ID: %v", err), http.StatusInternalServerError)
-				return
-			}
-
-			insertedStudents = append(insertedStudents, models.Student{ID: id,
-				Name:  batch[j].Name,
-				Age:   batch[j].Age,
-				Class: batch[j].Class,
-			}) // Append the inserted student to the insertedStudents slice
-			j++
-		}
-
-		// Close the rows object for the current batch
-		// Else resource leak may happen
-		if err := rows.Close(); err != nil {
-			log.Printf("Error closing rows: %v", err)
-		}
-
-	}
-
-	// Commit the transaction
-	if err := tx.Commit(); err != nil {
-		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
-		return
-	}
-
-	// ekhane amra sudhu resposne dekhbo but pathabo na response to client
-	// zodio name dekhabe na sudhu id dekhabe karon return kori nai
-	jsonResponse, err := json.Marshal(insertedStudents)
-	if err != nil {
-		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
-		return
-	}
-	log.Println("Inserted students:\n", string(jsonResponse))
-
-	// this not necessary in the case of insertion we dont need to send the result to the user
-	// Send the response to the client
-	// w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	// w.Write(jsonResponse)
-}
-
-// PUT --update all the information of a student
-func updateStudent(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
-
-	var s models.Student
-	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	// Validate the student data
-	if err := validation.ValidateStudent(s); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	_, err := db.Exec("UPDATE students SET name=$1, age=$2, class=$3 WHERE id=$4", s.Name, s.Age, s.Class, id)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	// Log the JSON response before sending it to the client
-	jsonResponse, err := json.Marshal(s)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	log.Println(id, "id is updated with\n", string(jsonResponse))
-
-	//w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	// json.NewEncoder(w).Encode(s)
-}
-
-// DELETE --delete a student from the database
-func deleteStudent(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
-
-	result, err := db.Exec("DELETE FROM students WHERE id=$1", id)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	// Check the number of rows affected
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	if rowsAffected == 0 {
-		// If no rows were affected, the ID does not exist
-		http.Error(w, "Student not found", http.StatusNotFound)
-		return
-	}
-
-	log.Println(id, "id is deleted")
-	w.WriteHeader(http.StatusNoContent)
-}
-
-// PATCH --update partial information of a student
-func patchStudent(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
-
-	var updates []string
-	var values []interface{}
-	values = append(values, id)
+// Routes ... builds the chi router with every versioned route
+func (h *Handler) Routes() http.Handler {
+	r := chi.NewRouter()
+	// every response, errors included, carries the id the problem details and the logs refer to
+	r.Use(requestID, h.accessLog, instrument)
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		problem.Write(w, r, problem.New(http.StatusNotFound, problem.TypeNotFound, "No route matches "+r.URL.Path))
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		problem.Write(w, r, problem.New(http.StatusMethodNotAllowed, problem.TypeBlank, r.Method+" is not supported on "+r.URL.Path))
+	})
 
-	var s models.Student
-	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
+	// Staff logins, these hand out the bearer tokens so they cannot require one
+	r.Route("/api/v1/auth", func(r chi.Router) {
+		r.Use(h.rateLimit("login"))
+		r.Post("/login", h.login)
+		r.Post("/refresh", h.refresh)
+		r.Post("/logout", h.logout)
+	})
 
-	// Build query dynamically based on provided fields
-	if s.Name != "" {
-		updates = append(updates, "name=$"+fmt.Sprint(len(values)+1))
-		values = append(values, s.Name)
-	}
-	if s.Age != 0 {
+	r.Route("/api/v1", func(r chi.Router) { // Versioned routes under /api/v1
 
-		if err := validation.ValidateAge(s.Age); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-		updates = append(updates, "age=$"+fmt.Sprint(len(values)+1))
-		values = append(values, s.Age)
-	}
-	if s.Class != 0 {
-		if err := validation.ValidateClass(s.Class); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-		updates = append(updates, "class=$"+fmt.Sprint(len(values)+1))
-		values = append(values, s.Class)
-	}
+		// eida use korchi authentication er jonno fir all routes below, every group then checks its own scopes
+		// and counts its requests against its own rate limit policy, see RATE_LIMIT_FILE.
+		// The client IP is limited first, failed authentications count as well
+		r.Use(h.clientRateLimit("clients"), h.authenticate)
+		// The list endpoints, the most expensive reads
+		r.Group(func(r chi.Router) {
+			r.Use(h.rateLimit("lists"))
+			r.With(requireScope(auth.ScopeStudentsRead)).Get("/students", h.getStudentsAll)
+			r.With(requireScope(auth.ScopeTeachersRead)).Get("/teachers", h.getTeachersAll)
+			r.With(requireScope(auth.ScopeCoursesRead)).Get("/courses", h.getCoursesAll)
+			r.With(requireScope(auth.ScopeGradesRead)).Get("/exams", h.getExamsAll)
 
-	if len(updates) == 0 {
-		http.Error(w, "No fields to update", http.StatusBadRequest)
-		return
-	}
+		})
 
-	query := fmt.Sprintf("UPDATE students SET %s WHERE id=$1",
-		stringJoin(updates, ", "))
+		// Group for student creation, retries with the same Idempotency-Key create nothing twice
+		r.Group(func(r chi.Router) {
+			r.Use(h.rateLimit("create"))
+			// the scope is checked first so a refused request never claims the key
+			r.With(requireScope(auth.ScopeStudentsWrite), h.idempotent).Post("/students", h.createStudentSingle)
+			r.With(requireScope(auth.ScopeStudentsWrite), h.idempotent).Post("/students/bulk", h.createStudentBulk)
+			r.With(requireScope(auth.ScopeTeachersWrite), h.idempotent).Post("/teachers", h.createTeacherSingle)
+			r.With(requireScope(auth.ScopeTeachersWrite), h.idempotent).Post("/teachers/bulk", h.createTeacherBulk)
+		})
 
-	_, err := db.Exec(query, values...)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
+		// Group for student modifications
+		r.Group(func(r chi.Router) {
+			r.Use(h.rateLimit("students"))
+			r.Use(requireReadWrite(auth.ScopeStudentsRead, auth.ScopeStudentsWrite))
+			r.Put("/students/{id}", h.updateStudent)
+			r.Delete("/students/{id}", h.deleteStudent)
+			r.Patch("/students/{id}", h.patchStudent)
+			r.Get("/students/{id}", h.getStudentOne)
+		})
 
-	log.Printf("Student with id %s is updated.", id)
+		// Same surface for teachers
+		r.Group(func(r chi.Router) {
+			r.Use(h.rateLimit("teachers"))
+			r.Use(requireReadWrite(auth.ScopeTeachersRead, auth.ScopeTeachersWrite))
+			r.Put("/teachers/{id}", h.updateTeacher)
+			r.Delete("/teachers/{id}", h.deleteTeacher)
+			r.Patch("/teachers/{id}", h.patchTeacher)
+			r.Get("/teachers/{id}", h.getTeacherOne)
+		})
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(s)
-}
+		// Courses and the enrollments linking them to students
+		r.Group(func(r chi.Router) {
+			r.Use(h.rateLimit("courses"))
+			r.Use(requireReadWrite(auth.ScopeCoursesRead, auth.ScopeCoursesWrite))
+			r.With(h.idempotent).Post("/courses", h.createCourse)
+			r.Get("/courses/{id}", h.getCourseOne)
+			r.Put("/courses/{id}", h.updateCourse)
+			r.Delete("/courses/{id}", h.deleteCourse)
+
+			r.Get("/courses/{id}/students", h.getCourseStudents)
+			r.With(h.idempotent).Post("/courses/{id}/enrollments", h.enrollStudent)
+			r.With(h.idempotent).Post("/courses/{id}/enrollments/bulk", h.enrollStudentsBulk)
+			r.Delete("/courses/{id}/enrollments", h.unenrollStudentsBulk)
+			r.Delete("/courses/{id}/enrollments/{studentID}", h.unenrollStudent)
+			r.Get("/students/{id}/courses", h.getStudentCourses)
+		})
 
-// helper function to join strings
-func stringJoin(arr []string, sep string) string {
-	result := ""
-	for i, s := range arr {
-		if i > 0 {
-			result += sep
-		}
-		result += s
-	}
-	return result
-}
+		// Daily attendance, marked per class and read back per student or per class
+		r.Group(func(r chi.Router) {
+			r.Use(h.rateLimit("attendance"))
+			r.Use(requireReadWrite(auth.ScopeAttendanceRead, auth.ScopeAttendanceWrite))
+			r.With(h.idempotent).Post("/attendance", h.markAttendance)
+			r.Get("/students/{id}/attendance", h.getStudentAttendance)
+			r.Get("/students/{id}/attendance/summary", h.getStudentAttendanceSummary)
+			r.Get("/classes/{class}/attendance/summary", h.getClassAttendanceSummary)
+		})
 
-// GET --get information of a single student
-func getStudentOne(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
+		// Exams, their grades and the report cards computed from them
+		r.Group(func(r chi.Router) {
+			r.Use(h.rateLimit("grades"))
+			r.Use(requireReadWrite(auth.ScopeGradesRead, auth.ScopeGradesWrite))
+			r.With(h.idempotent).Post("/exams", h.createExam)
+			r.Get("/exams/{id}", h.getExamOne)
+			r.Delete("/exams/{id}", h.deleteExam)
+
+			r.Get("/exams/{id}/grades", h.getExamGrades)
+			r.With(h.idempotent).Post("/exams/{id}/grades", h.recordGradesBulk)
+			r.Put("/exams/{id}/grades/{studentID}", h.putGrade)
+			r.Delete("/exams/{id}/grades/{studentID}", h.deleteGrade)
+			r.Get("/students/{id}/report-card", h.getReportCard)
+		})
 
-	var s models.Student
-	err := db.QueryRow("SELECT * FROM students WHERE id=$1", id).Scan(&s.ID, &s.Name, &s.Age, &s.Class)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			http.Error(w, "Student not found", http.StatusNotFound)
-		} else {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-		}
-		return
-	}
+		// API key management, admins only
+		r.Route("/admin/keys", func(r chi.Router) {
+			r.Use(h.rateLimit("admin"))
+			r.Use(requireScope(auth.ScopeAdmin))
+			r.Get("/", h.getAPIKeys)
+			r.Post("/", h.createAPIKey)
+			r.Get("/{id}", h.getAPIKey)
+			r.Delete("/{id}", h.revokeAPIKey)
+			r.Post("/{id}/rotate", h.rotateAPIKey)
+		})
 
-	// Log the JSON response before sending it to the client
-	jsonResponse, err := json.Marshal(s)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	log.Println("Student details sent for ID", id, "\n", string(jsonResponse))
+		// Parents and other contacts of a student
+		r.Group(func(r chi.Router) {
+			r.Use(h.rateLimit("guardians"))
+			r.Use(requireReadWrite(auth.ScopeGuardiansRead, auth.ScopeGuardiansWrite))
+			r.Get("/students/{id}/guardians", h.getStudentGuardians)
+			r.With(h.idempotent).Post("/students/{id}/guardians", h.addStudentGuardian)
+			r.Get("/students/{id}/guardians/{guardianID}", h.getStudentGuardian)
+			r.Put("/students/{id}/guardians/{guardianID}", h.updateStudentGuardian)
+			r.Delete("/students/{id}/guardians/{guardianID}", h.removeStudentGuardian)
+		})
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(s)
+	})
+	return r
 }
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"school_api_postgres/store"
+)
+
+// testKey ... the bootstrap API key of every test server, it has every scope
+const testKey = "test-bootstrap-key"
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// testAPI ... the routes of a Handler over fresh in-memory stores
+type testAPI struct {
+	t      *testing.T
+	routes http.Handler
+	stores store.Stores
+}
+
+// newTestAPI reads its configuration from the environment like New does, set variables with t.Setenv first
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+	t.Setenv("ValidAPIKey", testKey)
+	stores := store.NewMemoryStores()
+	return &testAPI{t: t, routes: New(stores).Routes(), stores: stores}
+}
+
+// do sends a request with the bootstrap key, headers are name, value pairs and replace the defaults,
+// an empty value removes the header
+func (a *testAPI) do(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	a.t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(APIKeyHeader, testKey)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		if headers[i+1] == "" {
+			req.Header.Del(headers[i])
+		} else {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+	}
+	rec := httptest.NewRecorder()
+	a.routes.ServeHTTP(rec, req)
+	return rec
+}
+
+// decode unmarshals the body of rec into v
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding %q: %v", rec.Body.String(), err)
+	}
+}
+
+// problemType ... the type of a problem+json response, empty for anything else
+func problemType(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	if rec.Header().Get("Content-Type") != "application/problem+json" {
+		return ""
+	}
+	var p struct {
+		Type string `json:"type"`
+	}
+	decode(t, rec, &p)
+	return p.Type
+}
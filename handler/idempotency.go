@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"school_api_postgres/auth"
+	"school_api_postgres/problem"
+	"school_api_postgres/store"
+)
+
+const (
+	// IdempotencyKeyHeader ... set by clients on create requests they may have to retry
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// defaultIdempotencyTTL ... how long a key is remembered unless IDEMPOTENCY_TTL says otherwise
+	defaultIdempotencyTTL = 24 * time.Hour
+	maxIdempotencyKeyLen  = 255
+)
+
+// idempotent makes a create route safe to retry: the first request with an Idempotency-Key runs as usual and
+// its response is saved, a retry with the same key and body gets that response back instead of creating again.
+// The same key with a different body is a client bug and gets 422. Requests without the header are not affected
+func (h *Handler) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			badRequest(w, r, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			badRequest(w, r, "Failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// every idempotent route is behind authentication, the subject keeps callers from sharing keys
+		var principal string
+		if p := auth.FromContext(r.Context()); p != nil {
+			principal = p.Subject
+		}
+
+		saved, err := h.idempotency.Claim(r.Context(), principal, key, fingerprint(principal, r, body), h.idempotencyTTL)
+		switch {
+		case errors.Is(err, store.ErrKeyReused):
+			problem.Write(w, r, problem.New(http.StatusUnprocessableEntity, problem.TypeIdempotencyKeyReused,
+				"This Idempotency-Key was used for a different request, use a new key for new data"))
+			return
+		case errors.Is(err, store.ErrKeyInProgress):
+			problem.Write(w, r, problem.New(http.StatusConflict, problem.TypeIdempotencyInProgress,
+				"The first request with this Idempotency-Key is still running, retry in a moment"))
+			return
+		case err != nil:
+			internalError(w, r, err)
+			return
+		case saved != nil:
+			for name, value := range map[string]string{
+				"Content-Type": saved.ContentType, "Location": saved.Location, "ETag": saved.ETag,
+			} {
+				if value != "" {
+					w.Header().Set(name, value)
+				}
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(saved.Status)
+			w.Write(saved.Body)
+			return
+		}
+
+		// finish the bookkeeping even if the client hangs up halfway
+		ctx := context.WithoutCancel(r.Context())
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		completed := false
+		defer func() {
+			if !completed {
+				if err := h.idempotency.Release(ctx, principal, key); err != nil {
+					slog.ErrorContext(r.Context(), "releasing idempotency key", "err", err)
+				}
+			}
+		}()
+
+		next.ServeHTTP(rec, r)
+
+		// a 5xx may well succeed when retried, so the key is given back instead of pinning the failure
+		if rec.status >= http.StatusInternalServerError {
+			return
+		}
+		resp := store.SavedResponse{
+			Status:      rec.status,
+			ContentType: rec.Header().Get("Content-Type"),
+			Location:    rec.Header().Get("Location"),
+			ETag:        rec.Header().Get("ETag"),
+			Body:        rec.body.Bytes(),
+		}
+		if err := h.idempotency.Complete(ctx, principal, key, resp); err != nil {
+			slog.ErrorContext(r.Context(), "saving idempotent response", "err", err)
+			return
+		}
+		completed = true
+	})
+}
+
+// fingerprint ... identifies what was asked for and by whom, the same key must always come with the same request
+func fingerprint(principal string, r *http.Request, body []byte) string {
+	sum := sha256.New()
+	io.WriteString(sum, principal+"\n"+r.Method+" "+r.URL.Path+"\n")
+	sum.Write(body)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// responseRecorder ... passes the response through and keeps a copy of the status and body
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status, rec.wroteHeader = status, true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// purgeExpired deletes expired idempotency keys and refresh tokens every hour until ctx is done
+func (h *Handler) purgeExpired(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := h.idempotency.DeleteExpired(ctx)
+			if err != nil {
+				slog.Error("purging idempotency keys", "err", err)
+			} else if n > 0 {
+				slog.Info("purged expired idempotency keys", "count", n)
+			}
+			n, err = h.refreshTokens.DeleteExpired(ctx)
+			if err != nil {
+				slog.Error("purging refresh tokens", "err", err)
+			} else if n > 0 {
+				slog.Info("purged expired refresh tokens", "count", n)
+			}
+		}
+	}
+}
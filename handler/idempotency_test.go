@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"school_api_postgres/auth"
+	"school_api_postgres/models"
+	"school_api_postgres/problem"
+	"school_api_postgres/store"
+)
+
+func TestIdempotentCreate(t *testing.T) {
+	const body = `{"name":"Rahim","age":12,"class":6}`
+	tests := []struct {
+		name        string
+		retryBody   string
+		retryKey    string
+		wantStatus  int
+		wantType    string
+		wantReplay  bool
+		wantCreated int
+	}{
+		{"retry is replayed", body, "k1", http.StatusCreated, "", true, 1},
+		{"same key, other body", `{"name":"Karim","age":12,"class":6}`, "k1", http.StatusUnprocessableEntity, problem.TypeIdempotencyKeyReused, false, 1},
+		{"other key", body, "k2", http.StatusCreated, "", false, 2},
+		{"no key", body, "", http.StatusCreated, "", false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t)
+			first := api.do("POST", "/api/v1/students", body, IdempotencyKeyHeader, "k1")
+			if first.Code != http.StatusCreated {
+				t.Fatalf("first request: %d %s", first.Code, first.Body)
+			}
+
+			rec := api.do("POST", "/api/v1/students", tt.retryBody, IdempotencyKeyHeader, tt.retryKey)
+			if rec.Code != tt.wantStatus || problemType(t, rec) != tt.wantType {
+				t.Fatalf("retry: %d %s, want %d %s", rec.Code, rec.Body, tt.wantStatus, tt.wantType)
+			}
+			replayed := rec.Header().Get("Idempotent-Replayed") == "true"
+			if replayed != tt.wantReplay {
+				t.Errorf("Idempotent-Replayed = %v, want %v", replayed, tt.wantReplay)
+			}
+			if replayed {
+				if rec.Body.String() != first.Body.String() {
+					t.Errorf("replayed %s, first response was %s", rec.Body, first.Body)
+				}
+				for _, h := range []string{"Content-Type", "Location", "ETag"} {
+					if rec.Header().Get(h) != first.Header().Get(h) {
+						t.Errorf("replayed %s %q, first response had %q", h, rec.Header().Get(h), first.Header().Get(h))
+					}
+				}
+			}
+
+			_, total, _ := api.stores.Students.List(t.Context(), store.StudentFilter{}, store.ListOptions{})
+			if total != tt.wantCreated {
+				t.Errorf("%d students stored, want %d", total, tt.wantCreated)
+			}
+		})
+	}
+}
+
+func TestIdempotencyKeysBelongToTheirPrincipal(t *testing.T) {
+	api := newTestAPI(t)
+	key, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = api.stores.APIKeys.Create(t.Context(), models.APIKey{
+		Name: "importer", Prefix: prefix, SecretHash: hash, Scopes: []string{auth.ScopeStudentsWrite},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api.do("POST", "/api/v1/students", `{"name":"Rahim","age":12,"class":6}`, IdempotencyKeyHeader, "shared")
+	// another caller picking the same key neither sees the first response nor gets 422
+	rec := api.do("POST", "/api/v1/students", `{"name":"Karim","age":12,"class":6}`,
+		IdempotencyKeyHeader, "shared", APIKeyHeader, key)
+	if rec.Code != http.StatusCreated || rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("second principal: %d %s", rec.Code, rec.Body)
+	}
+	var s models.Student
+	decode(t, rec, &s)
+	if s.Name != "Karim" {
+		t.Errorf("second principal got %+v", s)
+	}
+}
+
+func TestIdempotencyKeyInProgress(t *testing.T) {
+	const body = `{"name":"Rahim","age":12,"class":6}`
+	api := newTestAPI(t)
+	// a claim nobody completed looks like a request that is still running, the bootstrap key is its principal
+	req := httptest.NewRequest("POST", "/api/v1/students", nil)
+	if _, err := api.stores.Idempotency.Claim(t.Context(), "bootstrap", "busy", fingerprint("bootstrap", req, []byte(body)), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	rec := api.do("POST", "/api/v1/students", body, IdempotencyKeyHeader, "busy")
+	if rec.Code != http.StatusConflict || problemType(t, rec) != problem.TypeIdempotencyInProgress {
+		t.Fatalf("got %d %s, want 409", rec.Code, rec.Body)
+	}
+}
+
+func TestIdempotencySavesRefusals(t *testing.T) {
+	api := newTestAPI(t)
+	// a refused request is saved like any other response, fixing the body needs a new key
+	rec := api.do("POST", "/api/v1/students", `{"name":"","age":12,"class":6}`, IdempotencyKeyHeader, "k")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid student: %d", rec.Code)
+	}
+	rec = api.do("POST", "/api/v1/students", `{"name":"","age":12,"class":6}`, IdempotencyKeyHeader, "k")
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retried invalid student: %d, replayed %q", rec.Code, rec.Header().Get("Idempotent-Replayed"))
+	}
+
+	rec = api.do("POST", "/api/v1/students", `{}`, IdempotencyKeyHeader, strings.Repeat("k", maxIdempotencyKeyLen+1))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("overlong key: %d, want 400", rec.Code)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	base := fingerprint("apikey:1", httptest.NewRequest("POST", "/api/v1/students", nil), []byte(`{"a":1}`))
+	tests := []struct {
+		name, principal, method, path, body string
+	}{
+		{"principal", "apikey:2", "POST", "/api/v1/students", `{"a":1}`},
+		{"method", "apikey:1", "PUT", "/api/v1/students", `{"a":1}`},
+		{"path", "apikey:1", "POST", "/api/v1/teachers", `{"a":1}`},
+		{"body", "apikey:1", "POST", "/api/v1/students", `{"a":2}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if fingerprint(tt.principal, httptest.NewRequest(tt.method, tt.path, nil), []byte(tt.body)) == base {
+				t.Errorf("another %s gives the same fingerprint", tt.name)
+			}
+		})
+	}
+	if again := fingerprint("apikey:1", httptest.NewRequest("POST", "/api/v1/students", nil), []byte(`{"a":1}`)); again != base {
+		t.Error("the same request gives another fingerprint")
+	}
+}
@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"school_api_postgres/auth"
+	"school_api_postgres/models"
+	"school_api_postgres/problem"
+	"school_api_postgres/store"
+)
+
+const (
+	// maxLoginFailures failed logins in a row lock an account for lockoutDuration
+	maxLoginFailures = 5
+	lockoutDuration  = 15 * time.Minute
+
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// loginRequest ... body of POST /auth/login
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// refreshRequest ... body of POST /auth/refresh and /auth/logout
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// tokenResponse ... what a login or refresh hands out, expires_in is in seconds as in OAuth 2
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// POST --log in: {"username": "rahim", "password": "..."} gives an access token and a refresh token
+// an unknown user, a wrong password and a locked account all get the same answer, so nobody can tell which
+// usernames exist. Too many failures lock the account for a while, the lock only shows in the logs and metrics
+func (h *Handler) login(w http.ResponseWriter, r *http.Request) {
+	if !h.tokens.CanSign() {
+		loginsDisabled(w, r)
+		return
+	}
+
+	var req loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	if req.Username == "" || req.Password == "" {
+		badRequest(w, r, "username and password are required")
+		return
+	}
+
+	u, err := h.users.GetByUsername(r.Context(), req.Username)
+	if errors.Is(err, store.ErrNotFound) {
+		auth.CheckPassword("", req.Password)
+		authFailures.Inc("bad_password")
+		unauthorized(w, r, "Wrong username or password")
+		return
+	}
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	// a locked account never logs in, even with the right password, so guessing on is pointless.
+	// The password is still checked so the answer takes as long as any other
+	now := time.Now()
+	if u.LockedUntil != nil && now.Before(*u.LockedUntil) {
+		auth.CheckPassword(u.PasswordHash, req.Password)
+		authFailures.Inc("account_locked")
+		slog.InfoContext(r.Context(), "login to a locked account refused", "user_id", u.ID, "locked_until", *u.LockedUntil)
+		unauthorized(w, r, "Wrong username or password")
+		return
+	}
+	if !auth.CheckPassword(u.PasswordHash, req.Password) {
+		authFailures.Inc("bad_password")
+		u, err = h.users.LoginFailed(r.Context(), u.ID, maxLoginFailures, now.Add(lockoutDuration))
+		if err != nil {
+			internalError(w, r, err)
+			return
+		}
+		if u.LockedUntil != nil && now.Before(*u.LockedUntil) {
+			slog.WarnContext(r.Context(), "account locked", "user_id", u.ID, "failures", maxLoginFailures,
+				"locked_until", *u.LockedUntil)
+		}
+		unauthorized(w, r, "Wrong username or password")
+		return
+	}
+	if err := h.users.LoginSucceeded(r.Context(), u.ID); err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	family, err := auth.NewTokenFamily()
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	h.issueTokens(w, r, u, family)
+}
+
+// POST --exchange a refresh token for a new access token and the next refresh token, each one works once
+// a used refresh token coming back means someone else has a copy, so the whole login is ended
+func (h *Handler) refresh(w http.ResponseWriter, r *http.Request) {
+	if !h.tokens.CanSign() {
+		loginsDisabled(w, r)
+		return
+	}
+
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	if req.RefreshToken == "" {
+		badRequest(w, r, "refresh_token is required")
+		return
+	}
+
+	t, err := h.refreshTokens.Consume(r.Context(), auth.HashRefreshToken(req.RefreshToken), time.Now())
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		unauthorized(w, r, "The refresh token is not valid, log in again")
+		return
+	case errors.Is(err, store.ErrTokenReused):
+		slog.WarnContext(r.Context(), "refresh token reused, its login was ended")
+		unauthorized(w, r, "The refresh token was already used or its login has ended, log in again")
+		return
+	case err != nil:
+		internalError(w, r, err)
+		return
+	}
+	if time.Now().After(t.ExpiresAt) {
+		unauthorized(w, r, "The refresh token has expired, log in again")
+		return
+	}
+
+	u, err := h.users.Get(r.Context(), t.UserID)
+	if errors.Is(err, store.ErrNotFound) {
+		unauthorized(w, r, "The account no longer exists")
+		return
+	}
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	// a lock ends every way of getting new tokens, not just the password, and is answered like login answers it
+	if u.LockedUntil != nil && time.Now().Before(*u.LockedUntil) {
+		authFailures.Inc("account_locked")
+		slog.InfoContext(r.Context(), "refresh of a locked account refused", "user_id", u.ID, "locked_until", *u.LockedUntil)
+		unauthorized(w, r, "Wrong username or password")
+		return
+	}
+	h.issueTokens(w, r, u, t.Family)
+}
+
+// POST --log out: ends the login the refresh token belongs to, access tokens already handed out run out by themselves
+func (h *Handler) logout(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+	if req.RefreshToken == "" {
+		badRequest(w, r, "refresh_token is required")
+		return
+	}
+
+	if err := h.refreshTokens.RevokeFamily(r.Context(), auth.HashRefreshToken(req.RefreshToken)); err != nil {
+		internalError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// issueTokens ... a new access token for u and the next refresh token of family
+func (h *Handler) issueTokens(w http.ResponseWriter, r *http.Request, u models.User, family string) {
+	now := time.Now()
+	access, err := h.tokens.Sign(auth.Claims{
+		Subject:   strconv.Itoa(u.ID),
+		Name:      u.Name,
+		Roles:     []string{u.Role},
+		ExpiresAt: now.Add(h.accessTTL),
+	}, now)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	refresh, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	err = h.refreshTokens.Create(r.Context(), models.RefreshToken{
+		UserID: u.ID, Family: family, TokenHash: hash, ExpiresAt: now.Add(h.refreshTTL),
+	})
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, tokenResponse{
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(h.accessTTL / time.Second),
+		RefreshToken: refresh,
+	})
+}
+
+func loginsDisabled(w http.ResponseWriter, r *http.Request) {
+	problem.Write(w, r, problem.New(http.StatusServiceUnavailable, problem.TypeBlank, "Logins are disabled, JWT_SECRET is not set"))
+}
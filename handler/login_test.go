@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"school_api_postgres/auth"
+	"school_api_postgres/models"
+	"school_api_postgres/problem"
+)
+
+const testPassword = "correct horse battery"
+
+// newLoginAPI ... a test API that signs tokens, with the teacher rahim and a login rate limit out of the way
+func newLoginAPI(t *testing.T) *testAPI {
+	t.Helper()
+	limits := filepath.Join(t.TempDir(), "limits.json")
+	if err := os.WriteFile(limits, []byte(`{"groups": {"login": "100/s burst 100"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("RATE_LIMIT_FILE", limits)
+	t.Setenv("JWT_SECRET", testJWTSecret)
+	api := newTestAPI(t)
+
+	hash, err := auth.HashPassword(testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.stores.Users.Create(t.Context(), models.User{Username: "rahim", Name: "Rahim", PasswordHash: hash, Role: auth.RoleTeacher}); err != nil {
+		t.Fatal(err)
+	}
+	return api
+}
+
+// testResponse ... a recorded response with helpers for the auth endpoints
+type testResponse struct {
+	*httptest.ResponseRecorder
+}
+
+// detail ... the detail of a problem response
+func (r *testResponse) detail(t *testing.T) string {
+	t.Helper()
+	var p struct {
+		Detail string `json:"detail"`
+	}
+	decode(t, r.ResponseRecorder, &p)
+	return p.Detail
+}
+
+func (a *testAPI) login(username, password string) *testResponse {
+	a.t.Helper()
+	return &testResponse{a.do("POST", "/api/v1/auth/login", `{"username":"`+username+`","password":"`+password+`"}`, APIKeyHeader, "")}
+}
+
+func (a *testAPI) refresh(token string) *testResponse {
+	a.t.Helper()
+	return &testResponse{a.do("POST", "/api/v1/auth/refresh", `{"refresh_token":"`+token+`"}`, APIKeyHeader, "")}
+}
+
+// tokens ... the tokenResponse of a successful login or refresh
+func (r *testResponse) tokens(t *testing.T) tokenResponse {
+	t.Helper()
+	if r.Code != http.StatusOK {
+		t.Fatalf("got %d %s, want tokens", r.Code, r.Body)
+	}
+	var tr tokenResponse
+	decode(t, r.ResponseRecorder, &tr)
+	return tr
+}
+
+func TestLoginAndRefresh(t *testing.T) {
+	api := newLoginAPI(t)
+	first := api.login("rahim", testPassword).tokens(t)
+	if first.TokenType != "Bearer" || first.AccessToken == "" || first.RefreshToken == "" {
+		t.Fatalf("login handed out %+v", first)
+	}
+
+	rec := api.do("GET", "/api/v1/students", "", "Authorization", "Bearer "+first.AccessToken, APIKeyHeader, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("access token refused: %d %s", rec.Code, rec.Body)
+	}
+
+	second := api.refresh(first.RefreshToken).tokens(t)
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh handed out the same refresh token again")
+	}
+	third := api.refresh(second.RefreshToken).tokens(t)
+
+	// logging out ends the login, its refresh tokens stop working
+	if rec := api.do("POST", "/api/v1/auth/logout", `{"refresh_token":"`+third.RefreshToken+`"}`, APIKeyHeader, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("logout: %d %s", rec.Code, rec.Body)
+	}
+	if rec := api.refresh(third.RefreshToken); rec.Code != http.StatusUnauthorized {
+		t.Errorf("refresh after logout: %d, want 401", rec.Code)
+	}
+}
+
+func TestRefreshTokenReuse(t *testing.T) {
+	api := newLoginAPI(t)
+	stolen := api.login("rahim", testPassword).tokens(t)
+	other := api.login("rahim", testPassword).tokens(t)
+
+	// the owner refreshes, then the thief tries the copy of the old token
+	next := api.refresh(stolen.RefreshToken).tokens(t)
+	if rec := api.refresh(stolen.RefreshToken); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("reused refresh token: %d %s, want 401", rec.Code, rec.Body)
+	}
+	// that ends the whole login, the token the owner just got included
+	if rec := api.refresh(next.RefreshToken); rec.Code != http.StatusUnauthorized {
+		t.Errorf("refresh token of an ended login: %d, want 401", rec.Code)
+	}
+	// other logins of the same user are not affected
+	api.refresh(other.RefreshToken).tokens(t)
+}
+
+func TestRefreshRefused(t *testing.T) {
+	api := newLoginAPI(t)
+	tests := []struct {
+		name, body string
+		want       int
+	}{
+		{"unknown token", `{"refresh_token":"not-a-token"}`, http.StatusUnauthorized},
+		{"missing token", `{}`, http.StatusBadRequest},
+		{"malformed body", `{"refresh_token":`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := api.do("POST", "/api/v1/auth/refresh", tt.body, APIKeyHeader, ""); rec.Code != tt.want {
+				t.Errorf("got %d %s, want %d", rec.Code, rec.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginFailuresLookAlike(t *testing.T) {
+	api := newLoginAPI(t)
+	// a login from before the lock must not outlive it through its refresh token
+	session := api.login("rahim", testPassword).tokens(t)
+	wrong := api.login("rahim", "wrong password")
+	for i := 1; i < maxLoginFailures; i++ {
+		api.login("rahim", "wrong password")
+	}
+	if u, _ := api.stores.Users.GetByUsername(t.Context(), "rahim"); u.LockedUntil == nil {
+		t.Fatalf("account not locked after %d failures", maxLoginFailures)
+	}
+
+	tests := []struct {
+		name string
+		resp *testResponse
+	}{
+		{"locked account, right password", api.login("rahim", testPassword)},
+		{"locked account, wrong password", api.login("rahim", "wrong password")},
+		{"locked account, refresh", api.refresh(session.RefreshToken)},
+		{"unknown user", api.login("nobody", "wrong password")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.resp.Code != http.StatusUnauthorized || problemType(t, tt.resp.ResponseRecorder) != problem.TypeUnauthorized {
+				t.Fatalf("got %d %s, want 401", tt.resp.Code, tt.resp.Body)
+			}
+			if got, want := tt.resp.detail(t), wrong.detail(t); got != want {
+				t.Errorf("detail %q, a wrong password gets %q", got, want)
+			}
+			if tt.resp.Header().Get("Retry-After") != "" {
+				t.Error("the answer gives the lockout away with Retry-After")
+			}
+		})
+	}
+
+	if err := api.stores.Users.Unlock(t.Context(), 1); err != nil {
+		t.Fatal(err)
+	}
+	api.login("rahim", testPassword).tokens(t)
+}
+
+func TestLoginRequests(t *testing.T) {
+	tests := []struct {
+		name, body string
+		jwt        bool
+		want       int
+	}{
+		{"missing password", `{"username":"rahim"}`, true, http.StatusBadRequest},
+		{"malformed body", `{"username":`, true, http.StatusBadRequest},
+		{"logins disabled", `{"username":"rahim","password":"` + testPassword + `"}`, false, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newLoginAPI(t)
+			if !tt.jwt {
+				t.Setenv("JWT_SECRET", "")
+				api = newTestAPI(t)
+			}
+			if rec := api.do("POST", "/api/v1/auth/login", tt.body, APIKeyHeader, ""); rec.Code != tt.want {
+				t.Errorf("got %d %s, want %d", rec.Code, rec.Body, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"school_api_postgres/logging"
+	"school_api_postgres/metrics"
+)
+
+var (
+	httpRequests = metrics.NewCounter("school_api_http_requests_total",
+		"HTTP requests by method, chi route pattern and status.", "method", "route", "status")
+	httpDuration = metrics.NewHistogram("school_api_http_request_duration_seconds",
+		"Time from the request to the end of its response, by method and chi route pattern.",
+		metrics.DefaultBuckets, "method", "route")
+	rateLimited = metrics.NewCounter("school_api_rate_limited_total",
+		"Requests refused with 429 by the rate limiter, by route group.", "group")
+	authFailures = metrics.NewCounter("school_api_auth_failures_total",
+		"Requests refused with 401 or 403: missing_credentials, bad_api_key, bad_token, bad_password, account_locked or missing_scope.",
+		"reason")
+)
+
+// instrument counts and times every request. It labels them with the route pattern like /api/v1/students/{id},
+// never the path, or every id would become a series of its own
+func instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := chi.RouteContext(r.Context()).RoutePattern()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := metricMethod(r.Method)
+		httpRequests.Inc(method, route, strconv.Itoa(status))
+		httpDuration.Observe(time.Since(start).Seconds(), method, route)
+	})
+}
+
+// metricMethod ... the method label of a request. The method is whatever the client sent, any other than the
+// standard ones is counted as OTHER so made up methods cannot create series without end
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+		http.MethodOptions:
+		return method
+	default:
+		return "OTHER"
+	}
+}
+
+// metricsServer ... serves /metrics on METRICS_ADDR (default :9090), nil when that is "-". The port is its own so
+// neither the service nor the ingress expose it, only a Prometheus inside the cluster scraping the pods reaches it
+func metricsServer() *http.Server {
+	addr := os.Getenv("METRICS_ADDR")
+	if addr == "-" {
+		return nil
+	}
+	if addr == "" {
+		addr = ":9090"
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Default.Handler())
+	return &http.Server{Addr: addr, Handler: mux, ErrorLog: logging.Std(slog.LevelWarn)}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"school_api_postgres/metrics"
+)
+
+func TestInstrument(t *testing.T) {
+	api := newTestAPI(t)
+	api.do("GET", "/api/v1/students/424242", "")
+	api.do("GET", "/api/v1/students", "", APIKeyHeader, "")
+	api.do("GET", "/nowhere", "")
+	api.do("FOOBAR", "/api/v1/students", "")
+
+	var b strings.Builder
+	if _, err := metrics.Default.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	tests := []struct {
+		name, series string
+	}{
+		{"route pattern", `school_api_http_requests_total{method="GET",route="/api/v1/students/{id}",status="404"}`},
+		{"refused before routing", `school_api_http_requests_total{method="GET",route="/api/v1/*",status="401"}`},
+		{"no route", `school_api_http_requests_total{method="GET",route="unmatched",status="404"}`},
+		{"latency", `school_api_http_request_duration_seconds_count{method="GET",route="/api/v1/students/{id}"}`},
+		{"made up method", `school_api_http_requests_total{method="OTHER",route="unmatched",status="405"}`},
+		{"auth failure", `school_api_auth_failures_total{reason="missing_credentials"}`},
+	}
+	for _, tt := range tests {
+		if !strings.Contains(out, tt.series+" ") {
+			t.Errorf("%s: no %s in\n%s", tt.name, tt.series, out)
+		}
+	}
+	if strings.Contains(out, "424242") {
+		t.Error("the id of a path became a label value")
+	}
+	if strings.Contains(out, "FOOBAR") {
+		t.Error("a made up method became a label value")
+	}
+}
+
+func TestMetricsServer(t *testing.T) {
+	t.Setenv("METRICS_ADDR", "-")
+	if metricsServer() != nil {
+		t.Error("METRICS_ADDR=- still serves metrics")
+	}
+	t.Setenv("METRICS_ADDR", "")
+	srv := metricsServer()
+	if srv == nil || srv.Addr != ":9090" {
+		t.Fatalf("default metrics server %+v", srv)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "# TYPE school_api_http_requests_total counter") {
+		t.Errorf("GET /metrics: %d %s", rec.Code, rec.Body)
+	}
+	rec = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rec, httptest.NewRequest("POST", "/metrics", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /metrics: %d", rec.Code)
+	}
+}
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"school_api_postgres/store"
+)
+
+const (
+	defaultPageLimit = 3
+	// maxPageLimit ... larger limits are capped so nobody can pull the whole table with limit=1000000
+	maxPageLimit = 100
+	// maxPageOffset ... the deepest row page and limit may reach, OFFSET reads and throws away every row before it
+	// and a huge page would overflow (page-1)*limit. Deeper than that is what cursor paging is for
+	maxPageOffset = 100_000
+)
+
+// pageEnvelope ... what every list endpoint returns instead of a bare array
+type pageEnvelope struct {
+	Data       interface{} `json:"data"`
+	Page       int         `json:"page"`
+	Limit      int         `json:"limit"`
+	Total      int         `json:"total"`
+	TotalPages int         `json:"total_pages"`
+	Next       string      `json:"next,omitempty"`
+	Prev       string      `json:"prev,omitempty"`
+	// NextCursor lets a client switch to keyset paging from any page
+	NextCursor string `json:"next_cursor,omitempty"`
+	// LimitCapped ... the limit asked for was above maxPageLimit, Limit is what was used
+	LimitCapped bool `json:"limit_capped,omitempty"`
+}
+
+// cursorEnvelope ... list response in keyset mode, no page numbers or totals because counting defeats the purpose
+type cursorEnvelope struct {
+	Data       interface{} `json:"data"`
+	Limit      int         `json:"limit"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	Next       string      `json:"next,omitempty"`
+	// LimitCapped ... same as in pageEnvelope
+	LimitCapped bool `json:"limit_capped,omitempty"`
+}
+
+// parsePage reads page and limit, the defaults apply when they are missing and anything but a positive number is an
+// error. A limit above maxPageLimit is capped, capped tells the client so. A page starting past maxPageOffset is an error
+func parsePage(q url.Values) (page, limit int, capped bool, err error) {
+	page, limit = 1, defaultPageLimit
+	for _, p := range []struct {
+		name   string
+		target *int
+	}{{"page", &page}, {"limit", &limit}} {
+		v, err := optionalInt(q, p.name)
+		if err != nil {
+			return 0, 0, false, err
+		}
+		if v == nil {
+			continue
+		}
+		if *v < 1 {
+			return 0, 0, false, fmt.Errorf("query parameter %q must be a positive integer", p.name)
+		}
+		*p.target = *v
+	}
+	if limit > maxPageLimit {
+		limit, capped = maxPageLimit, true
+	}
+	// compared by division so the check itself cannot overflow
+	if page-1 > maxPageOffset/limit {
+		return 0, 0, false, fmt.Errorf("page %d is too deep, page and limit can reach %d rows, use cursor paging beyond that",
+			page, maxPageOffset)
+	}
+	return page, limit, capped, nil
+}
+
+// writePage sends the envelope together with X-Total-Count and an RFC 5988 Link header
+func writePage(w http.ResponseWriter, r *http.Request, data interface{}, page, limit, total int, nextCursor string, capped bool) {
+	totalPages := (total + limit - 1) / limit
+
+	env := pageEnvelope{
+		Data:        data,
+		Page:        page,
+		Limit:       limit,
+		Total:       total,
+		TotalPages:  totalPages,
+		NextCursor:  nextCursor,
+		LimitCapped: capped,
+	}
+
+	var links []string
+	addLink := func(rel string, p int) string {
+		u := pageURL(r, p, limit)
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, u, rel))
+		return u
+	}
+	if page < totalPages {
+		env.Next = addLink("next", page+1)
+	}
+	if page > 1 {
+		// past the end, prev points at the last real page instead of page-1
+		env.Prev = addLink("prev", min(page-1, max(totalPages, 1)))
+	}
+	addLink("first", 1)
+	addLink("last", max(totalPages, 1))
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	writeEnvelope(w, r, env)
+}
+
+// writeCursorPage ... keyset counterpart of writePage, nextCursor is empty on the last page
+func writeCursorPage(w http.ResponseWriter, r *http.Request, data interface{}, limit int, nextCursor string, capped bool) {
+	env := cursorEnvelope{Data: data, Limit: limit, NextCursor: nextCursor, LimitCapped: capped}
+	if nextCursor != "" {
+		q := r.URL.Query()
+		q.Set("cursor", nextCursor)
+		q.Set("limit", strconv.Itoa(limit))
+		env.Next = r.URL.Path + "?" + q.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, env.Next))
+	}
+	writeEnvelope(w, r, env)
+}
+
+// writeEnvelope ... the body is hashed into a weak ETag first, so polling an unchanged page costs a 304
+func writeEnvelope(w http.ResponseWriter, r *http.Request, env interface{}) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // keep & in the links readable
+	if err := enc.Encode(env); err != nil {
+		internalError(w, r, err)
+		return
+	}
+	if notModified(w, r, bodyETag(buf.Bytes())) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// pageURL ... the current request URL with page and limit replaced, every filter is kept
+func pageURL(r *http.Request, page, limit int) string {
+	q := r.URL.Query()
+	q.Set("page", strconv.Itoa(page))
+	q.Set("limit", strconv.Itoa(limit))
+	return r.URL.Path + "?" + q.Encode()
+}
+
+// listPage serves a list endpoint in offset or cursor mode depending on the query, ?cursor= switches to keyset
+// pagination and an empty cursor asks for the first page. list runs the store query, cursorOf builds the cursor
+// of the last row. It returns the rows that were sent, ok is false when an error response was written instead
+func listPage[T any](w http.ResponseWriter, r *http.Request, cursors *cursorSigner, sort []store.SortField,
+	list func(opts store.ListOptions) ([]T, int, error), cursorOf func(T, []store.SortField) store.Cursor) ([]T, bool) {
+
+	q := r.URL.Query()
+	page, limit, capped, err := parsePage(q)
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return nil, false
+	}
+
+	cursorMode := q.Has("cursor")
+	opts := store.ListOptions{Limit: limit, Offset: (page - 1) * limit, Sort: sort}
+	if cursorMode {
+		if q.Has("page") {
+			badRequest(w, r, "use either page or cursor, not both")
+			return nil, false
+		}
+		if token := q.Get("cursor"); token != "" {
+			var err error
+			if opts.After, err = cursors.decode(token, sort); err != nil {
+				badRequest(w, r, err.Error())
+				return nil, false
+			}
+		}
+		// one extra row tells us whether there is a next page without counting
+		opts.Limit, opts.Offset, opts.NoCount = limit+1, 0, true
+	}
+
+	items, total, err := list(opts)
+	if err != nil {
+		if errors.Is(err, store.ErrInvalidCursor) {
+			badRequest(w, r, err.Error())
+			return nil, false
+		}
+		internalError(w, r, err)
+		return nil, false
+	}
+
+	hasNext := page*limit < total
+	if cursorMode {
+		hasNext = len(items) > limit
+		items = items[:min(len(items), limit)]
+	}
+	nextCursor := ""
+	if hasNext && len(items) > 0 {
+		nextCursor = cursors.encode(cursorOf(items[len(items)-1], sort), sort)
+	}
+	if items == nil {
+		items = []T{} // [] instead of null in the envelope
+	}
+
+	if cursorMode {
+		writeCursorPage(w, r, items, limit, nextCursor, capped)
+	} else {
+		writePage(w, r, items, page, limit, total, nextCursor, capped)
+	}
+	return items, true
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestParsePage(t *testing.T) {
+	tests := []struct {
+		query      string
+		wantPage   int
+		wantLimit  int
+		wantCapped bool
+		wantErr    bool
+	}{
+		{"", 1, defaultPageLimit, false, false},
+		{"page=2&limit=10", 2, 10, false, false},
+		{"limit=100", 1, 100, false, false},
+		{"limit=101", 1, maxPageLimit, true, false},
+		{"page=0", 0, 0, false, true},
+		{"limit=0", 0, 0, false, true},
+		{"page=-1", 0, 0, false, true},
+		{"limit=ten", 0, 0, false, true},
+		{"page=1.5", 0, 0, false, true},
+		{"page=1001&limit=100", 1001, 100, false, false},
+		{"page=1002&limit=100", 0, 0, false, true},
+		// (page-1)*limit would overflow
+		{"page=9223372036854775807&limit=2", 0, 0, false, true},
+		{"page=9223372036854775807&limit=500", 0, 0, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, _ := url.ParseQuery(tt.query)
+			page, limit, capped, err := parsePage(q)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if page != tt.wantPage || limit != tt.wantLimit || capped != tt.wantCapped {
+				t.Errorf("parsePage = %d, %d, %v, want %d, %d, %v", page, limit, capped, tt.wantPage, tt.wantLimit, tt.wantCapped)
+			}
+		})
+	}
+}
+
+func TestStudentListEnvelope(t *testing.T) {
+	setRateLimits(t, `{"groups": {"lists": "100/s burst 100"}}`)
+	api := newTestAPI(t)
+	for i := 1; i <= 5; i++ {
+		api.do("POST", "/api/v1/students", fmt.Sprintf(`{"name":"Student %d","age":12,"class":6}`, i))
+	}
+
+	tests := []struct {
+		query      string
+		wantIDs    []int
+		wantTotal  int
+		wantPages  int
+		wantNext   string
+		wantPrev   string
+		wantCapped bool
+	}{
+		{"page=1&limit=2", []int{1, 2}, 5, 3, "/api/v1/students?limit=2&page=2", "", false},
+		{"page=3&limit=2", []int{5}, 5, 3, "", "/api/v1/students?limit=2&page=2", false},
+		{"page=9&limit=2", nil, 5, 3, "", "/api/v1/students?limit=2&page=3", false},
+		{"limit=500", []int{1, 2, 3, 4, 5}, 5, 1, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := api.do("GET", "/api/v1/students?"+tt.query, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("%d %s", rec.Code, rec.Body)
+			}
+			var env struct {
+				Data []struct {
+					ID int `json:"id"`
+				} `json:"data"`
+				Total       int    `json:"total"`
+				TotalPages  int    `json:"total_pages"`
+				Next        string `json:"next"`
+				Prev        string `json:"prev"`
+				LimitCapped bool   `json:"limit_capped"`
+			}
+			decode(t, rec, &env)
+			var ids []int
+			for _, s := range env.Data {
+				ids = append(ids, s.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("ids = %v, want %v", ids, tt.wantIDs)
+			}
+			if env.Total != tt.wantTotal || env.TotalPages != tt.wantPages {
+				t.Errorf("total %d in %d pages, want %d in %d", env.Total, env.TotalPages, tt.wantTotal, tt.wantPages)
+			}
+			if env.Next != tt.wantNext || env.Prev != tt.wantPrev {
+				t.Errorf("next %q prev %q, want %q and %q", env.Next, env.Prev, tt.wantNext, tt.wantPrev)
+			}
+			if env.LimitCapped != tt.wantCapped {
+				t.Errorf("limit_capped = %v, want %v", env.LimitCapped, tt.wantCapped)
+			}
+			if got := rec.Header().Get("X-Total-Count"); got != fmt.Sprint(tt.wantTotal) {
+				t.Errorf("X-Total-Count = %q", got)
+			}
+		})
+	}
+
+	t.Run("bad limit", func(t *testing.T) {
+		if rec := api.do("GET", "/api/v1/students?limit=0", ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("limit=0 got %d, want 400", rec.Code)
+		}
+	})
+	t.Run("page overflows the offset", func(t *testing.T) {
+		rec := api.do("GET", "/api/v1/students?page=9223372036854775807&limit=2", "")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("got %d %s, want 400", rec.Code, rec.Body)
+		}
+	})
+}
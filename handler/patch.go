@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"school_api_postgres/patch"
+	"school_api_postgres/problem"
+	"school_api_postgres/validation"
+)
+
+// readPatch parses a PATCH body by its Content-Type, writes a 415 or 400 and returns false when it cannot
+// nothing has been read from the store yet, so a broken patch costs no lock
+func readPatch(w http.ResponseWriter, r *http.Request) (patch.Patch, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		badRequest(w, r, "Failed to read request body")
+		return nil, false
+	}
+
+	p, err := patch.Parse(r.Header.Get("Content-Type"), body)
+	if errors.Is(err, patch.ErrUnsupportedType) {
+		w.Header().Set("Accept-Patch", patch.Accepted)
+		problem.Write(w, r, problem.New(http.StatusUnsupportedMediaType, problem.TypeUnsupportedMedia, err.Error()))
+		return nil, false
+	}
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return nil, false
+	}
+	return p, true
+}
+
+// applyPatch runs p against current as JSON and decodes the result into a fresh value,
+// so members the patch removed come back as zero values instead of keeping their old ones
+// the id is part of the URL and cannot be patched
+func applyPatch[T any](p patch.Patch, current T) (T, error) {
+	var next T
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return next, err
+	}
+	patched, err := p.Apply(doc)
+	if err != nil {
+		return next, err
+	}
+
+	var before, after struct {
+		ID json.RawMessage `json:"id"`
+	}
+	json.Unmarshal(doc, &before)
+	json.Unmarshal(patched, &after)
+	if !bytes.Equal(before.ID, after.ID) {
+		return next, validation.FieldError{Field: "id", Code: validation.CodeInvalid, Message: "id cannot be changed"}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&next); err != nil {
+		return next, decodeError(err)
+	}
+	return next, nil
+}
+
+// decodeError ... turns what encoding/json complains about into a field error the client can act on
+func decodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return validation.FieldError{Field: typeErr.Field, Code: validation.CodeInvalid,
+			Message: fmt.Sprintf("%s cannot be a JSON %s", typeErr.Field, typeErr.Value)}
+	}
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		field, _ := strconv.Unquote(name)
+		return validation.FieldError{Field: field, Code: validation.CodeInvalid, Message: field + " is not a known field"}
+	}
+	return validation.FieldError{Code: validation.CodeInvalid, Message: err.Error()}
+}
+
+// writePatchError ... validation and patch failures from inside Modify, anything else came from the store
+func writePatchError(w http.ResponseWriter, r *http.Request, err error, resource string) {
+	var fe validation.FieldError
+	var errs validation.Errors
+	switch {
+	case errors.As(err, &fe), errors.As(err, &errs):
+		invalid(w, r, err)
+	case errors.Is(err, patch.ErrConflict):
+		problem.Write(w, r, problem.New(http.StatusConflict, problem.TypePatchConflict, err.Error()))
+	default:
+		writeStoreError(w, r, err, resource)
+	}
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"school_api_postgres/models"
+	"school_api_postgres/patch"
+	"school_api_postgres/problem"
+)
+
+func TestPatchStudent(t *testing.T) {
+	tests := []struct {
+		name, contentType, body string
+		wantStatus              int
+		wantType                string
+		wantAge, wantClass      int
+	}{
+		{"merge patch", patch.MergePatchType, `{"age":13}`, http.StatusOK, "", 13, 6},
+		{"plain json", "application/json", `{"class":7}`, http.StatusOK, "", 12, 7},
+		{"json patch", patch.JSONPatchType,
+			`[{"op":"test","path":"/class","value":6},{"op":"replace","path":"/class","value":7}]`, http.StatusOK, "", 12, 7},
+		{"failed test", patch.JSONPatchType,
+			`[{"op":"test","path":"/class","value":5},{"op":"replace","path":"/class","value":7}]`,
+			http.StatusConflict, problem.TypePatchConflict, 12, 6},
+		{"result is invalid", patch.MergePatchType, `{"class":null}`, http.StatusBadRequest, problem.TypeValidation, 12, 6},
+		{"id cannot change", patch.MergePatchType, `{"id":5}`, http.StatusBadRequest, problem.TypeValidation, 12, 6},
+		{"unknown field", patch.MergePatchType, `{"colour":"red"}`, http.StatusBadRequest, problem.TypeValidation, 12, 6},
+		{"wrong type", patch.MergePatchType, `{"age":"twelve"}`, http.StatusBadRequest, problem.TypeValidation, 12, 6},
+		{"unsupported media type", "text/plain", `age=13`, http.StatusUnsupportedMediaType, problem.TypeUnsupportedMedia, 12, 6},
+		{"broken json patch", patch.JSONPatchType, `[{"op":"shuffle","path":"/age"}]`, http.StatusBadRequest, problem.TypeBadRequest, 12, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t)
+			api.do("POST", "/api/v1/students", `{"name":"Rahim","age":12,"class":6}`)
+
+			rec := api.do("PATCH", "/api/v1/students/1", tt.body, "Content-Type", tt.contentType)
+			if rec.Code != tt.wantStatus || problemType(t, rec) != tt.wantType {
+				t.Fatalf("got %d %s, want %d %s", rec.Code, rec.Body, tt.wantStatus, tt.wantType)
+			}
+			if rec.Code == http.StatusUnsupportedMediaType && rec.Header().Get("Accept-Patch") != patch.Accepted {
+				t.Errorf("Accept-Patch = %q", rec.Header().Get("Accept-Patch"))
+			}
+			s, _ := api.stores.Students.Get(t.Context(), 1)
+			if s.Age != tt.wantAge || s.Class != tt.wantClass || s.Name != "Rahim" {
+				t.Errorf("stored %+v, want age %d class %d", s, tt.wantAge, tt.wantClass)
+			}
+			if rec.Code == http.StatusOK {
+				var got models.Student
+				decode(t, rec, &got)
+				if got != s {
+					t.Errorf("response %+v, stored %+v", got, s)
+				}
+			}
+		})
+	}
+}
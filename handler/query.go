@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"school_api_postgres/models"
+	"school_api_postgres/store"
+)
+
+// checkParams rejects any query parameter the endpoint does not know about
+// so a typo like ?clas=7 fails loudly instead of silently returning everything
+func checkParams(q url.Values, allowed ...string) error {
+	for name := range q {
+		if !slices.Contains(allowed, name) {
+			return fmt.Errorf("unknown query parameter %q", name)
+		}
+	}
+	return nil
+}
+
+// optionalInt ... nil when the parameter is absent, error when it is not a number
+func optionalInt(q url.Values, name string) (*int, error) {
+	raw := q.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, fmt.Errorf("query parameter %q must be an integer", name)
+	}
+	return &v, nil
+}
+
+// optionalDate ... same as optionalInt for YYYY-MM-DD dates
+func optionalDate(q url.Values, name string) (*models.Date, error) {
+	raw := q.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	d, err := models.ParseDate(raw)
+	if err != nil {
+		return nil, fmt.Errorf("query parameter %q must be a date like 2025-03-01", name)
+	}
+	return &d, nil
+}
+
+// parseSort reads sort=class,-name or sort=class:asc,name:desc
+// a leading - or a :desc suffix means descending, only fields in allowed are accepted
+func parseSort(raw string, allowed []string) ([]store.SortField, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var fields []store.SortField
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		var f store.SortField
+
+		name, dir, hasDir := strings.Cut(part, ":")
+		switch {
+		case hasDir && dir == "asc":
+		case hasDir && dir == "desc":
+			f.Desc = true
+		case hasDir:
+			return nil, fmt.Errorf("sort direction must be asc or desc, got %q", dir)
+		case strings.HasPrefix(name, "-"):
+			f.Desc = true
+			name = name[1:]
+		}
+
+		if !slices.Contains(allowed, name) {
+			return nil, fmt.Errorf("cannot sort by %q, allowed fields are %s", name, strings.Join(allowed, ", "))
+		}
+		f.Field = name
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"school_api_postgres/store"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    []store.SortField
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"class", []store.SortField{{Field: "class"}}, false},
+		{"class,-name", []store.SortField{{Field: "class"}, {Field: "name", Desc: true}}, false},
+		{"class:asc, name:desc", []store.SortField{{Field: "class"}, {Field: "name", Desc: true}}, false},
+		{"password", nil, true},
+		{"-password", nil, true},
+		{"name:up", nil, true},
+		{"name,", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := parseSort(tt.raw, store.StudentSortFields)
+			if (err != nil) != tt.wantErr || fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("parseSort(%q) = %v, %v, want %v", tt.raw, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStudentQuery(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{"class=6&name=ra", "class 6, age <nil>-<nil>, name ra", false},
+		{"age=12", "class <nil>, age 12-12, name ", false},
+		{"age_min=10&age_max=12", "class <nil>, age 10-12, name ", false},
+		{"clas=7", "", true},
+		{"class=six", "", true},
+		{"age=12&age_min=10", "", true},
+		{"age_min=12&age_max=10", "", true},
+		{"sort=secret", "", true},
+	}
+	deref := func(p *int) any {
+		if p == nil {
+			return nil
+		}
+		return *p
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, _ := url.ParseQuery(tt.query)
+			f, _, err := parseStudentQuery(q)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStudentQuery = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := fmt.Sprintf("class %v, age %v-%v, name %s", deref(f.Class), deref(f.AgeMin), deref(f.AgeMax), f.NameContains)
+			if got != tt.want {
+				t.Errorf("filter %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStudentListFilters(t *testing.T) {
+	setRateLimits(t, `{"groups": {"lists": "100/s burst 100"}}`)
+	api := newTestAPI(t)
+	for _, s := range []string{
+		`{"name":"Rahim","age":12,"class":6}`,
+		`{"name":"Karim","age":14,"class":8}`,
+		`{"name":"Rahima","age":13,"class":6}`,
+	} {
+		api.do("POST", "/api/v1/students", s)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"class=6", []string{"Rahim", "Rahima"}},
+		{"class=6&sort=-age", []string{"Rahima", "Rahim"}},
+		{"name_prefix=rah&age_min=13", []string{"Rahima"}},
+		{"name=rim", []string{"Karim"}},
+		{"sort=class:desc,name", []string{"Karim", "Rahim", "Rahima"}},
+		{"class=9", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := api.do("GET", "/api/v1/students?"+tt.query, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("%d %s", rec.Code, rec.Body)
+			}
+			var env struct {
+				Data []struct {
+					Name string `json:"name"`
+				} `json:"data"`
+			}
+			decode(t, rec, &env)
+			var names []string
+			for _, s := range env.Data {
+				names = append(names, s.Name)
+			}
+			if fmt.Sprint(names) != fmt.Sprint(tt.want) {
+				t.Errorf("got %v, want %v", names, tt.want)
+			}
+		})
+	}
+
+	if rec := api.do("GET", "/api/v1/students?clas=6", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("a mistyped filter got %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"school_api_postgres/auth"
+	"school_api_postgres/problem"
+	"school_api_postgres/ratelimit"
+)
+
+// rateLimitGroups ... the route groups a policy can be set for in RATE_LIMIT_FILE
+var rateLimitGroups = []string{
+	"clients", "lists", "create", "students", "teachers", "courses", "attendance", "grades", "guardians", "admin", "login",
+}
+
+// checkRateLimits ... an error naming a group in the config that no route uses, most likely a typo
+func checkRateLimits(cfg ratelimit.Config) error {
+	for group := range cfg.Groups {
+		if !slices.Contains(rateLimitGroups, group) {
+			return fmt.Errorf("rate limits: unknown group %q, the groups are %v", group, rateLimitGroups)
+		}
+	}
+	return nil
+}
+
+// rateLimit counts the requests of a route group per caller: the principal once authenticated, the client IP
+// (its /64 for IPv6) before that. Every response gets the RateLimit-Limit, -Remaining and -Reset headers, a refused one also Retry-After.
+// If the limiter itself fails the request goes through, an outage of the limiter should not take the API with it
+func (h *Handler) rateLimit(group string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			subject := h.clientKey(r)
+			if p := auth.FromContext(r.Context()); p != nil {
+				subject = p.Subject
+			}
+			if h.allow(w, r, group, subject) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// clientRateLimit counts the requests of a group per client IP whatever credentials they carry. It runs before
+// authentication, so wrong API keys and tokens are limited too and cannot be guessed at the rate the key lookups allow.
+// The client is what clientKey makes of it: the address behind a trusted proxy, and a whole /64 for IPv6
+func (h *Handler) clientRateLimit(group string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if h.allow(w, r, group, h.clientKey(r)) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// allow counts the request against the policy of group for subject, it writes the 429 itself and returns false
+// when the request is refused
+func (h *Handler) allow(w http.ResponseWriter, r *http.Request, group, subject string) bool {
+	result, err := h.limiter.Allow(r.Context(), group+"|"+subject, h.limits.Policy(group, subject))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "rate limiter failed, letting the request through", "err", err)
+		return true
+	}
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ratelimit.Seconds(result.ResetAfter)))
+	if !result.Allowed {
+		rateLimited.Inc(group)
+		w.Header().Set("Retry-After", strconv.Itoa(ratelimit.Seconds(result.RetryAfter)))
+		problem.Write(w, r, problem.New(http.StatusTooManyRequests, problem.TypeRateLimited, "Slow down and try again in a moment"))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"school_api_postgres/problem"
+	"school_api_postgres/ratelimit"
+)
+
+// setRateLimits points RATE_LIMIT_FILE at a file holding limits
+func setRateLimits(t *testing.T, limits string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "limits.json")
+	if err := os.WriteFile(path, []byte(limits), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("RATE_LIMIT_FILE", path)
+}
+
+func TestRateLimit(t *testing.T) {
+	setRateLimits(t, `{"groups": {"lists": "1/m burst 2"}}`)
+	api := newTestAPI(t)
+
+	tests := []struct {
+		name, path          string
+		want                int
+		limit, remaining    string
+		retryAfter, resetIn string
+	}{
+		{"first of the burst", "/api/v1/students", http.StatusOK, "2", "1", "", "60"},
+		{"last of the burst", "/api/v1/students", http.StatusOK, "2", "0", "", "120"},
+		{"refused", "/api/v1/students", http.StatusTooManyRequests, "2", "0", "60", "120"},
+		// every group counts on its own
+		{"another group", "/api/v1/students/1", http.StatusNotFound, "20", "19", "", "1"},
+	}
+	for _, tt := range tests {
+		rec := api.do("GET", tt.path, "")
+		if rec.Code != tt.want {
+			t.Fatalf("%s: got %d %s, want %d", tt.name, rec.Code, rec.Body, tt.want)
+		}
+		h := rec.Header()
+		if h.Get("RateLimit-Limit") != tt.limit || h.Get("RateLimit-Remaining") != tt.remaining ||
+			h.Get("RateLimit-Reset") != tt.resetIn || h.Get("Retry-After") != tt.retryAfter {
+			t.Errorf("%s: headers limit %q remaining %q reset %q retry after %q, want %q %q %q %q", tt.name,
+				h.Get("RateLimit-Limit"), h.Get("RateLimit-Remaining"), h.Get("RateLimit-Reset"), h.Get("Retry-After"),
+				tt.limit, tt.remaining, tt.resetIn, tt.retryAfter)
+		}
+		if rec.Code == http.StatusTooManyRequests && problemType(t, rec) != problem.TypeRateLimited {
+			t.Errorf("%s: 429 is a %q problem", tt.name, problemType(t, rec))
+		}
+	}
+}
+
+func TestRateLimitPrincipalOverride(t *testing.T) {
+	setRateLimits(t, `{"groups": {"lists": "1/m burst 1"}, "principals": {"bootstrap": "100/s"}}`)
+	api := newTestAPI(t)
+	for i := 0; i < 5; i++ {
+		if rec := api.do("GET", "/api/v1/students", ""); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: %d, the principal has its own policy", i, rec.Code)
+		}
+	}
+}
+
+func TestRateLimitBeforeAuthentication(t *testing.T) {
+	setRateLimits(t, `{"groups": {"clients": "1/m burst 3"}}`)
+	api := newTestAPI(t)
+
+	// guessing keys costs the same as using one, the guesses never reach the key lookup once the IP is out
+	for i := 0; i < 3; i++ {
+		if rec := api.do("GET", "/api/v1/students", "", APIKeyHeader, "sk_00000000_guess"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("guess %d: got %d %s, want 401", i, rec.Code, rec.Body)
+		}
+	}
+	rec := api.do("GET", "/api/v1/students", "", APIKeyHeader, "sk_00000000_guess")
+	if rec.Code != http.StatusTooManyRequests || problemType(t, rec) != problem.TypeRateLimited {
+		t.Fatalf("guess after the burst: got %d %s, want 429", rec.Code, rec.Body)
+	}
+	// the limit belongs to the IP, a valid key from there waits as well
+	if rec := api.do("GET", "/api/v1/students", ""); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("valid key from the same IP: got %d, want 429", rec.Code)
+	}
+}
+
+func TestRateLimitBeforeAuthenticationPerClient(t *testing.T) {
+	setRateLimits(t, `{"groups": {"clients": "1/m burst 1"}}`)
+	t.Setenv("TRUSTED_PROXIES", "192.0.2.1")
+	api := newTestAPI(t)
+
+	// behind the proxy every forwarded client has a bucket of its own, the proxy's address is no one's
+	for _, client := range []string{"203.0.113.9", "203.0.113.10", "2001:db8:cafe:1::17"} {
+		if rec := api.do("GET", "/api/v1/students", "", "X-Forwarded-For", client); rec.Code != http.StatusOK {
+			t.Fatalf("first request of %s: got %d %s, want 200", client, rec.Code, rec.Body)
+		}
+	}
+	tests := []struct {
+		client string
+		want   int
+	}{
+		{"203.0.113.9", http.StatusTooManyRequests},
+		// another address of the same /64 is the same client
+		{"2001:db8:cafe:1::18", http.StatusTooManyRequests},
+		{"2001:db8:cafe:2::17", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if rec := api.do("GET", "/api/v1/students", "", "X-Forwarded-For", tt.client); rec.Code != tt.want {
+			t.Errorf("%s again: got %d, want %d", tt.client, rec.Code, tt.want)
+		}
+	}
+}
+
+// failingLimiter ... a limiter whose backend is down
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(context.Context, string, ratelimit.Policy) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+func (failingLimiter) Purge(context.Context) (int64, error) { return 0, nil }
+
+func TestRateLimitLimiterDown(t *testing.T) {
+	api := newTestAPI(t)
+	h := New(api.stores)
+	h.limiter = failingLimiter{}
+	api.routes = h.Routes()
+
+	// the request goes through, without headers it could not fill in
+	rec := api.do("GET", "/api/v1/students", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d %s, want 200", rec.Code, rec.Body)
+	}
+	if rec.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("RateLimit-Limit %q without a limiter", rec.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func TestCheckRateLimits(t *testing.T) {
+	tests := []struct {
+		group   string
+		wantErr bool
+	}{
+		{"lists", false},
+		{"login", false},
+		{"clients", false},
+		{"list", true},
+	}
+	for _, tt := range tests {
+		cfg := ratelimit.Config{Groups: map[string]ratelimit.Policy{tt.group: {Limit: 1, Period: time.Second, Burst: 1}}}
+		if err := checkRateLimits(cfg); (err != nil) != tt.wantErr {
+			t.Errorf("checkRateLimits with group %q = %v, want error %v", tt.group, err, tt.wantErr)
+		}
+	}
+}
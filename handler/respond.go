@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"school_api_postgres/problem"
+	"school_api_postgres/store"
+	"school_api_postgres/validation"
+)
+
+// pathID reads a numeric route parameter, writes a 400 and returns false if it is not a positive number
+func pathID(w http.ResponseWriter, r *http.Request, param string) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, param))
+	if err != nil || id <= 0 {
+		badRequest(w, r, "Invalid "+param)
+		return 0, false
+	}
+	return id, true
+}
+
+// badRequest ... 400 for input that could not be read at all: broken JSON, unknown query parameters, bad ids
+func badRequest(w http.ResponseWriter, r *http.Request, detail string) {
+	problem.Write(w, r, problem.New(http.StatusBadRequest, problem.TypeBadRequest, detail))
+}
+
+// invalid ... 400 for input that was read fine but failed validation, every field error goes into the errors member
+func invalid(w http.ResponseWriter, r *http.Request, err error) {
+	var errs validation.Errors
+	errs.Check(err)
+
+	detail := errs[0].Message
+	if len(errs) > 1 {
+		detail = fmt.Sprintf("%d problems found, see errors", len(errs))
+	}
+	p := problem.New(http.StatusBadRequest, problem.TypeValidation, detail)
+	p.Errors = errs
+	problem.Write(w, r, p)
+}
+
+// writeStoreError maps store errors to a problem, resource names the thing that was looked up
+// anything unexpected is logged and answered with a generic 500 so driver messages never reach the client
+func writeStoreError(w http.ResponseWriter, r *http.Request, err error, resource string) {
+	var p *problem.Problem
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		p = problem.New(http.StatusNotFound, problem.TypeNotFound, resource+" not found")
+	case errors.Is(err, store.ErrConflict):
+		p = problem.New(http.StatusConflict, problem.TypeConflict, resource+" "+err.Error())
+	case errors.Is(err, store.ErrRevoked):
+		p = problem.New(http.StatusConflict, problem.TypeKeyRevoked, resource+" "+err.Error()+", issue a new one instead")
+	case errors.Is(err, store.ErrInUse):
+		p = problem.New(http.StatusConflict, problem.TypeInUse, resource+" "+err.Error())
+	case errors.Is(err, store.ErrInvalidReference):
+		p = problem.New(http.StatusUnprocessableEntity, problem.TypeInvalidReference, resource+" "+err.Error())
+	case errors.Is(err, store.ErrCheckViolation):
+		p = problem.New(http.StatusUnprocessableEntity, problem.TypeConstraint, resource+" "+err.Error())
+	case errors.Is(err, store.ErrVersionMismatch):
+		preconditionFailed(w, r)
+		return
+	case errors.Is(err, store.ErrInvalidCursor):
+		p = problem.New(http.StatusBadRequest, problem.TypeBadRequest, err.Error())
+	default:
+		internalError(w, r, err)
+		return
+	}
+	problem.Write(w, r, p)
+}
+
+// internalError logs err with the request id and sends a 500 that only carries the id
+func internalError(w http.ResponseWriter, r *http.Request, err error) {
+	p := problem.New(http.StatusInternalServerError, problem.TypeInternal, "Something went wrong on our side, quote the request id when reporting it")
+	problem.Write(w, r, p)
+	slog.ErrorContext(r.Context(), "request failed", "method", r.Method, "path", r.URL.Path, "err", err)
+}
+
+// writeJSON ... sends v as a JSON body with the given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"school_api_postgres/models"
+	"school_api_postgres/problem"
+	"school_api_postgres/store"
+)
+
+// brokenStudents ... a student store whose reads fail like a database that went away
+type brokenStudents struct{ store.StudentStore }
+
+func (brokenStudents) Get(context.Context, int) (models.Student, error) {
+	return models.Student{}, errors.New(`pq: relation "students" does not exist`)
+}
+
+func TestProblemResponses(t *testing.T) {
+	api := newTestAPI(t)
+	api.stores.Students = brokenStudents{api.stores.Students}
+	api.routes = New(api.stores).Routes()
+
+	tests := []struct {
+		name, method, path string
+		want               int
+		wantType           string
+	}{
+		{"no route", "GET", "/api/v1/nowhere", http.StatusNotFound, problem.TypeNotFound},
+		{"wrong method", "PATCH", "/api/v1/students", http.StatusMethodNotAllowed, problem.TypeBlank},
+		{"store failure", "GET", "/api/v1/students/1", http.StatusInternalServerError, problem.TypeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := api.do(tt.method, tt.path, "")
+			var p problem.Problem
+			decode(t, rec, &p)
+			if rec.Code != tt.want || problemType(t, rec) != tt.wantType || p.Status != tt.want {
+				t.Fatalf("got %d %s, want %d %s", rec.Code, rec.Body, tt.want, tt.wantType)
+			}
+			if p.Instance != tt.path || p.RequestID == "" || p.RequestID != rec.Header().Get("X-Request-ID") {
+				t.Errorf("instance %q, request id %q, header %q", p.Instance, p.RequestID, rec.Header().Get("X-Request-ID"))
+			}
+			// the cause of a 500 goes to the log, never to the client
+			if strings.Contains(rec.Body.String(), "pq:") {
+				t.Errorf("the response leaks the store error: %s", rec.Body)
+			}
+		})
+	}
+}
+
+// checkedStudents ... a student store whose inserts break a CHECK constraint the handler's validation let through
+type checkedStudents struct{ store.StudentStore }
+
+func (checkedStudents) Create(context.Context, models.Student) (models.Student, error) {
+	return models.Student{}, &store.ConstraintError{Err: store.ErrCheckViolation, Constraint: "students_class_check"}
+}
+
+func (checkedStudents) CreateBatch(context.Context, []models.Student) ([]models.Student, error) {
+	return nil, &store.ConstraintError{Err: store.ErrCheckViolation, Constraint: "students_class_check"}
+}
+
+func TestStudentCreateStoreErrors(t *testing.T) {
+	api := newTestAPI(t)
+	api.stores.Students = checkedStudents{api.stores.Students}
+	api.routes = New(api.stores).Routes()
+
+	for _, path := range []string{"/api/v1/students", "/api/v1/students/bulk"} {
+		t.Run(path, func(t *testing.T) {
+			rec := api.do("POST", path, `{"name":"Rahim","age":12,"class":6}`)
+			if rec.Code != http.StatusUnprocessableEntity || problemType(t, rec) != problem.TypeConstraint {
+				t.Fatalf("got %d %s, want 422 %s", rec.Code, rec.Body, problem.TypeConstraint)
+			}
+			if strings.Contains(rec.Body.String(), "students_class_check") {
+				t.Errorf("the response leaks the constraint name: %s", rec.Body)
+			}
+		})
+	}
+}
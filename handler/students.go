@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"school_api_postgres/models"
+	"school_api_postgres/store"
+	"school_api_postgres/validation"
+	"strconv"
+)
+
+// Fetch multiple rows --- db.Query
+// Fetch a single row --- db.QueryRow
+// Insert, update, delete --- db.Exec
+// all of that now lives behind store.StudentStore
+
+// GET --get all students
+// supports ?class=7&age_min=12&age_max=14&name=rah&name_prefix=ra&sort=class,-name as well as page/limit or cursor/limit
+func (h *Handler) getStudentsAll(w http.ResponseWriter, r *http.Request) {
+
+	q := r.URL.Query()
+	filter, sort, err := parseStudentQuery(q)
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	students, ok := listPage(w, r, h.cursors, sort,
+		func(opts store.ListOptions) ([]models.Student, int, error) {
+			return h.students.List(r.Context(), filter, opts)
+		}, store.StudentCursor)
+	if !ok {
+		return
+	}
+	slog.DebugContext(r.Context(), "students listed", "count", len(students))
+
+	//time.Sleep(5 * time.Second) // for graceful shutdown cheking
+
+}
+
+// parseStudentQuery ... whitelisted filters and sort for the student list
+func parseStudentQuery(q url.Values) (store.StudentFilter, []store.SortField, error) {
+	var filter store.StudentFilter
+
+	if err := checkParams(q, "page", "limit", "cursor", "sort", "class", "age", "age_min", "age_max", "name", "name_prefix"); err != nil {
+		return filter, nil, err
+	}
+
+	var err error
+	if filter.Class, err = optionalInt(q, "class"); err != nil {
+		return filter, nil, err
+	}
+	if filter.AgeMin, err = optionalInt(q, "age_min"); err != nil {
+		return filter, nil, err
+	}
+	if filter.AgeMax, err = optionalInt(q, "age_max"); err != nil {
+		return filter, nil, err
+	}
+	// age=12 is shorthand for age_min=12&age_max=12
+	age, err := optionalInt(q, "age")
+	if err != nil {
+		return filter, nil, err
+	}
+	if age != nil {
+		if filter.AgeMin != nil || filter.AgeMax != nil {
+			return filter, nil, errors.New("use either age or age_min/age_max, not both")
+		}
+		filter.AgeMin, filter.AgeMax = age, age
+	}
+	if filter.AgeMin != nil && filter.AgeMax != nil && *filter.AgeMin > *filter.AgeMax {
+		return filter, nil, errors.New("age_min must not be greater than age_max")
+	}
+
+	filter.NameContains = q.Get("name")
+	filter.NamePrefix = q.Get("name_prefix")
+
+	sort, err := parseSort(q.Get("sort"), store.StudentSortFields)
+	if err != nil {
+		return filter, nil, err
+	}
+	return filter, sort, nil
+}
+
+// POST --insert a student
+
+func (h *Handler) createStudentSingle(w http.ResponseWriter, r *http.Request) {
+
+	var s models.Student
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	if err := validation.ValidateStudent(s); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	s, err := h.students.Create(r.Context(), s)
+	if err != nil {
+		writeStoreError(w, r, err, "Student")
+		return
+	}
+
+	// only the id is logged, the rest of a student is personal data
+	slog.InfoContext(r.Context(), "student created", "student_id", s.ID)
+
+	w.Header().Set("Location", "/api/v1/students/"+strconv.Itoa(s.ID))
+	w.Header().Set("ETag", etag(s.Version))
+	writeJSON(w, http.StatusCreated, s)
+}
+
+// Bulk with batch because query size matters, the batching itself lives in the store
+func (h *Handler) createStudentBulk(w http.ResponseWriter, r *http.Request) {
+
+	// Read the request body once and store it
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		badRequest(w, r, "Failed to read request body")
+		return
+	}
+	defer r.Body.Close()
+
+	var students []models.Student
+
+	err = json.Unmarshal(body, &students)
+	if err != nil {
+		var singleStudent models.Student
+		if err := json.Unmarshal(body, &singleStudent); err != nil {
+			badRequest(w, r, "Invalid request payload")
+			return
+		}
+		// Convert single student to array format
+		students = append(students, singleStudent)
+	}
+
+	if len(students) == 0 {
+		badRequest(w, r, "No student data provided")
+		return
+	}
+
+	// Validate each student in the bulk data, every error comes back with the index of its student
+	var errs validation.Errors
+	for i, student := range students {
+		errs.At(i, validation.ValidateStudent(student))
+	}
+	if err := errs.Err(); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	insertedStudents, err := h.students.CreateBatch(r.Context(), students)
+	if err != nil {
+		writeStoreError(w, r, err, "Student")
+		return
+	}
+
+	slog.InfoContext(r.Context(), "students inserted", "count", len(insertedStudents))
+
+	// this not necessary in the case of insertion we dont need to send the result to the user
+	// Send the response to the client
+	// w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	// w.Write(jsonResponse)
+}
+
+// PUT --update all the information of a student
+func (h *Handler) updateStudent(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	version, ok := h.ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var s models.Student
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	// Validate the student data
+	if err := validation.ValidateStudent(s); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	s, err := h.students.Update(r.Context(), id, s, version)
+	if err != nil {
+		writeStoreError(w, r, err, "Student")
+		return
+	}
+	w.Header().Set("ETag", etag(s.Version))
+
+	slog.InfoContext(r.Context(), "student updated", "student_id", id)
+
+	//w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	// json.NewEncoder(w).Encode(s)
+}
+
+// DELETE --delete a student from the database
+func (h *Handler) deleteStudent(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	version, ok := h.ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.students.Delete(r.Context(), id, version); err != nil {
+		writeStoreError(w, r, err, "Student")
+		return
+	}
+
+	slog.InfoContext(r.Context(), "student deleted", "student_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PATCH --update partial information of a student
+// application/merge-patch+json (or plain JSON): {"age": 26}, null clears a member
+// application/json-patch+json: [{"op": "test", "path": "/class", "value": 4}, {"op": "replace", "path": "/class", "value": 5}]
+func (h *Handler) patchStudent(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	version, ok := h.ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	p, ok := readPatch(w, r)
+	if !ok {
+		return
+	}
+
+	// the patch is applied to the locked row and the result validated like a PUT
+	s, err := h.students.Modify(r.Context(), id, version, func(s *models.Student) error {
+		next, err := applyPatch(p, *s)
+		if err != nil {
+			return err
+		}
+		if err := validation.ValidateStudent(next); err != nil {
+			return err
+		}
+		*s = next
+		return nil
+	})
+	if err != nil {
+		writePatchError(w, r, err, "Student")
+		return
+	}
+
+	slog.InfoContext(r.Context(), "student patched", "student_id", id)
+
+	w.Header().Set("ETag", etag(s.Version))
+	writeJSON(w, http.StatusOK, s)
+}
+
+// GET --get information of a single student
+func (h *Handler) getStudentOne(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	s, err := h.students.Get(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, r, err, "Student")
+		return
+	}
+
+	if notModified(w, r, etag(s.Version)) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(s)
+}
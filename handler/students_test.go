@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"school_api_postgres/models"
+	"school_api_postgres/problem"
+	"school_api_postgres/validation"
+)
+
+func TestStudentCRUD(t *testing.T) {
+	api := newTestAPI(t)
+
+	rec := api.do("POST", "/api/v1/students", `{"name":"Rahim","age":12,"class":6}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body)
+	}
+	var created models.Student
+	decode(t, rec, &created)
+	if created.ID == 0 || created.Name != "Rahim" {
+		t.Fatalf("created %+v", created)
+	}
+	path := "/api/v1/students/" + strconv.Itoa(created.ID)
+	if got := rec.Header().Get("Location"); got != path {
+		t.Errorf("Location = %q, want %q", got, path)
+	}
+	if got := rec.Header().Get("ETag"); got != `"1"` {
+		t.Errorf("ETag = %q, want %q", got, `"1"`)
+	}
+
+	rec = api.do("GET", path, "")
+	var got models.Student
+	decode(t, rec, &got)
+	if rec.Code != http.StatusOK || got != created {
+		t.Fatalf("get: %d %+v, want %+v", rec.Code, got, created)
+	}
+
+	rec = api.do("PUT", path, `{"name":"Rahim","age":13,"class":7}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", rec.Code, rec.Body)
+	}
+	rec = api.do("PATCH", path, `{"age":14}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch: %d %s", rec.Code, rec.Body)
+	}
+	if s, _ := api.stores.Students.Get(t.Context(), created.ID); s.Age != 14 || s.Class != 7 || s.Version != 3 {
+		t.Fatalf("stored after update and patch: %+v", s)
+	}
+
+	rec = api.do("DELETE", path, "")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: %d %s", rec.Code, rec.Body)
+	}
+	rec = api.do("GET", path, "")
+	if rec.Code != http.StatusNotFound || problemType(t, rec) != problem.TypeNotFound {
+		t.Fatalf("get after delete: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestStudentRequestErrors(t *testing.T) {
+	api := newTestAPI(t)
+	api.do("POST", "/api/v1/students", `{"name":"Rahim","age":12,"class":6}`)
+
+	tests := []struct {
+		name, method, path, body string
+		wantStatus               int
+		wantType                 string
+	}{
+		{"malformed json", "POST", "/api/v1/students", `{"name":`, http.StatusBadRequest, problem.TypeBadRequest},
+		{"invalid student", "POST", "/api/v1/students", `{"name":"","age":12,"class":6}`, http.StatusBadRequest, problem.TypeValidation},
+		{"missing student", "GET", "/api/v1/students/99", "", http.StatusNotFound, problem.TypeNotFound},
+		{"bad id", "GET", "/api/v1/students/one", "", http.StatusBadRequest, problem.TypeBadRequest},
+		{"update missing student", "PUT", "/api/v1/students/99", `{"name":"Karim","age":12,"class":6}`, http.StatusNotFound, problem.TypeNotFound},
+		{"delete missing student", "DELETE", "/api/v1/students/99", "", http.StatusNotFound, problem.TypeNotFound},
+		{"unknown route", "GET", "/api/v1/nothing", "", http.StatusNotFound, problem.TypeNotFound},
+		{"unknown filter", "GET", "/api/v1/students?colour=red", "", http.StatusBadRequest, problem.TypeBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := api.do(tt.method, tt.path, tt.body)
+			if rec.Code != tt.wantStatus || problemType(t, rec) != tt.wantType {
+				t.Errorf("%s %s = %d %s, want %d %s", tt.method, tt.path, rec.Code, rec.Body, tt.wantStatus, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestStudentsRequireAPIKey(t *testing.T) {
+	api := newTestAPI(t)
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{"no key", ""},
+		{"wrong key", "not-the-key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := api.do("GET", "/api/v1/students", "", APIKeyHeader, tt.key)
+			if rec.Code != http.StatusUnauthorized || problemType(t, rec) != problem.TypeUnauthorized {
+				t.Errorf("got %d %s, want 401", rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestStudentValidationErrors(t *testing.T) {
+	tests := []struct {
+		name, path, body string
+		wantDetail       string
+		want             []string
+	}{
+		{"one problem", "/api/v1/students", `{"name":"Rahim","age":12,"class":13}`,
+			"", []string{"class:out_of_range"}},
+		{"every problem at once", "/api/v1/students", `{"name":"","age":200,"class":11}`,
+			"3 problems found, see errors", []string{"name:required", "age:out_of_range", "class:out_of_range"}},
+		// the elements of a bulk request are checked together, each error names its element
+		{"bulk", "/api/v1/students/bulk", `[{"name":"Rahim","age":12,"class":6},{"name":"","age":12,"class":6},{"name":"Sadia","age":200,"class":6}]`,
+			"2 problems found, see errors", []string{"1 name:required", "2 age:out_of_range"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t)
+			rec := api.do("POST", tt.path, tt.body)
+			if rec.Code != http.StatusBadRequest || problemType(t, rec) != problem.TypeValidation {
+				t.Fatalf("got %d %s", rec.Code, rec.Body)
+			}
+			var p struct {
+				Detail string                  `json:"detail"`
+				Errors []validation.FieldError `json:"errors"`
+			}
+			decode(t, rec, &p)
+			var got []string
+			for _, fe := range p.Errors {
+				s := fe.Field + ":" + fe.Code
+				if fe.Index != nil {
+					s = fmt.Sprint(*fe.Index, " ", s)
+				}
+				got = append(got, s)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("errors %v, want %v", got, tt.want)
+			}
+			if tt.wantDetail != "" && p.Detail != tt.wantDetail {
+				t.Errorf("detail %q, want %q", p.Detail, tt.wantDetail)
+			}
+			if tt.wantDetail == "" && p.Detail != p.Errors[0].Message {
+				t.Errorf("detail %q of a single problem is not its message", p.Detail)
+			}
+			// nothing from a refused batch is created
+			if rec := api.do("GET", "/api/v1/students/1", ""); rec.Code != http.StatusNotFound {
+				t.Errorf("a refused request created student 1: %d", rec.Code)
+			}
+		})
+	}
+}
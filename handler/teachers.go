@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"school_api_postgres/models"
+	"school_api_postgres/store"
+	"school_api_postgres/validation"
+)
+
+// GET --get all teachers
+// supports ?subject=math&name=rah&name_prefix=ra&hired_after=2020-01-01&hired_before=2024-12-31&sort=-hire_date
+// with page/limit or cursor/limit exactly like the student list
+func (h *Handler) getTeachersAll(w http.ResponseWriter, r *http.Request) {
+	filter, sort, err := parseTeacherQuery(r.URL.Query())
+	if err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	teachers, ok := listPage(w, r, h.cursors, sort,
+		func(opts store.ListOptions) ([]models.Teacher, int, error) {
+			return h.teachers.List(r.Context(), filter, opts)
+		}, store.TeacherCursor)
+	if !ok {
+		return
+	}
+	slog.DebugContext(r.Context(), "teachers listed", "count", len(teachers))
+}
+
+// parseTeacherQuery ... whitelisted filters and sort for the teacher list
+func parseTeacherQuery(q url.Values) (store.TeacherFilter, []store.SortField, error) {
+	var filter store.TeacherFilter
+
+	if err := checkParams(q, "page", "limit", "cursor", "sort", "subject", "name", "name_prefix", "hired_after", "hired_before"); err != nil {
+		return filter, nil, err
+	}
+
+	filter.Subject = q.Get("subject")
+	filter.NameContains = q.Get("name")
+	filter.NamePrefix = q.Get("name_prefix")
+
+	for name, dst := range map[string]**models.Date{"hired_after": &filter.HiredAfter, "hired_before": &filter.HiredBefore} {
+		if raw := q.Get(name); raw != "" {
+			d, err := models.ParseDate(raw)
+			if err != nil {
+				return filter, nil, fmt.Errorf("query parameter %q: %w", name, err)
+			}
+			*dst = &d
+		}
+	}
+
+	sort, err := parseSort(q.Get("sort"), store.TeacherSortFields)
+	if err != nil {
+		return filter, nil, err
+	}
+	return filter, sort, nil
+}
+
+// POST --insert a teacher
+func (h *Handler) createTeacherSingle(w http.ResponseWriter, r *http.Request) {
+	var t models.Teacher
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	if err := validation.ValidateTeacher(t); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	t, err := h.teachers.Create(r.Context(), t)
+	if err != nil {
+		writeStoreError(w, r, err, "Teacher")
+		return
+	}
+	slog.InfoContext(r.Context(), "teacher created", "teacher_id", t.ID)
+
+	writeJSON(w, http.StatusCreated, t)
+}
+
+// POST --insert many teachers, a single object is accepted too
+func (h *Handler) createTeacherBulk(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		badRequest(w, r, "Failed to read request body")
+		return
+	}
+	defer r.Body.Close()
+
+	var teachers []models.Teacher
+	if err := json.Unmarshal(body, &teachers); err != nil {
+		var single models.Teacher
+		if err := json.Unmarshal(body, &single); err != nil {
+			badRequest(w, r, "Invalid request payload")
+			return
+		}
+		teachers = append(teachers, single)
+	}
+
+	if len(teachers) == 0 {
+		badRequest(w, r, "No teacher data provided")
+		return
+	}
+
+	var errs validation.Errors
+	for i, t := range teachers {
+		errs.At(i, validation.ValidateTeacher(t))
+	}
+	if err := errs.Err(); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	inserted, err := h.teachers.CreateBatch(r.Context(), teachers)
+	if err != nil {
+		writeStoreError(w, r, err, "Teacher")
+		return
+	}
+	slog.InfoContext(r.Context(), "teachers inserted", "count", len(inserted))
+
+	writeJSON(w, http.StatusCreated, inserted)
+}
+
+// PUT --replace all the information of a teacher
+func (h *Handler) updateTeacher(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var t models.Teacher
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	if err := validation.ValidateTeacher(t); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	if err := h.teachers.Update(r.Context(), id, t); err != nil {
+		writeStoreError(w, r, err, "Teacher")
+		return
+	}
+	slog.InfoContext(r.Context(), "teacher updated", "teacher_id", id)
+
+	t.ID = id
+	writeJSON(w, http.StatusOK, t)
+}
+
+// PATCH --update some fields of a teacher, merge patch or JSON Patch like the students
+func (h *Handler) patchTeacher(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	p, ok := readPatch(w, r)
+	if !ok {
+		return
+	}
+
+	t, err := h.teachers.Modify(r.Context(), id, func(t *models.Teacher) error {
+		next, err := applyPatch(p, *t)
+		if err != nil {
+			return err
+		}
+		if err := validation.ValidateTeacher(next); err != nil {
+			return err
+		}
+		*t = next
+		return nil
+	})
+	if err != nil {
+		writePatchError(w, r, err, "Teacher")
+		return
+	}
+	slog.InfoContext(r.Context(), "teacher patched", "teacher_id", id)
+
+	writeJSON(w, http.StatusOK, t)
+}
+
+// DELETE --delete a teacher
+func (h *Handler) deleteTeacher(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.teachers.Delete(r.Context(), id); err != nil {
+		writeStoreError(w, r, err, "Teacher")
+		return
+	}
+
+	slog.InfoContext(r.Context(), "teacher deleted", "teacher_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET --get a single teacher
+func (h *Handler) getTeacherOne(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	t, err := h.teachers.Get(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, r, err, "Teacher")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, t)
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"school_api_postgres/models"
+	"school_api_postgres/problem"
+)
+
+func TestTeacherCRUD(t *testing.T) {
+	api := newTestAPI(t)
+
+	rec := api.do("POST", "/api/v1/teachers", `{"name":"Nasrin","email":"nasrin@school.test","subjects":["math"],"hire_date":"2019-08-01"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body)
+	}
+	var created models.Teacher
+	decode(t, rec, &created)
+	path := fmt.Sprintf("/api/v1/teachers/%d", created.ID)
+
+	rec = api.do("PUT", path, `{"name":"Nasrin Akter","email":"nasrin@school.test","subjects":["math","physics"],"hire_date":"2019-08-01"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", rec.Code, rec.Body)
+	}
+	rec = api.do("PATCH", path, `{"subjects":["physics"]}`, "Content-Type", "application/merge-patch+json")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch: %d %s", rec.Code, rec.Body)
+	}
+
+	var got models.Teacher
+	rec = api.do("GET", path, "")
+	decode(t, rec, &got)
+	if got.Name != "Nasrin Akter" || fmt.Sprint(got.Subjects) != "[physics]" || got.HireDate.String() != "2019-08-01" {
+		t.Fatalf("get: %d %+v", rec.Code, got)
+	}
+
+	if rec := api.do("DELETE", path, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: %d %s", rec.Code, rec.Body)
+	}
+	if rec := api.do("GET", path, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("get after delete: %d", rec.Code)
+	}
+}
+
+func TestTeacherRefused(t *testing.T) {
+	const nasrin = `{"name":"Nasrin","email":"nasrin@school.test","subjects":["math"],"hire_date":"2019-08-01"}`
+	tests := []struct {
+		name, method, path, body string
+		want                     int
+		wantType                 string
+	}{
+		{"email taken", "POST", "/api/v1/teachers", `{"name":"N","email":"nasrin@school.test","subjects":[],"hire_date":"2020-01-01"}`,
+			http.StatusConflict, problem.TypeConflict},
+		{"bad email", "POST", "/api/v1/teachers", `{"name":"N","email":"nasrin","subjects":[],"hire_date":"2020-01-01"}`,
+			http.StatusBadRequest, problem.TypeValidation},
+		{"hired in the future", "POST", "/api/v1/teachers", `{"name":"N","email":"n@school.test","subjects":[],"hire_date":"2999-01-01"}`,
+			http.StatusBadRequest, problem.TypeValidation},
+		{"empty subject", "POST", "/api/v1/teachers", `{"name":"N","email":"n@school.test","subjects":[""],"hire_date":"2020-01-01"}`,
+			http.StatusBadRequest, problem.TypeValidation},
+		{"not a date", "POST", "/api/v1/teachers", `{"name":"N","email":"n@school.test","subjects":[],"hire_date":"01/01/2020"}`,
+			http.StatusBadRequest, problem.TypeBadRequest},
+		{"unknown teacher", "PUT", "/api/v1/teachers/99", nasrin, http.StatusNotFound, problem.TypeNotFound},
+		{"bad id", "GET", "/api/v1/teachers/abc", "", http.StatusBadRequest, problem.TypeBadRequest},
+		{"unknown filter", "GET", "/api/v1/teachers?subjct=math", "", http.StatusBadRequest, problem.TypeBadRequest},
+		{"bad hired_after", "GET", "/api/v1/teachers?hired_after=2020", "", http.StatusBadRequest, problem.TypeBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t)
+			api.do("POST", "/api/v1/teachers", nasrin)
+			rec := api.do(tt.method, tt.path, tt.body)
+			if rec.Code != tt.want || problemType(t, rec) != tt.wantType {
+				t.Errorf("got %d %s, want %d %s", rec.Code, rec.Body, tt.want, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestTeacherBulk(t *testing.T) {
+	api := newTestAPI(t)
+	rec := api.do("POST", "/api/v1/teachers/bulk", `[
+		{"name":"Nasrin","email":"nasrin@school.test","subjects":["math"],"hire_date":"2019-08-01"},
+		{"name":"Kamal","email":"kamal@school.test","subjects":["Bangla","history"],"hire_date":"2022-01-10"}
+	]`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("bulk: %d %s", rec.Code, rec.Body)
+	}
+
+	// a duplicate email inside the batch rejects all of it
+	rec = api.do("POST", "/api/v1/teachers/bulk", `[
+		{"name":"Rina","email":"rina@school.test","subjects":[],"hire_date":"2020-01-01"},
+		{"name":"Rina","email":"rina@school.test","subjects":[],"hire_date":"2020-01-01"}
+	]`)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("duplicate in batch: %d %s", rec.Code, rec.Body)
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"subject=bangla", "[Kamal]"},
+		{"hired_after=2020-01-01", "[Kamal]"},
+		{"hired_before=2020-01-01", "[Nasrin]"},
+		{"sort=-hire_date", "[Kamal Nasrin]"},
+	}
+	for _, tt := range tests {
+		rec := api.do("GET", "/api/v1/teachers?"+tt.query, "")
+		var env struct {
+			Data []models.Teacher `json:"data"`
+		}
+		decode(t, rec, &env)
+		var names []string
+		for _, teacher := range env.Data {
+			names = append(names, teacher.Name)
+		}
+		if fmt.Sprint(names) != tt.want {
+			t.Errorf("%s: got %v, want %s", tt.query, names, tt.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Package logging sets up the structured logger of the server. Everything goes through log/slog, the log package
+// included, as one JSON object (or one key=value line) per event on stderr. Attributes that carry personal data
+// or secrets are redacted by key, so a careless slog call cannot leak them
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Redacted ... what a redacted value is replaced with
+const Redacted = "[REDACTED]"
+
+// sensitive ... attribute keys whose values never reach the log, in any group
+var sensitive = map[string]bool{
+	"name": true, "email": true, "phone": true, "address": true, "date_of_birth": true, "username": true,
+	"password": true, "token": true, "access_token": true, "refresh_token": true, "api_key": true,
+	"secret": true, "authorization": true, "body": true,
+}
+
+// Setup reads LOG_LEVEL (debug, info, warn or error, default info) and LOG_FORMAT (json, the default, or text) and
+// makes the result the default logger of slog and of the log package
+func Setup() error {
+	logger, err := New(os.Stderr, os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT"))
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// New ... a logger writing to w, empty level and format mean info and json
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var l slog.Level
+	if level != "" {
+		if err := l.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("LOG_LEVEL must be debug, info, warn or error, got %q", level)
+		}
+	}
+	opts := &slog.HandlerOptions{Level: l, ReplaceAttr: redact}
+
+	switch strings.ToLower(format) {
+	case "", "json":
+		return slog.New(requestIDHandler{slog.NewJSONHandler(w, opts)}), nil
+	case "text":
+		return slog.New(requestIDHandler{slog.NewTextHandler(w, opts)}), nil
+	default:
+		return nil, fmt.Errorf("LOG_FORMAT must be json or text, got %q", format)
+	}
+}
+
+// requestIDHandler ... adds the request_id of the context to every record logged with one, so the lines a request
+// writes can be found by the id its response carried
+type requestIDHandler struct {
+	slog.Handler
+}
+
+func (h requestIDHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := middleware.GetReqID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h requestIDHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestIDHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h requestIDHandler) WithGroup(name string) slog.Handler {
+	return requestIDHandler{h.Handler.WithGroup(name)}
+}
+
+// redact ... a ReplaceAttr that blanks the values of sensitive keys
+func redact(groups []string, a slog.Attr) slog.Attr {
+	if sensitive[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, Redacted)
+	}
+	return a
+}
+
+// Fatal logs msg and args at error level and exits, log.Fatal would log at info level once slog is the default
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// Std ... a log.Logger writing into slog at level, for APIs like http.Server.ErrorLog that want one
+func Std(level slog.Level) *log.Logger {
+	return slog.NewLogLogger(slog.Default().Handler(), level)
+}
@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		level, format string
+		wantErr       bool
+	}{
+		{"", "", false},
+		{"debug", "json", false},
+		{"WARN", "TEXT", false},
+		{"error", "text", false},
+		{"verbose", "", true},
+		{"", "logfmt", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level+"/"+tt.format, func(t *testing.T) {
+			if _, err := New(&bytes.Buffer{}, tt.level, tt.format); (err != nil) != tt.wantErr {
+				t.Errorf("New = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "warn", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("dropped")
+	logger.Warn("kept")
+	if out := buf.String(); strings.Contains(out, "dropped") || !strings.Contains(out, "kept") {
+		t.Errorf("at warn level the log is %q", out)
+	}
+}
+
+// line logs msg with args through a JSON logger and returns the line as a map
+func line(t *testing.T, ctx context.Context, msg string, args ...any) map[string]any {
+	t.Helper()
+	var buf bytes.Buffer
+	logger, err := New(&buf, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.InfoContext(ctx, msg, args...)
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("decoding %q: %v", buf.String(), err)
+	}
+	return m
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		key  string
+		want any
+	}{
+		{"name", Redacted},
+		{"Password", Redacted},
+		{"refresh_token", Redacted},
+		{"Authorization", Redacted},
+		{"body", Redacted},
+		{"student_id", float64(7)},
+		{"status", float64(7)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := line(t, context.Background(), "m", tt.key, 7)[tt.key]; got != tt.want {
+				t.Errorf("%s logged as %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+
+	// a group does not hide a key from redaction
+	m := line(t, context.Background(), "m", slog.Group("user", "username", "rahim", "id", 7))
+	if user, _ := m["user"].(map[string]any); user["username"] != Redacted || user["id"] != float64(7) {
+		t.Errorf("grouped attributes logged as %v", m["user"])
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "req-1")
+	if got := line(t, ctx, "m")["request_id"]; got != "req-1" {
+		t.Errorf("request_id = %v, want req-1", got)
+	}
+	if m := line(t, context.Background(), "m"); m["request_id"] != nil {
+		t.Errorf("request_id %v without one in the context", m["request_id"])
+	}
+}
@@ -1,7 +1,56 @@
 package main
 
-import "school_api_postgres/handler"
+import (
+	"context"
+	"log"
+	"os"
+
+	"school_api_postgres/auth"
+	"school_api_postgres/database"
+	"school_api_postgres/handler"
+	"school_api_postgres/migrations"
+	"school_api_postgres/store"
+)
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		db, err := database.Open()
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+
+		if err := migrations.Command(context.Background(), db, os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		db, err := database.Open()
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+
+		if err := auth.KeysCommand(context.Background(), store.NewPostgresAPIKeyStore(db), os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "users" {
+		db, err := database.Open()
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+
+		if err := auth.UsersCommand(context.Background(), store.NewPostgresUserStore(db), os.Args[2:], os.Stdin, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	handler.Handle()
 }
@@ -0,0 +1,32 @@
+package metrics
+
+import "database/sql"
+
+// RegisterDBStats registers the connection pool statistics of db with Default, read from sql.DBStats at every scrape
+func RegisterDBStats(db *sql.DB) {
+	gauge := func(name, help string, read func(s sql.DBStats) int) {
+		NewGaugeFunc(name, help, func() float64 { return float64(read(db.Stats())) })
+	}
+	counter := func(name, help string, read func(s sql.DBStats) int64) {
+		NewCounterFunc(name, help, func() float64 { return float64(read(db.Stats())) })
+	}
+
+	gauge("school_api_db_max_open_connections", "Maximum number of open connections to the database.",
+		func(s sql.DBStats) int { return s.MaxOpenConnections })
+	gauge("school_api_db_open_connections", "Established connections, in use and idle.",
+		func(s sql.DBStats) int { return s.OpenConnections })
+	gauge("school_api_db_in_use_connections", "Connections currently in use.",
+		func(s sql.DBStats) int { return s.InUse })
+	gauge("school_api_db_idle_connections", "Idle connections.",
+		func(s sql.DBStats) int { return s.Idle })
+	counter("school_api_db_wait_count_total", "Connections waited for because the pool was exhausted.",
+		func(s sql.DBStats) int64 { return s.WaitCount })
+	NewCounterFunc("school_api_db_wait_duration_seconds_total", "Time spent waiting for a connection.",
+		func() float64 { return db.Stats().WaitDuration.Seconds() })
+	counter("school_api_db_max_idle_closed_total", "Connections closed because of SetMaxIdleConns.",
+		func(s sql.DBStats) int64 { return s.MaxIdleClosed })
+	counter("school_api_db_max_idle_time_closed_total", "Connections closed because of SetConnMaxIdleTime.",
+		func(s sql.DBStats) int64 { return s.MaxIdleTimeClosed })
+	counter("school_api_db_max_lifetime_closed_total", "Connections closed because of SetConnMaxLifetime.",
+		func(s sql.DBStats) int64 { return s.MaxLifetimeClosed })
+}
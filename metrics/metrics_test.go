@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// exposition ... what r writes
+func exposition(t *testing.T, r *Registry) string {
+	t.Helper()
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestCounter(t *testing.T) {
+	r := NewRegistry()
+	c := &Counter{desc: desc{"requests_total", "Requests by method.\nSecond line \\ backslash.", []string{"method", "path"}},
+		series: make(map[string]*counterSeries)}
+	r.register(c)
+
+	c.Inc("POST", "/")
+	c.Add(2.5, "GET", `/a"b`+"\n"+`c\d`)
+	c.Inc("POST", "/")
+	want := `# HELP requests_total Requests by method.\nSecond line \\ backslash.
+# TYPE requests_total counter
+requests_total{method="GET",path="/a\"b\nc\\d"} 2.5
+requests_total{method="POST",path="/"} 2
+`
+	if got := exposition(t, r); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCounterMisuse(t *testing.T) {
+	c := &Counter{desc: desc{"c_total", "", []string{"a"}}, series: make(map[string]*counterSeries)}
+	tests := []struct {
+		name string
+		f    func()
+	}{
+		{"negative", func() { c.Add(-1, "x") }},
+		{"too few labels", func() { c.Inc() }},
+		{"too many labels", func() { c.Inc("x", "y") }},
+		{"registered twice", func() {
+			r := NewRegistry()
+			r.register(c)
+			r.register(c)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("no panic")
+				}
+			}()
+			tt.f()
+		})
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	r := NewRegistry()
+	h := &Histogram{desc: desc{"latency_seconds", "Latency.", []string{"route"}}, buckets: []float64{0.1, 0.5, 1},
+		series: make(map[string]*histogramSeries)}
+	r.register(h)
+
+	// on a bound counts into that bucket, above the last only into +Inf
+	for _, v := range []float64{0.05, 0.1, 0.3, 2} {
+		h.Observe(v, "/b")
+	}
+	h.Observe(0.7, "/a")
+	want := `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a",le="0.1"} 0
+latency_seconds_bucket{route="/a",le="0.5"} 0
+latency_seconds_bucket{route="/a",le="1"} 1
+latency_seconds_bucket{route="/a",le="+Inf"} 1
+latency_seconds_sum{route="/a"} 0.7
+latency_seconds_count{route="/a"} 1
+latency_seconds_bucket{route="/b",le="0.1"} 2
+latency_seconds_bucket{route="/b",le="0.5"} 3
+latency_seconds_bucket{route="/b",le="1"} 3
+latency_seconds_bucket{route="/b",le="+Inf"} 4
+latency_seconds_sum{route="/b"} 2.45
+latency_seconds_count{route="/b"} 4
+`
+	if got := exposition(t, r); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	value := 3.0
+	r.register(&funcMetric{desc: desc{metricName: "open_connections", help: "Open."}, kind: "gauge", read: func() float64 { return value }})
+	r.register(&funcMetric{desc: desc{metricName: "b_total", help: "B."}, kind: "counter", read: func() float64 { return 7 }})
+	r.register(&Counter{desc: desc{"a_total", "A.", nil}, series: make(map[string]*counterSeries)})
+
+	// metrics come sorted by name, a counter nobody counted yet only has its header, a func is read at every scrape
+	value = 4
+	want := `# HELP a_total A.
+# TYPE a_total counter
+# HELP b_total B.
+# TYPE b_total counter
+b_total 7
+# HELP open_connections Open.
+# TYPE open_connections gauge
+open_connections 4
+`
+	if got := exposition(t, r); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("Content-Type %q", ct)
+	}
+	if rec.Body.String() != want {
+		t.Errorf("served\n%s", rec.Body)
+	}
+}
+
+func TestFormatFloat(t *testing.T) {
+	tests := []struct {
+		in   float64
+		want string
+	}{
+		{0, "0"},
+		{42, "42"},
+		{0.005, "0.005"},
+		{1e21, "1e+21"},
+		{math.Inf(1), "+Inf"},
+		{math.Inf(-1), "-Inf"},
+		{math.NaN(), "NaN"},
+	}
+	for _, tt := range tests {
+		if got := formatFloat(tt.in); got != tt.want {
+			t.Errorf("formatFloat(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
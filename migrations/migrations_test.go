@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	for i, m := range migrations {
+		// versions run 1, 2, 3 ... without gaps, every one with both directions
+		if m.Version != i+1 {
+			t.Errorf("migration %d_%s where %d was expected", m.Version, m.Name, i+1)
+		}
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			t.Errorf("migration %d_%s cannot be applied and rolled back", m.Version, m.Name)
+		}
+	}
+}
+
+func TestCommandRefused(t *testing.T) {
+	// none of these get as far as the database
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "usage"},
+		{[]string{"sideways"}, "unknown migrate command"},
+		{[]string{"down", "0"}, "positive number"},
+		{[]string{"down", "all"}, "positive number"},
+	}
+	for _, tt := range tests {
+		err := Command(context.Background(), nil, tt.args, &bytes.Buffer{})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Command(%q) = %v, want an error about %s", tt.args, err, tt.want)
+		}
+	}
+}
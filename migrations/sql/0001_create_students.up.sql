@@ -0,0 +1,8 @@
+-- IF NOT EXISTS because older deployments created this table by hand from the README
+CREATE TABLE IF NOT EXISTS students (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    age INT NOT NULL,
+    -- the upper bound of class belongs to the validation rules, VALIDATION_RULES_FILE can raise it above 10
+    class INTEGER NOT NULL CHECK (class >= 1)
+);
@@ -0,0 +1,10 @@
+CREATE TABLE teachers (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    email VARCHAR(254) NOT NULL,
+    subjects TEXT[] NOT NULL DEFAULT '{}',
+    hire_date DATE NOT NULL
+);
+
+-- one account per address regardless of how it was capitalised
+CREATE UNIQUE INDEX teachers_email_key ON teachers (lower(email));
@@ -0,0 +1,18 @@
+CREATE TABLE courses (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(20) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    -- a course outlives the teacher who taught it
+    teacher_id INTEGER REFERENCES teachers (id) ON DELETE SET NULL
+);
+
+CREATE TABLE enrollments (
+    student_id INTEGER NOT NULL REFERENCES students (id) ON DELETE CASCADE,
+    course_id INTEGER NOT NULL REFERENCES courses (id) ON DELETE CASCADE,
+    enrolled_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (student_id, course_id)
+);
+
+-- the primary key covers "courses of a student", this one covers "roster of a course"
+CREATE INDEX enrollments_course_id_idx ON enrollments (course_id);
@@ -0,0 +1,11 @@
+CREATE TABLE attendance (
+    student_id INTEGER NOT NULL REFERENCES students (id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    status VARCHAR(10) NOT NULL CHECK (status IN ('present', 'absent', 'late', 'excused')),
+    note TEXT NOT NULL DEFAULT '',
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (student_id, date)
+);
+
+-- class summaries scan one date range for many students
+CREATE INDEX attendance_date_idx ON attendance (date);
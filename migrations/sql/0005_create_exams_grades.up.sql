@@ -0,0 +1,20 @@
+CREATE TABLE exams (
+    id SERIAL PRIMARY KEY,
+    course_id INTEGER NOT NULL REFERENCES courses (id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    term VARCHAR(20) NOT NULL,
+    max_score NUMERIC(6, 2) NOT NULL CHECK (max_score > 0),
+    date DATE NOT NULL,
+    UNIQUE (course_id, term, name)
+);
+
+CREATE TABLE grades (
+    exam_id INTEGER NOT NULL REFERENCES exams (id) ON DELETE CASCADE,
+    student_id INTEGER NOT NULL REFERENCES students (id) ON DELETE CASCADE,
+    score NUMERIC(6, 2) NOT NULL CHECK (score >= 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (exam_id, student_id)
+);
+
+-- report cards read every grade of a student
+CREATE INDEX grades_student_id_idx ON grades (student_id);
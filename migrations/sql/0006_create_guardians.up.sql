@@ -0,0 +1,17 @@
+CREATE TABLE guardians (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    relationship VARCHAR(50) NOT NULL,
+    phone VARCHAR(30) NOT NULL DEFAULT '',
+    email VARCHAR(254) NOT NULL DEFAULT '',
+    address TEXT NOT NULL DEFAULT ''
+);
+
+-- siblings share their guardians, so the link is many-to-many
+CREATE TABLE student_guardians (
+    student_id INTEGER NOT NULL REFERENCES students (id) ON DELETE CASCADE,
+    guardian_id INTEGER NOT NULL REFERENCES guardians (id) ON DELETE CASCADE,
+    PRIMARY KEY (student_id, guardian_id)
+);
+
+CREATE INDEX student_guardians_guardian_id_idx ON student_guardians (guardian_id);
@@ -0,0 +1,2 @@
+-- bumped on every update, the ETag of a student is its version so clients can send it back in If-Match
+ALTER TABLE students ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
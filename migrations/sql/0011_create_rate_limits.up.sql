@@ -0,0 +1,6 @@
+-- one row per rate limited key, tat is the theoretical arrival time of GCRA
+-- unlogged: the table is rewritten on nearly every request and losing it in a crash only resets the limits
+CREATE UNLOGGED TABLE rate_limits (
+    key TEXT PRIMARY KEY,
+    tat TIMESTAMPTZ NOT NULL
+);
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // Student ... that holds data and key = `json:"id"` and so on if not provided then it would be ID
 /*
 In Go, even if two structs have identical fields
@@ -11,7 +13,180 @@ This is because Go treats types as unique based on their package namespace.
 // Student ... that holds data and key = `json:"id"` and so on if not provided then it would be ID
 type Student struct {
 	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Age   int    `json:"age"`
-	Class int    `json:"class"`
+	Name  string `json:"name" validate:"required,max=100"`
+	Age   int    `json:"age" validate:"required,min=1,max=120"`
+	Class int    `json:"class" validate:"required,min=1,max=10"`
+	// Version ... bumped by every update, the ETag of the student, ignored in request bodies
+	Version int `json:"version"`
+}
+
+// Teacher ... a member of staff, Subjects are the subject specialities they can teach
+type Teacher struct {
+	ID       int      `json:"id"`
+	Name     string   `json:"name" validate:"required,max=100"`
+	Email    string   `json:"email" validate:"required,email"`
+	Subjects []string `json:"subjects" validate:"dive,required"`
+	HireDate Date     `json:"hire_date" validate:"required,past"`
+}
+
+// Course ... a subject taught to a group of students, TeacherID is nil when nobody is assigned
+type Course struct {
+	ID          int    `json:"id"`
+	Code        string `json:"code" validate:"required,max=20,regex=^\\S+$"`
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description"`
+	TeacherID   *int   `json:"teacher_id" validate:"min=1"`
+}
+
+// Guardian ... a parent or other contact person, one guardian can be linked to several students (siblings)
+type Guardian struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name" validate:"required,max=100"`
+	Relationship string `json:"relationship" validate:"required,max=50"`
+	Phone        string `json:"phone" validate:"phone"`
+	Email        string `json:"email" validate:"email"`
+	Address      string `json:"address" validate:"max=500"`
+}
+
+// Enrollment ... links one student to one course
+type Enrollment struct {
+	StudentID  int       `json:"student_id"`
+	CourseID   int       `json:"course_id"`
+	EnrolledAt time.Time `json:"enrolled_at"`
+}
+
+// Attendance statuses a teacher can record for a student on a day
+const (
+	AttendancePresent = "present"
+	AttendanceAbsent  = "absent"
+	AttendanceLate    = "late"
+	AttendanceExcused = "excused"
+)
+
+// AttendanceRecord ... one student on one day
+type AttendanceRecord struct {
+	StudentID  int       `json:"student_id"`
+	Date       Date      `json:"date" validate:"past"`
+	Status     string    `json:"status" validate:"required,enum=present|absent|late|excused"`
+	Note       string    `json:"note,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// AttendanceSummary ... counts per status over a date range
+// Percentage is (present + late) / (days - excused) * 100, nil when there is nothing to count
+type AttendanceSummary struct {
+	StudentID  int      `json:"student_id,omitempty"`
+	Days       int      `json:"days"`
+	Present    int      `json:"present"`
+	Absent     int      `json:"absent"`
+	Late       int      `json:"late"`
+	Excused    int      `json:"excused"`
+	Percentage *float64 `json:"percentage"`
+}
+
+// ClassAttendanceSummary ... the whole class plus every student in it
+type ClassAttendanceSummary struct {
+	Class    int                 `json:"class"`
+	From     Date                `json:"from"`
+	To       Date                `json:"to"`
+	Overall  AttendanceSummary   `json:"overall"`
+	Students []AttendanceSummary `json:"students"`
+}
+
+// Exam ... one assessment of a course in a term, scores are out of MaxScore
+type Exam struct {
+	ID       int     `json:"id"`
+	CourseID int     `json:"course_id" validate:"required,min=1"`
+	Name     string  `json:"name" validate:"required,max=100"`
+	Term     string  `json:"term" validate:"required,max=20"`
+	MaxScore float64 `json:"max_score" validate:"required,min=0,max=1000"`
+	Date     Date    `json:"date" validate:"required"`
+}
+
+// Grade ... the score of one student in one exam
+type Grade struct {
+	ExamID    int       `json:"exam_id"`
+	StudentID int       `json:"student_id"`
+	Score     float64   `json:"score"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ExamScore ... a grade joined with its exam and course, the raw material of report cards
+type ExamScore struct {
+	StudentID  int
+	CourseID   int
+	CourseCode string
+	CourseName string
+	Score      float64
+	MaxScore   float64
+}
+
+// Result ... a percentage with the letter and grade points it earns on the grading scale
+// Rank is 1 for the best in the student's class, students with the same percentage share a rank
+type Result struct {
+	Percentage *float64 `json:"percentage"`
+	Letter     string   `json:"letter,omitempty"`
+	Points     *float64 `json:"points"`
+	Rank       int      `json:"rank,omitempty"`
+	OutOf      int      `json:"out_of,omitempty"`
+}
+
+// CourseResult ... a student's exams of one course added up
+type CourseResult struct {
+	CourseID int     `json:"course_id"`
+	Code     string  `json:"code"`
+	Name     string  `json:"name"`
+	Exams    int     `json:"exams"`
+	Score    float64 `json:"score"`
+	MaxScore float64 `json:"max_score"`
+	Result
+}
+
+// ReportCard ... per course and overall results of a student, for one term or all of them when Term is empty
+type ReportCard struct {
+	StudentID int            `json:"student_id"`
+	Name      string         `json:"name"`
+	Class     int            `json:"class"`
+	Term      string         `json:"term,omitempty"`
+	Courses   []CourseResult `json:"courses"`
+	Overall   Result         `json:"overall"`
+}
+
+// APIKey ... a key a client authenticates with, only the hash of the secret is kept
+// ExpiresAt is nil for keys that do not expire, LastUsedAt is nil until the key is first used
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	SecretHash string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	Revoked    bool       `json:"revoked"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	CreatedBy  string     `json:"created_by"`
+}
+
+// User ... a staff account of the portal, LockedUntil is set while too many failed logins lock it
+type User struct {
+	ID           int        `json:"id"`
+	Username     string     `json:"username"`
+	Name         string     `json:"name"`
+	PasswordHash string     `json:"-"`
+	Role         string     `json:"role"`
+	FailedLogins int        `json:"-"`
+	LockedUntil  *time.Time `json:"locked_until"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// RefreshToken ... one token of a login's family, only its hash is kept, UsedAt is set once it was exchanged
+type RefreshToken struct {
+	ID        int
+	UserID    int
+	Family    string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	Revoked   bool
+	CreatedAt time.Time
 }
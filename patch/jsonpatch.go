@@ -0,0 +1,313 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// operation ... one entry of a JSON Patch, Value stays raw so a missing value can be told apart from null
+type operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+
+	path, from []string
+}
+
+// jsonPatch ... RFC 6902, the operations run in order and the first failure aborts the whole patch
+type jsonPatch []operation
+
+func parseOperations(body []byte) (jsonPatch, error) {
+	var ops jsonPatch
+	if err := json.Unmarshal(body, &ops); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("%w: a JSON Patch is an array of operations with string op, path and from", ErrInvalid)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	for i := range ops {
+		o := &ops[i]
+		fail := func(format string, args ...interface{}) error {
+			return fmt.Errorf("%w: operation %d: %s", ErrInvalid, i, fmt.Sprintf(format, args...))
+		}
+
+		switch o.Op {
+		case "add", "remove", "replace", "move", "copy", "test":
+		case "":
+			return nil, fail("op is missing")
+		default:
+			return nil, fail("unknown op %q", o.Op)
+		}
+
+		var err error
+		if o.path, err = parsePointer(o.Path); err != nil {
+			return nil, fail("path: %v", err)
+		}
+		if o.Op == "move" || o.Op == "copy" {
+			if o.from, err = parsePointer(o.From); err != nil {
+				return nil, fail("from: %v", err)
+			}
+		}
+		if o.Op == "move" && len(o.from) < len(o.path) && isPrefix(o.from, o.path) {
+			return nil, fail("cannot move %s into one of its own children", o.From)
+		}
+		if (o.Op == "add" || o.Op == "replace" || o.Op == "test") && o.Value == nil {
+			return nil, fail("%s needs a value", o.Op)
+		}
+	}
+	return ops, nil
+}
+
+// Apply ...
+func (p jsonPatch) Apply(doc []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+
+	for i, o := range p {
+		var err error
+		if target, err = o.apply(target); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, o.Op, o.Path, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func (o operation) apply(doc interface{}) (interface{}, error) {
+	switch o.Op {
+	case "add":
+		return add(doc, o.path, decode(o.Value))
+	case "remove":
+		return remove(doc, o.path)
+	case "replace":
+		if _, err := get(doc, o.path); err != nil {
+			return nil, err
+		}
+		if len(o.path) == 0 {
+			return decode(o.Value), nil
+		}
+		return update(doc, o.path, func(parent interface{}, key string) (interface{}, error) {
+			switch c := parent.(type) {
+			case map[string]interface{}:
+				c[key] = decode(o.Value)
+			case []interface{}:
+				i, _ := index(key, len(c))
+				c[i] = decode(o.Value)
+			}
+			return parent, nil
+		})
+	case "move":
+		value, err := get(doc, o.from)
+		if err != nil {
+			return nil, err
+		}
+		if doc, err = remove(doc, o.from); err != nil {
+			return nil, err
+		}
+		return add(doc, o.path, value)
+	case "copy":
+		value, err := get(doc, o.from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, o.path, clone(value))
+	case "test":
+		value, err := get(doc, o.path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(value, decode(o.Value)) {
+			return nil, fmt.Errorf("%w, the value is %s", ErrTestFailed, encode(value))
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("%w: unknown op %q", ErrInvalid, o.Op)
+}
+
+// add ... sets a member or inserts into an array, "-" appends
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(doc, path, func(parent interface{}, key string) (interface{}, error) {
+		switch c := parent.(type) {
+		case map[string]interface{}:
+			c[key] = value
+			return c, nil
+		case []interface{}:
+			if key == "-" {
+				return append(c, value), nil
+			}
+			i, err := index(key, len(c)+1)
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		}
+		return nil, fmt.Errorf("%w: cannot add %s to a %s", ErrConflict, key, kind(parent))
+	})
+}
+
+// remove ... the member or array element must exist
+func remove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: cannot remove the whole document", ErrConflict)
+	}
+	return update(doc, path, func(parent interface{}, key string) (interface{}, error) {
+		switch c := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := c[key]; !ok {
+				return nil, fmt.Errorf("%w: %s does not exist", ErrConflict, key)
+			}
+			delete(c, key)
+			return c, nil
+		case []interface{}:
+			i, err := index(key, len(c))
+			if err != nil {
+				return nil, err
+			}
+			return append(c[:i], c[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("%w: cannot remove %s from a %s", ErrConflict, key, kind(parent))
+	})
+}
+
+// update walks down to the parent of the last token and lets fn change it, arrays may grow or shrink
+// so every level stores whatever fn returns
+func update(node interface{}, path []string, fn func(parent interface{}, key string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(node, path[0])
+	}
+	child, err := child(node, path[0])
+	if err != nil {
+		return nil, err
+	}
+	changed, err := update(child, path[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+	switch c := node.(type) {
+	case map[string]interface{}:
+		c[path[0]] = changed
+	case []interface{}:
+		i, _ := index(path[0], len(c))
+		c[i] = changed
+	}
+	return node, nil
+}
+
+// get ... the value a pointer refers to
+func get(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		var err error
+		if doc, err = child(doc, token); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+func child(node interface{}, token string) (interface{}, error) {
+	switch c := node.(type) {
+	case map[string]interface{}:
+		value, ok := c[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s does not exist", ErrConflict, token)
+		}
+		return value, nil
+	case []interface{}:
+		i, err := index(token, len(c))
+		if err != nil {
+			return nil, err
+		}
+		return c[i], nil
+	}
+	return nil, fmt.Errorf("%w: %s cannot be looked up in a %s", ErrConflict, token, kind(node))
+}
+
+// index ... an array index below limit, without leading zeros as RFC 6901 requires
+func index(token string, limit int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: %q is not an array index", ErrConflict, token)
+	}
+	if i >= limit {
+		return 0, fmt.Errorf("%w: index %d is out of range", ErrConflict, i)
+	}
+	return i, nil
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer, "" is the whole document
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("%q must be empty or start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		// ~ only ever starts ~0 or ~1
+		if strings.Count(t, "~") != strings.Count(t, "~0")+strings.Count(t, "~1") {
+			return nil, fmt.Errorf("%q has a ~ that is not ~0 or ~1", pointer)
+		}
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// decode ... a fresh copy of a raw value for every use, json numbers become float64 so 1 and 1.0 test equal
+func decode(raw json.RawMessage) interface{} {
+	var v interface{}
+	json.Unmarshal(raw, &v)
+	return v
+}
+
+func encode(v interface{}) string {
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(v)
+	return strings.TrimSpace(buf.String())
+}
+
+func clone(v interface{}) interface{} {
+	return decode(json.RawMessage(encode(v)))
+}
+
+func kind(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
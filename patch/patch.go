@@ -0,0 +1,97 @@
+// Package patch applies the two standard patch formats to JSON documents:
+// JSON Merge Patch (RFC 7396), where the body looks like the resource and null
+// removes a member, and JSON Patch (RFC 6902), a list of operations that can
+// also test the current value before changing it. The handlers marshal the
+// current row, apply the patch and decode the result back, so a patch can set
+// any field to any value, including its zero value.
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+)
+
+// Media types of the supported formats, plain application/json is read as a merge patch
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// Accepted ... value of the Accept-Patch header
+const Accepted = MergePatchType + ", " + JSONPatchType
+
+var (
+	// ErrUnsupportedType ... the Content-Type is not a patch format we know
+	ErrUnsupportedType = errors.New("unsupported patch media type")
+	// ErrInvalid ... the patch document itself is malformed
+	ErrInvalid = errors.New("invalid patch document")
+	// ErrConflict ... the patch is fine but does not fit the resource, e.g. it removes a member that is not there
+	ErrConflict = errors.New("patch cannot be applied")
+	// ErrTestFailed ... a JSON Patch test operation found a different value, it wraps ErrConflict
+	ErrTestFailed = fmt.Errorf("%w: test failed", ErrConflict)
+)
+
+// Patch ... a parsed patch document, ready to be applied to the current state of a resource
+type Patch interface {
+	Apply(doc []byte) ([]byte, error)
+}
+
+// Parse reads body as the patch format named by contentType
+// malformed documents are rejected here, before any row is locked
+func Parse(contentType string, body []byte) (Patch, error) {
+	mediaType := "application/json"
+	if contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+		}
+	}
+
+	switch mediaType {
+	case MergePatchType, "application/json":
+		var doc interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		return mergePatch{doc: doc}, nil
+	case JSONPatchType:
+		return parseOperations(body)
+	}
+	return nil, fmt.Errorf("%w %q, use %s", ErrUnsupportedType, mediaType, Accepted)
+}
+
+// mergePatch ... RFC 7396
+type mergePatch struct {
+	doc interface{}
+}
+
+// Apply ...
+func (p mergePatch) Apply(doc []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	return json.Marshal(merge(target, p.doc))
+}
+
+// merge ... the MergePatch function of RFC 7396 section 2
+func merge(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+		} else {
+			t[key] = merge(t[key], value)
+		}
+	}
+	return t
+}
@@ -0,0 +1,106 @@
+// Package problem implements RFC 7807 problem details, the one error format
+// every endpoint answers with. A Problem only ever carries text written for
+// clients; driver errors and other internals are logged by the caller and
+// replaced with a generic detail before they get here.
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// ContentType ... the media type of a problem response
+const ContentType = "application/problem+json"
+
+// Problem types, clients can switch on these instead of parsing titles or details
+const (
+	TypeBadRequest       = "urn:school-api:problem:bad-request"
+	TypeValidation       = "urn:school-api:problem:validation"
+	TypeNotFound         = "urn:school-api:problem:not-found"
+	TypeConflict         = "urn:school-api:problem:conflict"
+	TypeInUse            = "urn:school-api:problem:in-use"
+	TypeInvalidReference = "urn:school-api:problem:invalid-reference"
+	TypeConstraint       = "urn:school-api:problem:constraint-violation"
+	TypeUnsupportedMedia = "urn:school-api:problem:unsupported-media-type"
+	TypePatchConflict    = "urn:school-api:problem:patch-conflict"
+	// TypePreconditionFailed ... the If-Match version is stale, TypePreconditionRequired ... If-Match is missing
+	TypePreconditionFailed   = "urn:school-api:problem:precondition-failed"
+	TypePreconditionRequired = "urn:school-api:problem:precondition-required"
+	TypeUnauthorized         = "urn:school-api:problem:unauthorized"
+	TypeForbidden            = "urn:school-api:problem:forbidden"
+	TypeKeyRevoked           = "urn:school-api:problem:api-key-revoked"
+	// TypeIdempotencyKeyReused ... the key came with another body, TypeIdempotencyInProgress ... its first request is running
+	TypeIdempotencyKeyReused  = "urn:school-api:problem:idempotency-key-reused"
+	TypeIdempotencyInProgress = "urn:school-api:problem:idempotency-in-progress"
+	TypeRateLimited           = "urn:school-api:problem:rate-limited"
+	TypeInternal              = "urn:school-api:problem:internal"
+	// TypeBlank ... no more to say than the status code, the title is the status text
+	TypeBlank = "about:blank"
+)
+
+var titles = map[string]string{
+	TypeBadRequest:            "Malformed request",
+	TypeValidation:            "Validation failed",
+	TypeNotFound:              "Resource not found",
+	TypeConflict:              "Resource already exists",
+	TypeInUse:                 "Resource still in use",
+	TypeInvalidReference:      "Reference to a missing resource",
+	TypeConstraint:            "Constraint violated",
+	TypeUnsupportedMedia:      "Unsupported media type",
+	TypePatchConflict:         "Patch cannot be applied",
+	TypePreconditionFailed:    "Precondition failed",
+	TypePreconditionRequired:  "Precondition required",
+	TypeUnauthorized:          "Not authenticated",
+	TypeForbidden:             "Not allowed",
+	TypeKeyRevoked:            "API key revoked",
+	TypeIdempotencyKeyReused:  "Idempotency key reused",
+	TypeIdempotencyInProgress: "Request still in progress",
+	TypeRateLimited:           "Too many requests",
+	TypeInternal:              "Internal server error",
+}
+
+// Problem ... the problem details object, Instance and RequestID are filled in by Write
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	// Errors ... extension member with the individual problems, the field errors of a failed validation
+	Errors interface{} `json:"errors,omitempty"`
+}
+
+// New ... a problem of the given type, the title comes from the type
+func New(status int, typ, detail string) *Problem {
+	title, ok := titles[typ]
+	if !ok {
+		title = http.StatusText(status)
+	}
+	return &Problem{Type: typ, Title: title, Status: status, Detail: detail}
+}
+
+// Error ...
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return p.Title
+	}
+	return p.Title + ": " + p.Detail
+}
+
+// Write sends p for request r, the instance is the request path and the request id is the one
+// middleware.RequestID put into the context
+func Write(w http.ResponseWriter, r *http.Request, p *Problem) {
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+	if p.RequestID == "" {
+		p.RequestID = middleware.GetReqID(r.Context())
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
@@ -0,0 +1,61 @@
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		status    int
+		typ       string
+		wantTitle string
+	}{
+		{http.StatusNotFound, TypeNotFound, titles[TypeNotFound]},
+		{http.StatusTooManyRequests, TypeRateLimited, "Too many requests"},
+		// a type without a title of its own falls back to the status text
+		{http.StatusTeapot, "urn:example:teapot", "I'm a teapot"},
+	}
+	for _, tt := range tests {
+		p := New(tt.status, tt.typ, "detail")
+		if p.Title != tt.wantTitle || p.Status != tt.status || p.Type != tt.typ {
+			t.Errorf("New(%d, %q) = %+v, want title %q", tt.status, tt.typ, p, tt.wantTitle)
+		}
+	}
+	if got := New(http.StatusNotFound, TypeNotFound, "").Error(); got != titles[TypeNotFound] {
+		t.Errorf("Error without detail = %q", got)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/students/9?x=1", nil)
+	r = r.WithContext(context.WithValue(r.Context(), middleware.RequestIDKey, "req-1"))
+	rec := httptest.NewRecorder()
+	Write(rec, r, New(http.StatusNotFound, TypeNotFound, "Student not found"))
+
+	if rec.Code != http.StatusNotFound || rec.Header().Get("Content-Type") != ContentType ||
+		rec.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Fatalf("got %d %v", rec.Code, rec.Header())
+	}
+	var got map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"type": TypeNotFound, "title": titles[TypeNotFound], "status": float64(404), "detail": "Student not found",
+		"instance": "/api/v1/students/9", "request_id": "req-1",
+	}
+	if len(got) != len(want) {
+		t.Errorf("members %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+}
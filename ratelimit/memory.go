@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ Limiter = (*Memory)(nil)
+
+// Memory ... Limiter kept in a map, every replica counts on its own
+type Memory struct {
+	mu   sync.Mutex
+	tats map[string]time.Time
+}
+
+// NewMemory ...
+func NewMemory() *Memory {
+	return &Memory{tats: make(map[string]time.Time)}
+}
+
+// Allow ... the check and the update happen under one lock, so concurrent requests cannot both take the last token
+func (m *Memory) Allow(ctx context.Context, key string, p Policy) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result, tat := gcra(time.Now(), m.tats[key], p)
+	m.tats[key] = tat
+	return result, nil
+}
+
+// Purge ...
+func (m *Memory) Purge(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var n int64
+	for key, tat := range m.tats {
+		if tat.Before(now) {
+			delete(m.tats, key)
+			n++
+		}
+	}
+	return n, nil
+}
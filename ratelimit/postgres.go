@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var _ Limiter = (*Postgres)(nil)
+
+// Postgres ... Limiter backed by the rate_limits table, every replica counts against the same TATs. The clock of the
+// database is used, not the one of the replica, so replicas whose clocks are a little apart still agree
+type Postgres struct {
+	db *sql.DB
+}
+
+// NewPostgres ...
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+// Allow ... the check and the update are one UPSERT, the row lock makes concurrent requests for a key take turns.
+// A refused request leaves the row alone and reads it back for the headers
+func (l *Postgres) Allow(ctx context.Context, key string, p Policy) (Result, error) {
+	interval := p.interval().Microseconds()
+	tolerance := int64(p.Burst) * interval
+
+	var tat, now time.Time
+	err := l.db.QueryRowContext(ctx, `
+		INSERT INTO rate_limits (key, tat)
+		VALUES ($1, now() + $2::float8 * interval '1 microsecond')
+		ON CONFLICT (key) DO UPDATE
+			SET tat = greatest(rate_limits.tat, now()) + $2::float8 * interval '1 microsecond'
+			WHERE greatest(rate_limits.tat, now()) + ($2::float8 - $3::float8) * interval '1 microsecond' <= now()
+		RETURNING tat, now()`, key, interval, tolerance).Scan(&tat, &now)
+	if err == nil {
+		return allowed(now, tat, p), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return Result{}, err
+	}
+
+	err = l.db.QueryRowContext(ctx, "SELECT tat, now() FROM rate_limits WHERE key=$1", key).Scan(&tat, &now)
+	if errors.Is(err, sql.ErrNoRows) {
+		// purged in between, which only happens to a full bucket, so this time it goes through
+		return l.Allow(ctx, key, p)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	return refused(now, tat, p), nil
+}
+
+// Purge ...
+func (l *Postgres) Purge(ctx context.Context) (int64, error) {
+	result, err := l.db.ExecContext(ctx, "DELETE FROM rate_limits WHERE tat < now()")
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,232 @@
+// Package ratelimit decides whether a request may go ahead. Limits are kept
+// with GCRA, the generic cell rate algorithm: it behaves exactly like a token
+// bucket but only stores one timestamp per key, the theoretical arrival time
+// (TAT) of the next request, which is what makes it cheap to keep in a table
+// shared by every replica as well as in memory.
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limiter ... a place the TATs of the keys are kept
+type Limiter interface {
+	// Allow counts one request for key under p
+	Allow(ctx context.Context, key string, p Policy) (Result, error)
+	// Purge forgets keys whose bucket is full again, they behave as if they were never seen
+	Purge(ctx context.Context) (int64, error)
+}
+
+// Policy ... Limit requests per Period on average, with bursts of up to Burst requests
+type Policy struct {
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// interval ... the time one request uses up, the emission interval of GCRA
+func (p Policy) interval() time.Duration {
+	return p.Period / time.Duration(p.Limit)
+}
+
+// String ... in the form ParsePolicy reads
+func (p Policy) String() string {
+	unit := map[time.Duration]string{time.Second: "s", time.Minute: "m", time.Hour: "h"}[p.Period]
+	return fmt.Sprintf("%d/%s burst %d", p.Limit, unit, p.Burst)
+}
+
+// Result ... the outcome of Allow, what the RateLimit headers are made of
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter ... until the bucket is full again
+	ResetAfter time.Duration
+	// RetryAfter ... until the next request is allowed, 0 when this one was
+	RetryAfter time.Duration
+}
+
+// gcra works out a request at now for a key whose TAT is tat, the zero time for a key never seen.
+// It returns the result and the TAT to store, which is tat itself when the request is refused
+func gcra(now, tat time.Time, p Policy) (Result, time.Time) {
+	if tat.Before(now) {
+		tat = now
+	}
+	next := tat.Add(p.interval())
+	if now.Before(allowAt(next, p)) {
+		return refused(now, tat, p), tat
+	}
+	return allowed(now, next, p), next
+}
+
+// allowAt ... the earliest a request that moves the TAT to next may come
+func allowAt(next time.Time, p Policy) time.Time {
+	return next.Add(-time.Duration(p.Burst) * p.interval())
+}
+
+// allowed ... the result of a request that moved the TAT to next
+func allowed(now, next time.Time, p Policy) Result {
+	return Result{
+		Allowed:    true,
+		Limit:      p.Burst,
+		Remaining:  int(now.Sub(allowAt(next, p)) / p.interval()),
+		ResetAfter: next.Sub(now),
+	}
+}
+
+// refused ... the result of a request that left the TAT at tat
+func refused(now, tat time.Time, p Policy) Result {
+	return Result{
+		Limit:      p.Burst,
+		ResetAfter: tat.Sub(now),
+		RetryAfter: allowAt(tat.Add(p.interval()), p).Sub(now),
+	}
+}
+
+// Config ... the policies of the route groups, Default applies to groups without their own. Principals overrides
+// them for one caller, keyed by the subject of the principal like "apikey:3"
+type Config struct {
+	Default    Policy
+	Groups     map[string]Policy
+	Principals map[string]Policy
+}
+
+// Policy ... the policy for a request of subject to group
+func (c Config) Policy(group, subject string) Policy {
+	if p, ok := c.Principals[subject]; ok {
+		return p
+	}
+	if p, ok := c.Groups[group]; ok {
+		return p
+	}
+	return c.Default
+}
+
+// DefaultConfig ... what applies without RATE_LIMIT_FILE, the list endpoints keep their old 3/s with bursts of 5.
+// clients is the limit per IP in front of authentication, roomy because whole schools share one NAT address
+func DefaultConfig() Config {
+	return Config{
+		Default: Policy{Limit: 10, Period: time.Second, Burst: 20},
+		Groups: map[string]Policy{
+			"clients": {Limit: 50, Period: time.Second, Burst: 100},
+			"lists":   {Limit: 3, Period: time.Second, Burst: 5},
+			"login":   {Limit: 10, Period: time.Minute, Burst: 5},
+		},
+		Principals: map[string]Policy{},
+	}
+}
+
+// FromEnv reads the JSON file RATE_LIMIT_FILE points at on top of DefaultConfig:
+//
+//	{"default": "10/s burst 20", "groups": {"lists": "5/s"}, "principals": {"apikey:3": "100/s burst 200"}}
+func FromEnv() (Config, error) {
+	cfg := DefaultConfig()
+	path := os.Getenv("RATE_LIMIT_FILE")
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read rate limits: %w", err)
+	}
+
+	var file struct {
+		Default    string            `json:"default"`
+		Groups     map[string]string `json:"groups"`
+		Principals map[string]string `json:"principals"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return cfg, fmt.Errorf("rate limits %s: %w", path, err)
+	}
+	if file.Default != "" {
+		if cfg.Default, err = ParsePolicy(file.Default); err != nil {
+			return cfg, fmt.Errorf("rate limits %s: default: %w", path, err)
+		}
+	}
+	for target, spec := range map[*map[string]Policy]map[string]string{&cfg.Groups: file.Groups, &cfg.Principals: file.Principals} {
+		for name, s := range spec {
+			p, err := ParsePolicy(s)
+			if err != nil {
+				return cfg, fmt.Errorf("rate limits %s: %s: %w", path, name, err)
+			}
+			(*target)[name] = p
+		}
+	}
+	return cfg, nil
+}
+
+// LimiterFromEnv ... the limiter RATE_LIMIT_BACKEND names: memory, the default, where every replica counts on its
+// own, or postgres, where all replicas share the rate_limits table of db
+func LimiterFromEnv(db *sql.DB) (Limiter, error) {
+	switch backend := os.Getenv("RATE_LIMIT_BACKEND"); backend {
+	case "", "memory":
+		return NewMemory(), nil
+	case "postgres":
+		return NewPostgres(db), nil
+	default:
+		return nil, fmt.Errorf("RATE_LIMIT_BACKEND must be memory or postgres, got %q", backend)
+	}
+}
+
+// ParsePolicy reads "10/s", "100/m burst 20" or "1000/h", the burst defaults to the limit
+func ParsePolicy(s string) (Policy, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 1 && !(len(fields) == 3 && fields[1] == "burst") {
+		return Policy{}, fmt.Errorf("%q is not like 10/s or 10/s burst 20", s)
+	}
+
+	count, unit, _ := strings.Cut(fields[0], "/")
+	var p Policy
+	var err error
+	if p.Limit, err = strconv.Atoi(count); err != nil || p.Limit < 1 {
+		return Policy{}, fmt.Errorf("%q: the limit must be a positive number", s)
+	}
+	switch unit {
+	case "s":
+		p.Period = time.Second
+	case "m":
+		p.Period = time.Minute
+	case "h":
+		p.Period = time.Hour
+	default:
+		return Policy{}, fmt.Errorf("%q: the period must be s, m or h", s)
+	}
+
+	p.Burst = p.Limit
+	if len(fields) == 3 {
+		if p.Burst, err = strconv.Atoi(fields[2]); err != nil || p.Burst < 1 {
+			return Policy{}, fmt.Errorf("%q: the burst must be a positive number", s)
+		}
+	}
+	return p, nil
+}
+
+// RunJanitor purges l every interval until ctx is done
+func RunJanitor(ctx context.Context, l Limiter, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := l.Purge(ctx); err != nil {
+				slog.Error("purging rate limits", "err", err)
+			}
+		}
+	}
+}
+
+// Seconds ... d rounded up to whole seconds, as the headers want them
+func Seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,245 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGCRA(t *testing.T) {
+	p := Policy{Limit: 1, Period: time.Second, Burst: 3}
+	start := time.Unix(1_700_000_000, 0)
+
+	// one key, requests at these offsets from start, each step sees the TAT the previous one stored
+	steps := []struct {
+		at   time.Duration
+		want Result
+	}{
+		{0, Result{Allowed: true, Limit: 3, Remaining: 2, ResetAfter: time.Second}},
+		{0, Result{Allowed: true, Limit: 3, Remaining: 1, ResetAfter: 2 * time.Second}},
+		{0, Result{Allowed: true, Limit: 3, Remaining: 0, ResetAfter: 3 * time.Second}},
+		{0, Result{Limit: 3, ResetAfter: 3 * time.Second, RetryAfter: time.Second}},
+		{500 * time.Millisecond, Result{Limit: 3, ResetAfter: 2500 * time.Millisecond, RetryAfter: 500 * time.Millisecond}},
+		{time.Second, Result{Allowed: true, Limit: 3, Remaining: 0, ResetAfter: 3 * time.Second}},
+		{1500 * time.Millisecond, Result{Limit: 3, ResetAfter: 2500 * time.Millisecond, RetryAfter: 500 * time.Millisecond}},
+		// two intervals after the last allowed request two tokens are back, this request takes one of them
+		{3 * time.Second, Result{Allowed: true, Limit: 3, Remaining: 1, ResetAfter: 2 * time.Second}},
+		// idle long enough the bucket is full, as if the key was never seen
+		{time.Minute, Result{Allowed: true, Limit: 3, Remaining: 2, ResetAfter: time.Second}},
+	}
+	var tat time.Time
+	for i, step := range steps {
+		var got Result
+		got, tat = gcra(start.Add(step.at), tat, p)
+		if got != step.want {
+			t.Errorf("step %d at +%v: got %+v, want %+v", i, step.at, got, step.want)
+		}
+	}
+}
+
+func TestGCRASustainedRate(t *testing.T) {
+	tests := []struct {
+		policy Policy
+		every  time.Duration
+		want   int
+	}{
+		// a client sending faster than the limit gets the burst at once and then one request per interval,
+		// at 100ms, 200ms ... 900ms for 10/s
+		{Policy{Limit: 10, Period: time.Second, Burst: 5}, 10 * time.Millisecond, 5 + 9},
+		{Policy{Limit: 60, Period: time.Minute, Burst: 1}, 100 * time.Millisecond, 1},
+		// a client below the limit is never refused
+		{Policy{Limit: 10, Period: time.Second, Burst: 1}, 100 * time.Millisecond, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			start := time.Unix(1_700_000_000, 0)
+			var tat time.Time
+			allowed := 0
+			// one second of requests, the last one just before the second is over
+			for at := time.Duration(0); at < time.Second; at += tt.every {
+				var r Result
+				r, tat = gcra(start.Add(at), tat, tt.policy)
+				if r.Allowed {
+					allowed++
+				}
+			}
+			if allowed != tt.want {
+				t.Errorf("%d requests allowed in a second, want %d", allowed, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	p := Policy{Limit: 1, Period: time.Hour, Burst: 2}
+
+	for i, want := range []bool{true, true, false} {
+		r, err := m.Allow(ctx, "a", p)
+		if err != nil || r.Allowed != want {
+			t.Fatalf("request %d of a: %+v, %v, want allowed %v", i, r, err, want)
+		}
+	}
+	if r, _ := m.Allow(ctx, "b", p); !r.Allowed || r.Remaining != 1 {
+		t.Errorf("b is counted with a: %+v", r)
+	}
+
+	// a key whose TAT has passed is as good as new and purged, one still counting is kept
+	m.tats["old"] = time.Now().Add(-time.Second)
+	n, err := m.Purge(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("Purge = %d, %v, want 1", n, err)
+	}
+	if _, ok := m.tats["a"]; !ok {
+		t.Error("Purge removed a key that is still limited")
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Policy
+		wantErr bool
+	}{
+		{"10/s", Policy{10, time.Second, 10}, false},
+		{"100/m burst 20", Policy{100, time.Minute, 20}, false},
+		{"1000/h", Policy{1000, time.Hour, 1000}, false},
+		{"  5/s   burst   1 ", Policy{5, time.Second, 1}, false},
+		{"", Policy{}, true},
+		{"10", Policy{}, true},
+		{"10/d", Policy{}, true},
+		{"0/s", Policy{}, true},
+		{"-1/s", Policy{}, true},
+		{"ten/s", Policy{}, true},
+		{"10/s burst", Policy{}, true},
+		{"10/s burst 0", Policy{}, true},
+		{"10/s bursts 5", Policy{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParsePolicy(tt.in)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Fatalf("ParsePolicy(%q) = %+v, %v, want %+v", tt.in, got, err, tt.want)
+			}
+			if err == nil {
+				if again, _ := ParsePolicy(got.String()); again != got {
+					t.Errorf("%q does not read back as %+v", got.String(), got)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigPolicy(t *testing.T) {
+	cfg := Config{
+		Default:    Policy{1, time.Second, 1},
+		Groups:     map[string]Policy{"lists": {2, time.Second, 2}},
+		Principals: map[string]Policy{"apikey:3": {3, time.Second, 3}},
+	}
+	tests := []struct {
+		group, subject string
+		want           int
+	}{
+		{"lists", "apikey:1", 2},
+		{"create", "apikey:1", 1},
+		{"lists", "apikey:3", 3},
+		{"create", "apikey:3", 3},
+	}
+	for _, tt := range tests {
+		if got := cfg.Policy(tt.group, tt.subject); got.Limit != tt.want {
+			t.Errorf("Policy(%q, %q) = %+v, want limit %d", tt.group, tt.subject, got, tt.want)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		check   func(t *testing.T, cfg Config)
+		wantErr bool
+	}{
+		{"no file", "", func(t *testing.T, cfg Config) {
+			if cfg.Default != DefaultConfig().Default {
+				t.Errorf("default %+v", cfg.Default)
+			}
+		}, false},
+		{"overrides", `{"default": "5/s burst 9", "groups": {"lists": "1/m"}, "principals": {"apikey:3": "100/s"}}`,
+			func(t *testing.T, cfg Config) {
+				if cfg.Default != (Policy{5, time.Second, 9}) || cfg.Groups["lists"] != (Policy{1, time.Minute, 1}) ||
+					cfg.Principals["apikey:3"] != (Policy{100, time.Second, 100}) {
+					t.Errorf("config %+v", cfg)
+				}
+				if cfg.Groups["login"] != DefaultConfig().Groups["login"] {
+					t.Errorf("a group the file leaves out lost its default: %+v", cfg.Groups["login"])
+				}
+			}, false},
+		{"bad policy", `{"groups": {"lists": "lots"}}`, nil, true},
+		{"not json", `{"groups":`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := ""
+			if tt.file != "" {
+				path = filepath.Join(t.TempDir(), "limits.json")
+				if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv("RATE_LIMIT_FILE", path)
+			cfg, err := FromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromEnv = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.check != nil {
+				tt.check(t, cfg)
+			}
+		})
+	}
+}
+
+func TestSeconds(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want int
+	}{
+		{0, 0},
+		{time.Millisecond, 1},
+		{time.Second, 1},
+		{1500 * time.Millisecond, 2},
+	}
+	for _, tt := range tests {
+		if got := Seconds(tt.in); got != tt.want {
+			t.Errorf("Seconds(%v) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLimiterFromEnv(t *testing.T) {
+	tests := []struct {
+		backend string
+		want    Limiter
+		wantErr bool
+	}{
+		{"", &Memory{}, false},
+		{"memory", &Memory{}, false},
+		{"postgres", &Postgres{}, false},
+		{"redis", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.backend, func(t *testing.T) {
+			t.Setenv("RATE_LIMIT_BACKEND", tt.backend)
+			l, err := LimiterFromEnv(nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LimiterFromEnv = %v, want error %v", err, tt.wantErr)
+			}
+			if fmt.Sprintf("%T", l) != fmt.Sprintf("%T", tt.want) {
+				t.Errorf("LimiterFromEnv = %T, want %T", l, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"school_api_postgres/models"
+)
+
+// ErrRevoked ... a revoked key cannot be rotated, only replaced by a new one
+var ErrRevoked = errors.New("was revoked")
+
+// APIKeyStore ... the API keys clients authenticate with
+type APIKeyStore interface {
+	// List ... every key, revoked and expired ones included, oldest first
+	List(ctx context.Context) ([]models.APIKey, error)
+	// Get ... ErrNotFound if there is no key with the id
+	Get(ctx context.Context, id int) (models.APIKey, error)
+	// FindByPrefix ... the key with the prefix, revoked and expired ones included, ErrNotFound if there is none
+	FindByPrefix(ctx context.Context, prefix string) (models.APIKey, error)
+	// Create stores a new key, ErrConflict if the prefix is taken
+	Create(ctx context.Context, k models.APIKey) (models.APIKey, error)
+	// Rotate stores replacement and makes key id expire at oldExpiresAt, or earlier if it already does,
+	// both or neither. ErrNotFound, ErrRevoked, or ErrConflict if the prefix of replacement is taken
+	Rotate(ctx context.Context, id int, replacement models.APIKey, oldExpiresAt time.Time) (models.APIKey, error)
+	// Revoke ... the key stops working at once, revoking it twice is fine, ErrNotFound if there is none
+	Revoke(ctx context.Context, id int) (models.APIKey, error)
+	// MarkUsed ... sets last_used_at
+	MarkUsed(ctx context.Context, id int, at time.Time) error
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"school_api_postgres/models"
+)
+
+var _ APIKeyStore = (*MemoryAPIKeyStore)(nil)
+
+// MemoryAPIKeyStore ... APIKeyStore kept in a map, safe for concurrent use
+type MemoryAPIKeyStore struct {
+	mu     sync.RWMutex
+	keys   map[int]models.APIKey
+	nextID int
+}
+
+// NewMemoryAPIKeyStore ...
+func NewMemoryAPIKeyStore() *MemoryAPIKeyStore {
+	return &MemoryAPIKeyStore{keys: make(map[int]models.APIKey), nextID: 1}
+}
+
+// FindByPrefix ...
+func (m *MemoryAPIKeyStore) FindByPrefix(ctx context.Context, prefix string) (models.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, k := range m.keys {
+		if k.Prefix == prefix {
+			return cloneAPIKey(k), nil
+		}
+	}
+	return models.APIKey{}, ErrNotFound
+}
+
+// List ...
+func (m *MemoryAPIKeyStore) List(ctx context.Context) ([]models.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]models.APIKey, 0, len(m.keys))
+	for _, k := range m.keys {
+		keys = append(keys, cloneAPIKey(k))
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys, nil
+}
+
+// Get ...
+func (m *MemoryAPIKeyStore) Get(ctx context.Context, id int) (models.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	k, ok := m.keys[id]
+	if !ok {
+		return models.APIKey{}, ErrNotFound
+	}
+	return cloneAPIKey(k), nil
+}
+
+// Create ...
+func (m *MemoryAPIKeyStore) Create(ctx context.Context, k models.APIKey) (models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.create(k)
+}
+
+// create ... the caller holds the write lock
+func (m *MemoryAPIKeyStore) create(k models.APIKey) (models.APIKey, error) {
+	for _, other := range m.keys {
+		if other.Prefix == k.Prefix {
+			return k, constraintError(ErrConflict, "api_keys_prefix_key")
+		}
+	}
+	k.ID = m.nextID
+	m.nextID++
+	k.CreatedAt = time.Now()
+	m.keys[k.ID] = cloneAPIKey(k)
+	return k, nil
+}
+
+// Rotate ...
+func (m *MemoryAPIKeyStore) Rotate(ctx context.Context, id int, replacement models.APIKey, oldExpiresAt time.Time) (models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.keys[id]
+	if !ok {
+		return replacement, ErrNotFound
+	}
+	if old.Revoked {
+		return replacement, ErrRevoked
+	}
+	k, err := m.create(replacement)
+	if err != nil {
+		return replacement, err
+	}
+	if old.ExpiresAt == nil || oldExpiresAt.Before(*old.ExpiresAt) {
+		old.ExpiresAt = &oldExpiresAt
+	}
+	m.keys[id] = old
+	return k, nil
+}
+
+// Revoke ...
+func (m *MemoryAPIKeyStore) Revoke(ctx context.Context, id int) (models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k, ok := m.keys[id]
+	if !ok {
+		return models.APIKey{}, ErrNotFound
+	}
+	k.Revoked = true
+	m.keys[id] = k
+	return cloneAPIKey(k), nil
+}
+
+// MarkUsed ...
+func (m *MemoryAPIKeyStore) MarkUsed(ctx context.Context, id int, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if k, ok := m.keys[id]; ok {
+		k.LastUsedAt = &at
+		m.keys[id] = k
+	}
+	return nil
+}
+
+// cloneAPIKey ... copies the scopes so callers cannot mutate the stored key
+func cloneAPIKey(k models.APIKey) models.APIKey {
+	k.Scopes = slices.Clone(k.Scopes)
+	return k
+}
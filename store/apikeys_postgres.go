@@ -0,0 +1,138 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"school_api_postgres/models"
+
+	"github.com/lib/pq"
+)
+
+var _ APIKeyStore = (*PostgresAPIKeyStore)(nil)
+
+// PostgresAPIKeyStore ... APIKeyStore backed by the api_keys table
+type PostgresAPIKeyStore struct {
+	db *sql.DB
+}
+
+// NewPostgresAPIKeyStore ...
+func NewPostgresAPIKeyStore(db *sql.DB) *PostgresAPIKeyStore {
+	return &PostgresAPIKeyStore{db: db}
+}
+
+const apiKeySelect = "SELECT id, name, prefix, secret_hash, scopes, expires_at, revoked, last_used_at, created_at, created_by FROM api_keys"
+
+// scanAPIKey ... works for *sql.Row and *sql.Rows
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (models.APIKey, error) {
+	var k models.APIKey
+	err := row.Scan(&k.ID, &k.Name, &k.Prefix, &k.SecretHash, pq.Array(&k.Scopes), &k.ExpiresAt, &k.Revoked, &k.LastUsedAt, &k.CreatedAt, &k.CreatedBy)
+	return k, err
+}
+
+// FindByPrefix ...
+func (p *PostgresAPIKeyStore) FindByPrefix(ctx context.Context, prefix string) (models.APIKey, error) {
+	k, err := scanAPIKey(p.db.QueryRowContext(ctx, apiKeySelect+" WHERE prefix=$1", prefix))
+	if errors.Is(err, sql.ErrNoRows) {
+		return k, ErrNotFound
+	}
+	return k, err
+}
+
+// apiKeyInsert ... takes name, prefix, secret_hash, scopes, expires_at and created_by
+const apiKeyInsert = `
+	INSERT INTO api_keys (name, prefix, secret_hash, scopes, expires_at, created_by)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id, created_at`
+
+// List ...
+func (p *PostgresAPIKeyStore) List(ctx context.Context) ([]models.APIKey, error) {
+	rows, err := p.db.QueryContext(ctx, apiKeySelect+" ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// Get ...
+func (p *PostgresAPIKeyStore) Get(ctx context.Context, id int) (models.APIKey, error) {
+	k, err := scanAPIKey(p.db.QueryRowContext(ctx, apiKeySelect+" WHERE id=$1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return k, ErrNotFound
+	}
+	return k, err
+}
+
+// Create ...
+func (p *PostgresAPIKeyStore) Create(ctx context.Context, k models.APIKey) (models.APIKey, error) {
+	err := p.db.QueryRowContext(ctx, apiKeyInsert,
+		k.Name, k.Prefix, k.SecretHash, pq.Array(k.Scopes), k.ExpiresAt, k.CreatedBy).Scan(&k.ID, &k.CreatedAt)
+	return k, pgError(err)
+}
+
+// Rotate ... the old key is locked FOR UPDATE so a revoke cannot slip in between the check and the insert
+func (p *PostgresAPIKeyStore) Rotate(ctx context.Context, id int, replacement models.APIKey, oldExpiresAt time.Time) (models.APIKey, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return replacement, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var revoked bool
+	err = tx.QueryRowContext(ctx, "SELECT revoked FROM api_keys WHERE id=$1 FOR UPDATE", id).Scan(&revoked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return replacement, ErrNotFound
+	}
+	if err != nil {
+		return replacement, err
+	}
+	if revoked {
+		return replacement, ErrRevoked
+	}
+
+	k := replacement
+	err = tx.QueryRowContext(ctx, apiKeyInsert,
+		k.Name, k.Prefix, k.SecretHash, pq.Array(k.Scopes), k.ExpiresAt, k.CreatedBy).Scan(&k.ID, &k.CreatedAt)
+	if err != nil {
+		return replacement, pgError(err)
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE api_keys SET expires_at = LEAST(COALESCE(expires_at, $1), $1) WHERE id=$2", oldExpiresAt, id)
+	if err != nil {
+		return replacement, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return replacement, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return k, nil
+}
+
+// Revoke ...
+func (p *PostgresAPIKeyStore) Revoke(ctx context.Context, id int) (models.APIKey, error) {
+	k, err := scanAPIKey(p.db.QueryRowContext(ctx, `
+		UPDATE api_keys SET revoked = TRUE WHERE id=$1
+		RETURNING id, name, prefix, secret_hash, scopes, expires_at, revoked, last_used_at, created_at, created_by`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return k, ErrNotFound
+	}
+	return k, err
+}
+
+// MarkUsed ...
+func (p *PostgresAPIKeyStore) MarkUsed(ctx context.Context, id int, at time.Time) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE api_keys SET last_used_at=$1 WHERE id=$2", at, id)
+	return err
+}
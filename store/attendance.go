@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"math"
+
+	"school_api_postgres/models"
+)
+
+// DateRange ... inclusive bounds, nil means open ended
+type DateRange struct {
+	From *models.Date
+	To   *models.Date
+}
+
+// contains ...
+func (r DateRange) contains(d models.Date) bool {
+	if r.From != nil && d.Before(r.From.Time) {
+		return false
+	}
+	if r.To != nil && d.After(r.To.Time) {
+		return false
+	}
+	return true
+}
+
+// AttendanceStore ... daily attendance per student
+type AttendanceStore interface {
+	// Mark records (or corrects) attendance for students of one class on one day in a single transaction,
+	// ErrInvalidReference if a student does not exist or is not in that class
+	Mark(ctx context.Context, class int, date models.Date, records []models.AttendanceRecord) ([]models.AttendanceRecord, error)
+	// StudentHistory ... a student's records ordered by date, ErrNotFound if the student is missing
+	StudentHistory(ctx context.Context, studentID int, r DateRange) ([]models.AttendanceRecord, error)
+	// StudentSummary ... ErrNotFound if the student is missing
+	StudentSummary(ctx context.Context, studentID int, r DateRange) (models.AttendanceSummary, error)
+	// ClassSummary ... every student currently in the class, including those with no records
+	ClassSummary(ctx context.Context, class int, r DateRange) (models.ClassAttendanceSummary, error)
+}
+
+// count adds one record to the summary
+func count(s *models.AttendanceSummary, status string) {
+	s.Days++
+	switch status {
+	case models.AttendancePresent:
+		s.Present++
+	case models.AttendanceAbsent:
+		s.Absent++
+	case models.AttendanceLate:
+		s.Late++
+	case models.AttendanceExcused:
+		s.Excused++
+	}
+}
+
+// finishSummary fills in the percentage, late counts as attended and excused days are left out entirely
+func finishSummary(s *models.AttendanceSummary) {
+	counted := s.Days - s.Excused
+	if counted <= 0 {
+		s.Percentage = nil
+		return
+	}
+	p := math.Round(float64(s.Present+s.Late)/float64(counted)*10000) / 100 // two decimals
+	s.Percentage = &p
+}
+
+// finishClassSummary ... computes every percentage and the overall totals
+func finishClassSummary(c *models.ClassAttendanceSummary) {
+	c.Overall = models.AttendanceSummary{}
+	for i := range c.Students {
+		s := &c.Students[i]
+		finishSummary(s)
+		c.Overall.Days += s.Days
+		c.Overall.Present += s.Present
+		c.Overall.Absent += s.Absent
+		c.Overall.Late += s.Late
+		c.Overall.Excused += s.Excused
+	}
+	finishSummary(&c.Overall)
+	if c.Students == nil {
+		c.Students = []models.AttendanceSummary{}
+	}
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"school_api_postgres/models"
+)
+
+var _ AttendanceStore = (*MemoryAttendanceStore)(nil)
+
+// attendanceKey ... one record per student per day, like the primary key in Postgres
+type attendanceKey struct {
+	studentID int
+	date      string
+}
+
+// MemoryAttendanceStore ... AttendanceStore kept in a map, safe for concurrent use
+// class membership is checked against the student store the way the Postgres query joins students
+type MemoryAttendanceStore struct {
+	mu      sync.RWMutex
+	records map[attendanceKey]models.AttendanceRecord
+
+	students StudentStore
+}
+
+// NewMemoryAttendanceStore ...
+func NewMemoryAttendanceStore(students StudentStore) *MemoryAttendanceStore {
+	return &MemoryAttendanceStore{
+		records:  make(map[attendanceKey]models.AttendanceRecord),
+		students: students,
+	}
+}
+
+// Mark ...
+func (m *MemoryAttendanceStore) Mark(ctx context.Context, class int, date models.Date, records []models.AttendanceRecord) ([]models.AttendanceRecord, error) {
+	for _, rec := range records {
+		s, err := m.students.Get(ctx, rec.StudentID)
+		if errors.Is(err, ErrNotFound) || (err == nil && s.Class != class) {
+			return nil, fmt.Errorf("%w: every student must be in class %d", ErrInvalidReference, class)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	marked := make([]models.AttendanceRecord, len(records))
+	for i, rec := range records {
+		rec.Date = date
+		rec.RecordedAt = now
+		m.records[attendanceKey{rec.StudentID, date.String()}] = rec
+		marked[i] = rec
+	}
+	return marked, nil
+}
+
+// StudentHistory ...
+func (m *MemoryAttendanceStore) StudentHistory(ctx context.Context, studentID int, r DateRange) ([]models.AttendanceRecord, error) {
+	if _, err := m.students.Get(ctx, studentID); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	var records []models.AttendanceRecord
+	for key, rec := range m.records {
+		if key.studentID == studentID && r.contains(rec.Date) {
+			records = append(records, rec)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(records, func(i, j int) bool { return records[i].Date.Before(records[j].Date.Time) })
+	return records, nil
+}
+
+// StudentSummary ...
+func (m *MemoryAttendanceStore) StudentSummary(ctx context.Context, studentID int, r DateRange) (models.AttendanceSummary, error) {
+	summary := models.AttendanceSummary{StudentID: studentID}
+	records, err := m.StudentHistory(ctx, studentID, r)
+	if err != nil {
+		return summary, err
+	}
+	for _, rec := range records {
+		count(&summary, rec.Status)
+	}
+	finishSummary(&summary)
+	return summary, nil
+}
+
+// ClassSummary ...
+func (m *MemoryAttendanceStore) ClassSummary(ctx context.Context, class int, r DateRange) (models.ClassAttendanceSummary, error) {
+	summary := models.ClassAttendanceSummary{Class: class}
+	if r.From != nil {
+		summary.From = *r.From
+	}
+	if r.To != nil {
+		summary.To = *r.To
+	}
+
+	// a zero limit lists the whole class, already ordered by id
+	students, _, err := m.students.List(ctx, StudentFilter{Class: &class}, ListOptions{NoCount: true})
+	if err != nil {
+		return summary, err
+	}
+
+	index := make(map[int]int, len(students))
+	summary.Students = make([]models.AttendanceSummary, len(students))
+	for i, s := range students {
+		index[s.ID] = i
+		summary.Students[i].StudentID = s.ID
+	}
+
+	m.mu.RLock()
+	for key, rec := range m.records {
+		if i, ok := index[key.studentID]; ok && r.contains(rec.Date) {
+			count(&summary.Students[i], rec.Status)
+		}
+	}
+	m.mu.RUnlock()
+
+	finishClassSummary(&summary)
+	return summary, nil
+}
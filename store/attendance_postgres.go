@@ -0,0 +1,188 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"school_api_postgres/models"
+
+	"github.com/lib/pq"
+)
+
+var _ AttendanceStore = (*PostgresAttendanceStore)(nil)
+
+// PostgresAttendanceStore ... AttendanceStore backed by the attendance table
+type PostgresAttendanceStore struct {
+	db *sql.DB
+}
+
+// NewPostgresAttendanceStore ...
+func NewPostgresAttendanceStore(db *sql.DB) *PostgresAttendanceStore {
+	return &PostgresAttendanceStore{db: db}
+}
+
+// Mark upserts the day's records in batches the same way the student bulk insert does
+func (p *PostgresAttendanceStore) Mark(ctx context.Context, class int, date models.Date, records []models.AttendanceRecord) ([]models.AttendanceRecord, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	ids := make([]int, len(records))
+	for i, rec := range records {
+		ids[i] = rec.StudentID
+	}
+
+	// every student must exist and belong to the class being marked
+	var inClass int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM students WHERE id = ANY($1) AND class = $2", pq.Array(ids), class).Scan(&inClass)
+	if err != nil {
+		return nil, err
+	}
+	if inClass != len(ids) {
+		return nil, fmt.Errorf("%w: every student must be in class %d", ErrInvalidReference, class)
+	}
+
+	marked := make([]models.AttendanceRecord, len(records))
+	rows := make([][]interface{}, len(records))
+	for i, rec := range records {
+		rec.Date = date
+		marked[i] = rec
+		rows[i] = []interface{}{rec.StudentID, date, rec.Status, rec.Note}
+	}
+
+	// marking the same day again corrects the earlier record instead of failing
+	query := `
+		INSERT INTO attendance (student_id, date, status, note)
+		VALUES %s
+		ON CONFLICT (student_id, date) DO UPDATE
+		SET status = EXCLUDED.status, note = EXCLUDED.note, recorded_at = now()
+		RETURNING recorded_at`
+	err = batchInsert(ctx, tx, query, rows, func(r *sql.Rows, i int) error { return r.Scan(&marked[i].RecordedAt) })
+	if err != nil {
+		return nil, pgError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return marked, nil
+}
+
+// StudentHistory ...
+func (p *PostgresAttendanceStore) StudentHistory(ctx context.Context, studentID int, r DateRange) ([]models.AttendanceRecord, error) {
+	if err := studentExists(ctx, p.db, studentID); err != nil {
+		return nil, err
+	}
+
+	var where whereBuilder
+	where.add("student_id = ?", studentID)
+	rangeWhere(&where, "date", r)
+
+	rows, err := p.db.QueryContext(ctx, "SELECT student_id, date, status, note, recorded_at FROM attendance "+where.String()+" ORDER BY date", where.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []models.AttendanceRecord
+	for rows.Next() {
+		var rec models.AttendanceRecord
+		if err := rows.Scan(&rec.StudentID, &rec.Date, &rec.Status, &rec.Note, &rec.RecordedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// StudentSummary ...
+func (p *PostgresAttendanceStore) StudentSummary(ctx context.Context, studentID int, r DateRange) (models.AttendanceSummary, error) {
+	summary := models.AttendanceSummary{StudentID: studentID}
+	if err := studentExists(ctx, p.db, studentID); err != nil {
+		return summary, err
+	}
+
+	var where whereBuilder
+	where.add("student_id = ?", studentID)
+	rangeWhere(&where, "date", r)
+
+	err := p.db.QueryRowContext(ctx, `
+		SELECT COUNT(*),
+			COUNT(*) FILTER (WHERE status = 'present'),
+			COUNT(*) FILTER (WHERE status = 'absent'),
+			COUNT(*) FILTER (WHERE status = 'late'),
+			COUNT(*) FILTER (WHERE status = 'excused')
+		FROM attendance `+where.String(), where.args...).
+		Scan(&summary.Days, &summary.Present, &summary.Absent, &summary.Late, &summary.Excused)
+	if err != nil {
+		return summary, err
+	}
+	finishSummary(&summary)
+	return summary, nil
+}
+
+// ClassSummary counts per student in one GROUP BY, the LEFT JOIN keeps students without records
+func (p *PostgresAttendanceStore) ClassSummary(ctx context.Context, class int, r DateRange) (models.ClassAttendanceSummary, error) {
+	summary := models.ClassAttendanceSummary{Class: class}
+	if r.From != nil {
+		summary.From = *r.From
+	}
+	if r.To != nil {
+		summary.To = *r.To
+	}
+
+	// the range goes into the JOIN condition, in WHERE it would drop students with no records
+	var join whereBuilder
+	join.add("a.student_id = s.id")
+	rangeWhere(&join, "a.date", r)
+	classArg := join.arg(class)
+
+	rows, err := p.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT s.id,
+			COUNT(a.status),
+			COUNT(*) FILTER (WHERE a.status = 'present'),
+			COUNT(*) FILTER (WHERE a.status = 'absent'),
+			COUNT(*) FILTER (WHERE a.status = 'late'),
+			COUNT(*) FILTER (WHERE a.status = 'excused')
+		FROM students s
+		LEFT JOIN attendance a ON %s
+		WHERE s.class = %s
+		GROUP BY s.id
+		ORDER BY s.id`, joinConditions(join), classArg), join.args...)
+	if err != nil {
+		return summary, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s models.AttendanceSummary
+		if err := rows.Scan(&s.StudentID, &s.Days, &s.Present, &s.Absent, &s.Late, &s.Excused); err != nil {
+			return summary, err
+		}
+		summary.Students = append(summary.Students, s)
+	}
+	if err := rows.Err(); err != nil {
+		return summary, err
+	}
+	finishClassSummary(&summary)
+	return summary, nil
+}
+
+// rangeWhere ... adds the inclusive bounds of r on column
+func rangeWhere(where *whereBuilder, column string, r DateRange) {
+	if r.From != nil {
+		where.add(column+" >= ?", *r.From)
+	}
+	if r.To != nil {
+		where.add(column+" <= ?", *r.To)
+	}
+}
+
+// joinConditions ... the collected conditions without the WHERE keyword, for ON clauses
+func joinConditions(b whereBuilder) string {
+	s := b.String()
+	return s[len("WHERE "):]
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"school_api_postgres/metrics"
+)
+
+// bulkBatchSize ... how many rows go into one multi-row INSERT so the query size stays sane
+const bulkBatchSize = 3
+
+var (
+	bulkRows = metrics.NewHistogram("school_api_bulk_insert_rows",
+		"Rows per bulk insert, by table.", []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000}, "table")
+	bulkBatches = metrics.NewHistogram("school_api_bulk_insert_batches",
+		"Multi-row INSERT statements per bulk insert, by table.", []float64{1, 2, 5, 10, 25, 50, 100, 250}, "table")
+)
+
+// batchInsert runs a multi-row INSERT in batches of bulkBatchSize inside tx
+// query has one %s where the VALUES list goes, e.g. "INSERT INTO students (name, age, class) VALUES %s RETURNING id",
+// every element of rows holds the values of one row in column order
+// scan is called for each RETURNING row with the index of the input row it belongs to, nil when nothing is returned
+func batchInsert(ctx context.Context, tx *sql.Tx, query string, rows [][]interface{}, scan func(rows *sql.Rows, i int) error) error {
+	table := tableOf(query)
+	bulkRows.Observe(float64(len(rows)), table)
+	bulkBatches.Observe(float64((len(rows)+bulkBatchSize-1)/bulkBatchSize), table)
+
+	for i := 0; i < len(rows); i += bulkBatchSize {
+
+		end := i + bulkBatchSize
+		if end > len(rows) { // this is for last batch
+			end = len(rows)
+		}
+
+		batch := rows[i:end] // Get the current batch of rows
+
+		// Build the VALUES clause dynamically for the current batch
+		var valueStrings []string
+		var valueArgs []interface{}
+
+		for _, row := range batch {
+			placeholders := make([]string, len(row))
+			for k := range row {
+				placeholders[k] = fmt.Sprintf("$%d", len(valueArgs)+k+1) // example = ($1, $2, $3), ($4, $5, $6)
+			}
+			valueStrings = append(valueStrings, "("+strings.Join(placeholders, ", ")+")")
+			valueArgs = append(valueArgs, row...)
+		}
+
+		// Combine the query and VALUES clause
+		finalQuery := fmt.Sprintf(query, strings.Join(valueStrings, ", "))
+
+		if scan == nil {
+			if _, err := tx.ExecContext(ctx, finalQuery, valueArgs...); err != nil {
+				return fmt.Errorf("failed to execute bulk insert: %w", err)
+			}
+			continue
+		}
+
+		// Execute the bulk insert query for the current batch
+		result, err := tx.QueryContext(ctx, finalQuery, valueArgs...)
+		if err != nil {
+			return fmt.Errorf("failed to execute bulk insert: %w", err)
+		}
+
+		j := i // index of the input row this RETURNING row belongs to
+		for result.Next() {
+			if err := scan(result, j); err != nil {
+				result.Close()
+				return fmt.Errorf("failed to scan inserted row: %w", err)
+			}
+			j++
+		}
+
+		// Close the rows object for the current batch
+		// Else resource leak may happen
+		if err := result.Close(); err != nil {
+			slog.Error("closing rows", "err", err)
+		}
+		if err := result.Err(); err != nil {
+			return fmt.Errorf("failed to execute bulk insert: %w", err)
+		}
+	}
+	return nil
+}
+
+// tableOf ... the table of an "INSERT INTO table ..." query
+func tableOf(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) < 3 {
+		return ""
+	}
+	return fields[2]
+}
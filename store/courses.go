@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+
+	"school_api_postgres/models"
+)
+
+// CourseSortFields ... the only fields a course list may be sorted by
+var CourseSortFields = []string{"id", "code", "name"}
+
+// CourseFilter ... narrows a course list, zero values mean no filter
+type CourseFilter struct {
+	TeacherID *int
+	// NameContains is matched case-insensitively against name and code
+	NameContains string
+}
+
+// CourseStore ... courses and the enrollments linking them to students
+type CourseStore interface {
+	List(ctx context.Context, filter CourseFilter, opts ListOptions) ([]models.Course, int, error)
+	Get(ctx context.Context, id int) (models.Course, error)
+	// Create and Update return ErrInvalidReference when TeacherID does not exist
+	Create(ctx context.Context, c models.Course) (models.Course, error)
+	Update(ctx context.Context, id int, c models.Course) error
+	Delete(ctx context.Context, id int) error
+
+	// Enroll adds every student to the course in one transaction: ErrNotFound if the course is missing,
+	// ErrInvalidReference if a student is missing and ErrConflict if one is already enrolled
+	Enroll(ctx context.Context, courseID int, studentIDs []int) ([]models.Enrollment, error)
+	// Unenroll removes the students from the course, ErrNotFound if any of them was not enrolled
+	Unenroll(ctx context.Context, courseID int, studentIDs []int) error
+	// Roster ... students enrolled in a course ordered by name, ErrNotFound if the course is missing
+	Roster(ctx context.Context, courseID int) ([]models.Student, error)
+	// StudentCourses ... courses a student takes ordered by code, ErrNotFound if the student is missing
+	StudentCourses(ctx context.Context, studentID int) ([]models.Course, error)
+}
+
+// CourseCursor ... the cursor pointing just after c for the given sort
+func CourseCursor(c models.Course, sort []SortField) Cursor {
+	var cur Cursor
+	for _, f := range KeysetSort(sort) {
+		cur.Values = append(cur.Values, courseFieldValue(c, f.Field))
+	}
+	return cur
+}
+
+func courseFieldValue(c models.Course, field string) interface{} {
+	switch field {
+	case "id":
+		return c.ID
+	case "code":
+		return c.Code
+	case "name":
+		return c.Name
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"school_api_postgres/models"
+)
+
+var _ CourseStore = (*MemoryCourseStore)(nil)
+
+// MemoryCourseStore ... CourseStore kept in maps, safe for concurrent use
+// it checks student and teacher references against the given stores the way the foreign keys do in Postgres
+type MemoryCourseStore struct {
+	mu          sync.RWMutex
+	courses     map[int]models.Course
+	enrollments map[[2]int]models.Enrollment // key is {courseID, studentID}
+	nextID      int
+
+	students StudentStore
+	teachers TeacherStore
+}
+
+// NewMemoryCourseStore ...
+func NewMemoryCourseStore(students StudentStore, teachers TeacherStore) *MemoryCourseStore {
+	return &MemoryCourseStore{
+		courses:     make(map[int]models.Course),
+		enrollments: make(map[[2]int]models.Enrollment),
+		nextID:      1,
+		students:    students,
+		teachers:    teachers,
+	}
+}
+
+// List ...
+func (m *MemoryCourseStore) List(ctx context.Context, filter CourseFilter, opts ListOptions) ([]models.Course, int, error) {
+	for _, f := range opts.Sort {
+		if _, ok := courseColumns[f.Field]; !ok {
+			return nil, 0, fmt.Errorf("cannot sort by %q", f.Field)
+		}
+	}
+	keys := KeysetSort(opts.Sort)
+	needle := strings.ToLower(filter.NameContains)
+
+	m.mu.RLock()
+	var all []models.Course
+	for _, c := range m.courses {
+		if filter.TeacherID != nil && (c.TeacherID == nil || *c.TeacherID != *filter.TeacherID) {
+			continue
+		}
+		if needle != "" && !strings.Contains(strings.ToLower(c.Name), needle) && !strings.Contains(strings.ToLower(c.Code), needle) {
+			continue
+		}
+		all = append(all, c)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(all, func(i, j int) bool {
+		return compareKeys(CourseCursor(all[i], keys).Values, CourseCursor(all[j], keys).Values, keys) < 0
+	})
+
+	total := len(all)
+	if opts.NoCount {
+		total = 0
+	}
+	if opts.After != nil {
+		if len(opts.After.Values) != len(keys) {
+			return nil, 0, ErrInvalidCursor
+		}
+		start := sort.Search(len(all), func(i int) bool {
+			return compareKeys(CourseCursor(all[i], keys).Values, opts.After.Values, keys) > 0
+		})
+		page, err := paginate(all[start:], ListOptions{Limit: opts.Limit})
+		return page, total, err
+	}
+	page, err := paginate(all, opts)
+	return page, total, err
+}
+
+// Get ...
+func (m *MemoryCourseStore) Get(ctx context.Context, id int) (models.Course, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, ok := m.courses[id]
+	if !ok {
+		return models.Course{}, ErrNotFound
+	}
+	return c, nil
+}
+
+// Create ...
+func (m *MemoryCourseStore) Create(ctx context.Context, c models.Course) (models.Course, error) {
+	if err := m.checkTeacher(ctx, c.TeacherID); err != nil {
+		return models.Course{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.codeTaken(c.Code, 0) {
+		return models.Course{}, constraintError(ErrConflict, "courses_code_key")
+	}
+	c.ID = m.nextID
+	m.nextID++
+	m.courses[c.ID] = c
+	return c, nil
+}
+
+// Update ...
+func (m *MemoryCourseStore) Update(ctx context.Context, id int, c models.Course) error {
+	if err := m.checkTeacher(ctx, c.TeacherID); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.courses[id]; !ok {
+		return ErrNotFound
+	}
+	if m.codeTaken(c.Code, id) {
+		return constraintError(ErrConflict, "courses_code_key")
+	}
+	c.ID = id
+	m.courses[id] = c
+	return nil
+}
+
+// Delete ... drops the enrollments too, like ON DELETE CASCADE
+func (m *MemoryCourseStore) Delete(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.courses[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.courses, id)
+	for key := range m.enrollments {
+		if key[0] == id {
+			delete(m.enrollments, key)
+		}
+	}
+	return nil
+}
+
+// Enroll ...
+func (m *MemoryCourseStore) Enroll(ctx context.Context, courseID int, studentIDs []int) ([]models.Enrollment, error) {
+	if _, err := m.Get(ctx, courseID); err != nil {
+		return nil, err
+	}
+	for _, id := range studentIDs {
+		if _, err := m.students.Get(ctx, id); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return nil, constraintError(ErrInvalidReference, "enrollments_student_id_fkey")
+			}
+			return nil, err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.courses[courseID]; !ok {
+		return nil, ErrNotFound
+	}
+	seen := make(map[int]bool)
+	for _, id := range studentIDs {
+		if _, ok := m.enrollments[[2]int{courseID, id}]; ok || seen[id] {
+			return nil, constraintError(ErrConflict, "enrollments_pkey")
+		}
+		seen[id] = true
+	}
+
+	now := time.Now()
+	enrollments := make([]models.Enrollment, 0, len(studentIDs))
+	for _, id := range studentIDs {
+		e := models.Enrollment{StudentID: id, CourseID: courseID, EnrolledAt: now}
+		m.enrollments[[2]int{courseID, id}] = e
+		enrollments = append(enrollments, e)
+	}
+	return enrollments, nil
+}
+
+// Unenroll ...
+func (m *MemoryCourseStore) Unenroll(ctx context.Context, courseID int, studentIDs []int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range studentIDs {
+		if _, ok := m.enrollments[[2]int{courseID, id}]; !ok {
+			return ErrNotFound
+		}
+	}
+	for _, id := range studentIDs {
+		delete(m.enrollments, [2]int{courseID, id})
+	}
+	return nil
+}
+
+// Roster ... students deleted since they enrolled are skipped, like ON DELETE CASCADE would have done
+func (m *MemoryCourseStore) Roster(ctx context.Context, courseID int) ([]models.Student, error) {
+	m.mu.RLock()
+	_, ok := m.courses[courseID]
+	var ids []int
+	for key := range m.enrollments {
+		if key[0] == courseID {
+			ids = append(ids, key[1])
+		}
+	}
+	m.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	var students []models.Student
+	for _, id := range ids {
+		s, err := m.students.Get(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		students = append(students, s)
+	}
+	sort.Slice(students, func(i, j int) bool {
+		if c := compareValues(students[i].Name, students[j].Name); c != 0 {
+			return c < 0
+		}
+		return students[i].ID < students[j].ID
+	})
+	return students, nil
+}
+
+// StudentCourses ...
+func (m *MemoryCourseStore) StudentCourses(ctx context.Context, studentID int) ([]models.Course, error) {
+	if _, err := m.students.Get(ctx, studentID); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var courses []models.Course
+	for key := range m.enrollments {
+		if key[1] == studentID {
+			if c, ok := m.courses[key[0]]; ok {
+				courses = append(courses, c)
+			}
+		}
+	}
+	sort.Slice(courses, func(i, j int) bool { return courses[i].Code < courses[j].Code })
+	return courses, nil
+}
+
+// checkTeacher ... the in-memory stand-in for the teacher_id foreign key
+func (m *MemoryCourseStore) checkTeacher(ctx context.Context, teacherID *int) error {
+	if teacherID == nil {
+		return nil
+	}
+	_, err := m.teachers.Get(ctx, *teacherID)
+	if errors.Is(err, ErrNotFound) {
+		return constraintError(ErrInvalidReference, "courses_teacher_id_fkey")
+	}
+	return err
+}
+
+func (m *MemoryCourseStore) codeTaken(code string, except int) bool {
+	for id, c := range m.courses {
+		if id != except && c.Code == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,229 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"school_api_postgres/models"
+
+	"github.com/lib/pq"
+)
+
+var _ CourseStore = (*PostgresCourseStore)(nil)
+
+// PostgresCourseStore ... CourseStore backed by the courses and enrollments tables
+type PostgresCourseStore struct {
+	db *sql.DB
+}
+
+// NewPostgresCourseStore ...
+func NewPostgresCourseStore(db *sql.DB) *PostgresCourseStore {
+	return &PostgresCourseStore{db: db}
+}
+
+var courseColumns = map[string]string{
+	"id":   "id",
+	"code": "code",
+	"name": "name",
+}
+
+const courseSelect = "SELECT id, code, name, description, teacher_id FROM courses"
+
+// List ...
+func (p *PostgresCourseStore) List(ctx context.Context, filter CourseFilter, opts ListOptions) ([]models.Course, int, error) {
+	order, err := orderBy(opts.Sort, courseColumns)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var where whereBuilder
+	if filter.TeacherID != nil {
+		where.add("teacher_id = ?", *filter.TeacherID)
+	}
+	if filter.NameContains != "" {
+		pattern := "%" + likeEscaper.Replace(filter.NameContains) + "%"
+		where.add(`(name ILIKE ? ESCAPE '\' OR code ILIKE ? ESCAPE '\')`, pattern, pattern)
+	}
+
+	var total int
+	if !opts.NoCount {
+		if err := p.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM courses "+where.String(), where.args...).Scan(&total); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	offset := opts.Offset
+	if opts.After != nil {
+		if err := keysetAfter(&where, opts.Sort, opts.After, courseColumns); err != nil {
+			return nil, 0, err
+		}
+		offset = 0
+	}
+
+	query := fmt.Sprintf("%s %s %s LIMIT %s OFFSET %s", courseSelect, where.String(), order, where.arg(opts.Limit), where.arg(offset))
+	courses, err := queryCourses(ctx, p.db, query, where.args...)
+	return courses, total, err
+}
+
+// queryCourses ... runs a query selecting the courseSelect columns
+func queryCourses(ctx context.Context, db *sql.DB, query string, args ...interface{}) ([]models.Course, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var courses []models.Course
+	for rows.Next() {
+		var c models.Course
+		if err := rows.Scan(&c.ID, &c.Code, &c.Name, &c.Description, &c.TeacherID); err != nil {
+			return nil, err
+		}
+		courses = append(courses, c)
+	}
+	return courses, rows.Err()
+}
+
+// Get ...
+func (p *PostgresCourseStore) Get(ctx context.Context, id int) (models.Course, error) {
+	var c models.Course
+	err := p.db.QueryRowContext(ctx, courseSelect+" WHERE id=$1", id).Scan(&c.ID, &c.Code, &c.Name, &c.Description, &c.TeacherID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c, ErrNotFound
+	}
+	return c, err
+}
+
+// Create ...
+func (p *PostgresCourseStore) Create(ctx context.Context, c models.Course) (models.Course, error) {
+	err := p.db.QueryRowContext(ctx, "INSERT INTO courses (code, name, description, teacher_id) VALUES ($1, $2, $3, $4) RETURNING id",
+		c.Code, c.Name, c.Description, c.TeacherID).Scan(&c.ID)
+	return c, pgError(err)
+}
+
+// Update ...
+func (p *PostgresCourseStore) Update(ctx context.Context, id int, c models.Course) error {
+	result, err := p.db.ExecContext(ctx, "UPDATE courses SET code=$1, name=$2, description=$3, teacher_id=$4 WHERE id=$5",
+		c.Code, c.Name, c.Description, c.TeacherID, id)
+	if err != nil {
+		return pgError(err)
+	}
+	return expectRows(result)
+}
+
+// Delete ... enrollments go with it through ON DELETE CASCADE
+func (p *PostgresCourseStore) Delete(ctx context.Context, id int) error {
+	result, err := p.db.ExecContext(ctx, "DELETE FROM courses WHERE id=$1", id)
+	if err != nil {
+		return pgError(err)
+	}
+	return expectRows(result)
+}
+
+// Enroll inserts the enrollments in batches inside one transaction, the primary key turns a repeat into ErrConflict
+func (p *PostgresCourseStore) Enroll(ctx context.Context, courseID int, studentIDs []int) ([]models.Enrollment, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// FOR SHARE keeps the course from being deleted until we commit
+	var exists int
+	err = tx.QueryRowContext(ctx, "SELECT 1 FROM courses WHERE id=$1 FOR SHARE", courseID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	enrollments := make([]models.Enrollment, len(studentIDs))
+	rows := make([][]interface{}, len(studentIDs))
+	for i, id := range studentIDs {
+		enrollments[i] = models.Enrollment{StudentID: id, CourseID: courseID}
+		rows[i] = []interface{}{id, courseID}
+	}
+
+	err = batchInsert(ctx, tx, "INSERT INTO enrollments (student_id, course_id) VALUES %s RETURNING enrolled_at", rows,
+		func(r *sql.Rows, i int) error { return r.Scan(&enrollments[i].EnrolledAt) })
+	if err != nil {
+		return nil, pgError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return enrollments, nil
+}
+
+// Unenroll ... all or nothing, if one student was not enrolled nothing is removed
+func (p *PostgresCourseStore) Unenroll(ctx context.Context, courseID int, studentIDs []int) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM enrollments WHERE course_id=$1 AND student_id = ANY($2)", courseID, pq.Array(studentIDs))
+	if err != nil {
+		return pgError(err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if int(n) != len(studentIDs) {
+		return ErrNotFound
+	}
+	return tx.Commit()
+}
+
+// Roster ...
+func (p *PostgresCourseStore) Roster(ctx context.Context, courseID int) ([]models.Student, error) {
+	if _, err := p.Get(ctx, courseID); err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT s.id, s.name, s.age, s.class, s.version
+		FROM enrollments e
+		JOIN students s ON s.id = e.student_id
+		WHERE e.course_id = $1
+		ORDER BY s.name, s.id`, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var students []models.Student
+	for rows.Next() {
+		var s models.Student
+		if err := rows.Scan(&s.ID, &s.Name, &s.Age, &s.Class, &s.Version); err != nil {
+			return nil, err
+		}
+		students = append(students, s)
+	}
+	return students, rows.Err()
+}
+
+// StudentCourses ...
+func (p *PostgresCourseStore) StudentCourses(ctx context.Context, studentID int) ([]models.Course, error) {
+	var exists int
+	err := p.db.QueryRowContext(ctx, "SELECT 1 FROM students WHERE id=$1", studentID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return queryCourses(ctx, p.db, `
+		SELECT c.id, c.code, c.name, c.description, c.teacher_id
+		FROM enrollments e
+		JOIN courses c ON c.id = e.course_id
+		WHERE e.student_id = $1
+		ORDER BY c.code`, studentID)
+}
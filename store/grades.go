@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+
+	"school_api_postgres/models"
+)
+
+// ExamSortFields ... the only fields an exam list may be sorted by
+var ExamSortFields = []string{"id", "name", "term", "date"}
+
+// ExamFilter ... narrows an exam list, zero values mean no filter
+type ExamFilter struct {
+	CourseID *int
+	Term     string
+}
+
+// GradeStore ... exams of a course and the scores students got in them
+type GradeStore interface {
+	ListExams(ctx context.Context, filter ExamFilter, opts ListOptions) ([]models.Exam, int, error)
+	GetExam(ctx context.Context, id int) (models.Exam, error)
+	// CreateExam returns ErrInvalidReference when the course does not exist
+	// and ErrConflict when the course already has an exam of that name in the term
+	CreateExam(ctx context.Context, e models.Exam) (models.Exam, error)
+	// DeleteExam ... removes the exam together with its grades
+	DeleteExam(ctx context.Context, id int) error
+
+	// RecordGrades stores (or corrects) the scores of one exam in a single transaction:
+	// ErrNotFound if the exam is missing, ErrInvalidReference if a student is not enrolled in its course
+	RecordGrades(ctx context.Context, examID int, grades []models.Grade) ([]models.Grade, error)
+	// Grades ... every grade of an exam ordered by student id, ErrNotFound if the exam is missing
+	Grades(ctx context.Context, examID int) ([]models.Grade, error)
+	// DeleteGrade ... ErrNotFound if the student has no grade in the exam
+	DeleteGrade(ctx context.Context, examID, studentID int) error
+
+	// ClassScores ... every grade of the students currently in the class, limited to one term unless term is empty
+	ClassScores(ctx context.Context, class int, term string) ([]models.ExamScore, error)
+}
+
+// ExamCursor ... the cursor pointing just after e for the given sort
+func ExamCursor(e models.Exam, sort []SortField) Cursor {
+	var cur Cursor
+	for _, f := range KeysetSort(sort) {
+		cur.Values = append(cur.Values, examFieldValue(e, f.Field))
+	}
+	return cur
+}
+
+func examFieldValue(e models.Exam, field string) interface{} {
+	switch field {
+	case "id":
+		return e.ID
+	case "name":
+		return e.Name
+	case "term":
+		return e.Term
+	case "date":
+		return e.Date.String()
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"school_api_postgres/models"
+)
+
+var _ GradeStore = (*MemoryGradeStore)(nil)
+
+// MemoryGradeStore ... GradeStore kept in maps, safe for concurrent use
+// courses and enrollments are checked against the given stores the way the foreign keys and joins do in Postgres
+type MemoryGradeStore struct {
+	mu     sync.RWMutex
+	exams  map[int]models.Exam
+	grades map[[2]int]models.Grade // key is {examID, studentID}
+	nextID int
+
+	students StudentStore
+	courses  CourseStore
+}
+
+// NewMemoryGradeStore ...
+func NewMemoryGradeStore(students StudentStore, courses CourseStore) *MemoryGradeStore {
+	return &MemoryGradeStore{
+		exams:    make(map[int]models.Exam),
+		grades:   make(map[[2]int]models.Grade),
+		nextID:   1,
+		students: students,
+		courses:  courses,
+	}
+}
+
+// ListExams ...
+func (m *MemoryGradeStore) ListExams(ctx context.Context, filter ExamFilter, opts ListOptions) ([]models.Exam, int, error) {
+	for _, f := range opts.Sort {
+		if _, ok := examColumns[f.Field]; !ok {
+			return nil, 0, fmt.Errorf("cannot sort by %q", f.Field)
+		}
+	}
+	keys := KeysetSort(opts.Sort)
+
+	m.mu.RLock()
+	var all []models.Exam
+	for _, e := range m.exams {
+		if filter.CourseID != nil && e.CourseID != *filter.CourseID {
+			continue
+		}
+		if filter.Term != "" && e.Term != filter.Term {
+			continue
+		}
+		all = append(all, e)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(all, func(i, j int) bool {
+		return compareKeys(ExamCursor(all[i], keys).Values, ExamCursor(all[j], keys).Values, keys) < 0
+	})
+
+	total := len(all)
+	if opts.NoCount {
+		total = 0
+	}
+	if opts.After != nil {
+		if len(opts.After.Values) != len(keys) {
+			return nil, 0, ErrInvalidCursor
+		}
+		start := sort.Search(len(all), func(i int) bool {
+			return compareKeys(ExamCursor(all[i], keys).Values, opts.After.Values, keys) > 0
+		})
+		page, err := paginate(all[start:], ListOptions{Limit: opts.Limit})
+		return page, total, err
+	}
+	page, err := paginate(all, opts)
+	return page, total, err
+}
+
+// GetExam ...
+func (m *MemoryGradeStore) GetExam(ctx context.Context, id int) (models.Exam, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	e, ok := m.exams[id]
+	if !ok {
+		return models.Exam{}, ErrNotFound
+	}
+	return e, nil
+}
+
+// CreateExam ...
+func (m *MemoryGradeStore) CreateExam(ctx context.Context, e models.Exam) (models.Exam, error) {
+	if _, err := m.courses.Get(ctx, e.CourseID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return e, constraintError(ErrInvalidReference, "exams_course_id_fkey")
+		}
+		return e, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, other := range m.exams {
+		if other.CourseID == e.CourseID && other.Term == e.Term && other.Name == e.Name {
+			return e, constraintError(ErrConflict, "exams_course_id_term_name_key")
+		}
+	}
+	e.ID = m.nextID
+	m.nextID++
+	m.exams[e.ID] = e
+	return e, nil
+}
+
+// DeleteExam ...
+func (m *MemoryGradeStore) DeleteExam(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.exams[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.exams, id)
+	for key := range m.grades {
+		if key[0] == id {
+			delete(m.grades, key)
+		}
+	}
+	return nil
+}
+
+// RecordGrades ...
+func (m *MemoryGradeStore) RecordGrades(ctx context.Context, examID int, grades []models.Grade) ([]models.Grade, error) {
+	e, err := m.GetExam(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+	roster, err := m.courses.Roster(ctx, e.CourseID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrNotFound // the course went away, and the exam with it
+	}
+	if err != nil {
+		return nil, err
+	}
+	enrolled := make(map[int]bool, len(roster))
+	for _, s := range roster {
+		enrolled[s.ID] = true
+	}
+	for _, g := range grades {
+		if !enrolled[g.StudentID] {
+			return nil, fmt.Errorf("%w: every student must be enrolled in course %d", ErrInvalidReference, e.CourseID)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.exams[examID]; !ok {
+		return nil, ErrNotFound
+	}
+	now := time.Now()
+	recorded := make([]models.Grade, len(grades))
+	for i, g := range grades {
+		g.ExamID = examID
+		g.UpdatedAt = now
+		m.grades[[2]int{examID, g.StudentID}] = g
+		recorded[i] = g
+	}
+	return recorded, nil
+}
+
+// Grades ...
+func (m *MemoryGradeStore) Grades(ctx context.Context, examID int) ([]models.Grade, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.exams[examID]; !ok {
+		return nil, ErrNotFound
+	}
+	var grades []models.Grade
+	for key, g := range m.grades {
+		if key[0] == examID {
+			grades = append(grades, g)
+		}
+	}
+	sort.Slice(grades, func(i, j int) bool { return grades[i].StudentID < grades[j].StudentID })
+	return grades, nil
+}
+
+// DeleteGrade ...
+func (m *MemoryGradeStore) DeleteGrade(ctx context.Context, examID, studentID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := [2]int{examID, studentID}
+	if _, ok := m.grades[key]; !ok {
+		return ErrNotFound
+	}
+	delete(m.grades, key)
+	return nil
+}
+
+// ClassScores ... grades of deleted students or courses are skipped, like the joins in Postgres would
+func (m *MemoryGradeStore) ClassScores(ctx context.Context, class int, term string) ([]models.ExamScore, error) {
+	students, _, err := m.students.List(ctx, StudentFilter{Class: &class}, ListOptions{NoCount: true})
+	if err != nil {
+		return nil, err
+	}
+	inClass := make(map[int]bool, len(students))
+	for _, s := range students {
+		inClass[s.ID] = true
+	}
+
+	m.mu.RLock()
+	var grades []models.Grade
+	exams := make(map[int]models.Exam)
+	for key, g := range m.grades {
+		e := m.exams[key[0]]
+		if inClass[key[1]] && (term == "" || e.Term == term) {
+			grades = append(grades, g)
+			exams[e.ID] = e
+		}
+	}
+	m.mu.RUnlock()
+
+	courses := make(map[int]models.Course)
+	for _, e := range exams {
+		if _, ok := courses[e.CourseID]; ok {
+			continue
+		}
+		c, err := m.courses.Get(ctx, e.CourseID)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		courses[e.CourseID] = c
+	}
+
+	var scores []models.ExamScore
+	for _, g := range grades {
+		e := exams[g.ExamID]
+		c, ok := courses[e.CourseID]
+		if !ok {
+			continue
+		}
+		scores = append(scores, models.ExamScore{
+			StudentID:  g.StudentID,
+			CourseID:   c.ID,
+			CourseCode: c.Code,
+			CourseName: c.Name,
+			Score:      g.Score,
+			MaxScore:   e.MaxScore,
+		})
+	}
+	return scores, nil
+}
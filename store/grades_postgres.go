@@ -0,0 +1,227 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"school_api_postgres/models"
+
+	"github.com/lib/pq"
+)
+
+var _ GradeStore = (*PostgresGradeStore)(nil)
+
+// PostgresGradeStore ... GradeStore backed by the exams and grades tables
+type PostgresGradeStore struct {
+	db *sql.DB
+}
+
+// NewPostgresGradeStore ...
+func NewPostgresGradeStore(db *sql.DB) *PostgresGradeStore {
+	return &PostgresGradeStore{db: db}
+}
+
+var examColumns = map[string]string{
+	"id":   "id",
+	"name": "name",
+	"term": "term",
+	"date": "date",
+}
+
+const examSelect = "SELECT id, course_id, name, term, max_score, date FROM exams"
+
+// ListExams ...
+func (p *PostgresGradeStore) ListExams(ctx context.Context, filter ExamFilter, opts ListOptions) ([]models.Exam, int, error) {
+	order, err := orderBy(opts.Sort, examColumns)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var where whereBuilder
+	if filter.CourseID != nil {
+		where.add("course_id = ?", *filter.CourseID)
+	}
+	if filter.Term != "" {
+		where.add("term = ?", filter.Term)
+	}
+
+	var total int
+	if !opts.NoCount {
+		if err := p.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM exams "+where.String(), where.args...).Scan(&total); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	offset := opts.Offset
+	if opts.After != nil {
+		if err := keysetAfter(&where, opts.Sort, opts.After, examColumns); err != nil {
+			return nil, 0, err
+		}
+		offset = 0
+	}
+
+	query := fmt.Sprintf("%s %s %s LIMIT %s OFFSET %s", examSelect, where.String(), order, where.arg(opts.Limit), where.arg(offset))
+	rows, err := p.db.QueryContext(ctx, query, where.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var exams []models.Exam
+	for rows.Next() {
+		var e models.Exam
+		if err := rows.Scan(&e.ID, &e.CourseID, &e.Name, &e.Term, &e.MaxScore, &e.Date); err != nil {
+			return nil, 0, err
+		}
+		exams = append(exams, e)
+	}
+	return exams, total, rows.Err()
+}
+
+// GetExam ...
+func (p *PostgresGradeStore) GetExam(ctx context.Context, id int) (models.Exam, error) {
+	var e models.Exam
+	err := p.db.QueryRowContext(ctx, examSelect+" WHERE id=$1", id).Scan(&e.ID, &e.CourseID, &e.Name, &e.Term, &e.MaxScore, &e.Date)
+	if errors.Is(err, sql.ErrNoRows) {
+		return e, ErrNotFound
+	}
+	return e, err
+}
+
+// CreateExam ...
+func (p *PostgresGradeStore) CreateExam(ctx context.Context, e models.Exam) (models.Exam, error) {
+	err := p.db.QueryRowContext(ctx, "INSERT INTO exams (course_id, name, term, max_score, date) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		e.CourseID, e.Name, e.Term, e.MaxScore, e.Date).Scan(&e.ID)
+	return e, pgError(err)
+}
+
+// DeleteExam ... grades go with it through ON DELETE CASCADE
+func (p *PostgresGradeStore) DeleteExam(ctx context.Context, id int) error {
+	result, err := p.db.ExecContext(ctx, "DELETE FROM exams WHERE id=$1", id)
+	if err != nil {
+		return pgError(err)
+	}
+	return expectRows(result)
+}
+
+// RecordGrades upserts the scores in batches the same way the student bulk insert does
+func (p *PostgresGradeStore) RecordGrades(ctx context.Context, examID int, grades []models.Grade) ([]models.Grade, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// FOR SHARE keeps the exam from being deleted until we commit
+	var courseID int
+	err = tx.QueryRowContext(ctx, "SELECT course_id FROM exams WHERE id=$1 FOR SHARE", examID).Scan(&courseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, len(grades))
+	for i, g := range grades {
+		ids[i] = g.StudentID
+	}
+	var enrolled int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM enrollments WHERE course_id=$1 AND student_id = ANY($2)", courseID, pq.Array(ids)).Scan(&enrolled)
+	if err != nil {
+		return nil, err
+	}
+	if enrolled != len(ids) {
+		return nil, fmt.Errorf("%w: every student must be enrolled in course %d", ErrInvalidReference, courseID)
+	}
+
+	recorded := make([]models.Grade, len(grades))
+	rows := make([][]interface{}, len(grades))
+	for i, g := range grades {
+		g.ExamID = examID
+		recorded[i] = g
+		rows[i] = []interface{}{examID, g.StudentID, g.Score}
+	}
+
+	// entering a score again corrects it
+	query := `
+		INSERT INTO grades (exam_id, student_id, score)
+		VALUES %s
+		ON CONFLICT (exam_id, student_id) DO UPDATE
+		SET score = EXCLUDED.score, updated_at = now()
+		RETURNING updated_at`
+	err = batchInsert(ctx, tx, query, rows, func(r *sql.Rows, i int) error { return r.Scan(&recorded[i].UpdatedAt) })
+	if err != nil {
+		return nil, pgError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return recorded, nil
+}
+
+// Grades ...
+func (p *PostgresGradeStore) Grades(ctx context.Context, examID int) ([]models.Grade, error) {
+	if _, err := p.GetExam(ctx, examID); err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db.QueryContext(ctx, "SELECT exam_id, student_id, score, updated_at FROM grades WHERE exam_id=$1 ORDER BY student_id", examID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var grades []models.Grade
+	for rows.Next() {
+		var g models.Grade
+		if err := rows.Scan(&g.ExamID, &g.StudentID, &g.Score, &g.UpdatedAt); err != nil {
+			return nil, err
+		}
+		grades = append(grades, g)
+	}
+	return grades, rows.Err()
+}
+
+// DeleteGrade ...
+func (p *PostgresGradeStore) DeleteGrade(ctx context.Context, examID, studentID int) error {
+	result, err := p.db.ExecContext(ctx, "DELETE FROM grades WHERE exam_id=$1 AND student_id=$2", examID, studentID)
+	if err != nil {
+		return pgError(err)
+	}
+	return expectRows(result)
+}
+
+// ClassScores ...
+func (p *PostgresGradeStore) ClassScores(ctx context.Context, class int, term string) ([]models.ExamScore, error) {
+	var where whereBuilder
+	where.add("s.class = ?", class)
+	if term != "" {
+		where.add("e.term = ?", term)
+	}
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT g.student_id, c.id, c.code, c.name, g.score, e.max_score
+		FROM grades g
+		JOIN students s ON s.id = g.student_id
+		JOIN exams e ON e.id = g.exam_id
+		JOIN courses c ON c.id = e.course_id
+		`+where.String(), where.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scores []models.ExamScore
+	for rows.Next() {
+		var sc models.ExamScore
+		if err := rows.Scan(&sc.StudentID, &sc.CourseID, &sc.CourseCode, &sc.CourseName, &sc.Score, &sc.MaxScore); err != nil {
+			return nil, err
+		}
+		scores = append(scores, sc)
+	}
+	return scores, rows.Err()
+}
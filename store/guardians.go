@@ -0,0 +1,24 @@
+package store
+
+import (
+	"context"
+
+	"school_api_postgres/models"
+)
+
+// GuardianStore ... guardians are always reached through one of their students
+type GuardianStore interface {
+	// StudentGuardians ... guardians of a student ordered by name, ErrNotFound if the student is missing
+	StudentGuardians(ctx context.Context, studentID int) ([]models.Guardian, error)
+	// Get ... ErrNotFound unless the guardian is linked to the student
+	Get(ctx context.Context, studentID, guardianID int) (models.Guardian, error)
+	// Create adds a new guardian linked to the student, ErrNotFound if the student is missing
+	Create(ctx context.Context, studentID int, g models.Guardian) (models.Guardian, error)
+	// Link adds an existing guardian (say of a sibling) to the student: ErrNotFound if the student is missing,
+	// ErrInvalidReference if the guardian is and ErrConflict if the two are already linked
+	Link(ctx context.Context, studentID, guardianID int) (models.Guardian, error)
+	// Update ... replaces the guardian's details, which every linked student sees, ErrNotFound unless linked to the student
+	Update(ctx context.Context, studentID, guardianID int, g models.Guardian) error
+	// Unlink removes the link only, the guardian stays for any other students, ErrNotFound if there was no link
+	Unlink(ctx context.Context, studentID, guardianID int) error
+}
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"school_api_postgres/models"
+)
+
+var _ GuardianStore = (*MemoryGuardianStore)(nil)
+
+// MemoryGuardianStore ... GuardianStore kept in maps, safe for concurrent use
+type MemoryGuardianStore struct {
+	mu        sync.RWMutex
+	guardians map[int]models.Guardian
+	links     map[[2]int]bool // key is {studentID, guardianID}
+	nextID    int
+
+	students StudentStore
+}
+
+// NewMemoryGuardianStore ...
+func NewMemoryGuardianStore(students StudentStore) *MemoryGuardianStore {
+	return &MemoryGuardianStore{
+		guardians: make(map[int]models.Guardian),
+		links:     make(map[[2]int]bool),
+		nextID:    1,
+		students:  students,
+	}
+}
+
+// StudentGuardians ...
+func (m *MemoryGuardianStore) StudentGuardians(ctx context.Context, studentID int) ([]models.Guardian, error) {
+	if _, err := m.students.Get(ctx, studentID); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	var guardians []models.Guardian
+	for key := range m.links {
+		if key[0] == studentID {
+			guardians = append(guardians, m.guardians[key[1]])
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(guardians, func(i, j int) bool {
+		if c := compareValues(guardians[i].Name, guardians[j].Name); c != 0 {
+			return c < 0
+		}
+		return guardians[i].ID < guardians[j].ID
+	})
+	return guardians, nil
+}
+
+// Get ...
+func (m *MemoryGuardianStore) Get(ctx context.Context, studentID, guardianID int) (models.Guardian, error) {
+	if _, err := m.students.Get(ctx, studentID); err != nil {
+		return models.Guardian{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.links[[2]int{studentID, guardianID}] {
+		return models.Guardian{}, ErrNotFound
+	}
+	return m.guardians[guardianID], nil
+}
+
+// Create ...
+func (m *MemoryGuardianStore) Create(ctx context.Context, studentID int, g models.Guardian) (models.Guardian, error) {
+	if _, err := m.students.Get(ctx, studentID); err != nil {
+		return g, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	g.ID = m.nextID
+	m.nextID++
+	m.guardians[g.ID] = g
+	m.links[[2]int{studentID, g.ID}] = true
+	return g, nil
+}
+
+// Link ...
+func (m *MemoryGuardianStore) Link(ctx context.Context, studentID, guardianID int) (models.Guardian, error) {
+	if _, err := m.students.Get(ctx, studentID); err != nil {
+		return models.Guardian{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	g, ok := m.guardians[guardianID]
+	if !ok {
+		return g, constraintError(ErrInvalidReference, "student_guardians_guardian_id_fkey")
+	}
+	key := [2]int{studentID, guardianID}
+	if m.links[key] {
+		return g, constraintError(ErrConflict, "student_guardians_pkey")
+	}
+	m.links[key] = true
+	return g, nil
+}
+
+// Update ...
+func (m *MemoryGuardianStore) Update(ctx context.Context, studentID, guardianID int, g models.Guardian) error {
+	// links of a deleted student are gone in Postgres through ON DELETE CASCADE
+	if _, err := m.students.Get(ctx, studentID); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.links[[2]int{studentID, guardianID}] {
+		return ErrNotFound
+	}
+	g.ID = guardianID
+	m.guardians[guardianID] = g
+	return nil
+}
+
+// Unlink ...
+func (m *MemoryGuardianStore) Unlink(ctx context.Context, studentID, guardianID int) error {
+	if _, err := m.students.Get(ctx, studentID); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := [2]int{studentID, guardianID}
+	if !m.links[key] {
+		return ErrNotFound
+	}
+	delete(m.links, key)
+	return nil
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"school_api_postgres/models"
+)
+
+var _ GuardianStore = (*PostgresGuardianStore)(nil)
+
+// PostgresGuardianStore ... GuardianStore backed by the guardians and student_guardians tables
+type PostgresGuardianStore struct {
+	db *sql.DB
+}
+
+// NewPostgresGuardianStore ...
+func NewPostgresGuardianStore(db *sql.DB) *PostgresGuardianStore {
+	return &PostgresGuardianStore{db: db}
+}
+
+const guardianSelect = `
+	SELECT g.id, g.name, g.relationship, g.phone, g.email, g.address
+	FROM guardians g
+	JOIN student_guardians sg ON sg.guardian_id = g.id`
+
+// scanGuardian ... works for *sql.Row and *sql.Rows
+func scanGuardian(row interface{ Scan(...interface{}) error }) (models.Guardian, error) {
+	var g models.Guardian
+	err := row.Scan(&g.ID, &g.Name, &g.Relationship, &g.Phone, &g.Email, &g.Address)
+	return g, err
+}
+
+// StudentGuardians ...
+func (p *PostgresGuardianStore) StudentGuardians(ctx context.Context, studentID int) ([]models.Guardian, error) {
+	if err := studentExists(ctx, p.db, studentID); err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db.QueryContext(ctx, guardianSelect+" WHERE sg.student_id = $1 ORDER BY g.name, g.id", studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var guardians []models.Guardian
+	for rows.Next() {
+		g, err := scanGuardian(rows)
+		if err != nil {
+			return nil, err
+		}
+		guardians = append(guardians, g)
+	}
+	return guardians, rows.Err()
+}
+
+// Get ...
+func (p *PostgresGuardianStore) Get(ctx context.Context, studentID, guardianID int) (models.Guardian, error) {
+	g, err := scanGuardian(p.db.QueryRowContext(ctx, guardianSelect+" WHERE sg.student_id = $1 AND g.id = $2", studentID, guardianID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return g, ErrNotFound
+	}
+	return g, err
+}
+
+// Create inserts the guardian and the link in one transaction
+func (p *PostgresGuardianStore) Create(ctx context.Context, studentID int, g models.Guardian) (models.Guardian, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return g, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// FOR SHARE keeps the student from being deleted until we commit
+	var exists int
+	err = tx.QueryRowContext(ctx, "SELECT 1 FROM students WHERE id=$1 FOR SHARE", studentID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return g, ErrNotFound
+	}
+	if err != nil {
+		return g, err
+	}
+
+	err = tx.QueryRowContext(ctx, "INSERT INTO guardians (name, relationship, phone, email, address) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		g.Name, g.Relationship, g.Phone, g.Email, g.Address).Scan(&g.ID)
+	if err != nil {
+		return g, pgError(err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO student_guardians (student_id, guardian_id) VALUES ($1, $2)", studentID, g.ID); err != nil {
+		return g, pgError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return g, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return g, nil
+}
+
+// Link ... the primary key turns a repeat into ErrConflict and the foreign key a missing guardian into ErrInvalidReference
+func (p *PostgresGuardianStore) Link(ctx context.Context, studentID, guardianID int) (models.Guardian, error) {
+	if err := studentExists(ctx, p.db, studentID); err != nil {
+		return models.Guardian{}, err
+	}
+	_, err := p.db.ExecContext(ctx, "INSERT INTO student_guardians (student_id, guardian_id) VALUES ($1, $2)", studentID, guardianID)
+	if err != nil {
+		return models.Guardian{}, pgError(err)
+	}
+	return p.Get(ctx, studentID, guardianID)
+}
+
+// Update ...
+func (p *PostgresGuardianStore) Update(ctx context.Context, studentID, guardianID int, g models.Guardian) error {
+	result, err := p.db.ExecContext(ctx, `
+		UPDATE guardians SET name=$1, relationship=$2, phone=$3, email=$4, address=$5
+		WHERE id=$6 AND EXISTS (SELECT 1 FROM student_guardians WHERE student_id=$7 AND guardian_id=$6)`,
+		g.Name, g.Relationship, g.Phone, g.Email, g.Address, guardianID, studentID)
+	if err != nil {
+		return pgError(err)
+	}
+	return expectRows(result)
+}
+
+// Unlink ...
+func (p *PostgresGuardianStore) Unlink(ctx context.Context, studentID, guardianID int) error {
+	result, err := p.db.ExecContext(ctx, "DELETE FROM student_guardians WHERE student_id=$1 AND guardian_id=$2", studentID, guardianID)
+	if err != nil {
+		return pgError(err)
+	}
+	return expectRows(result)
+}
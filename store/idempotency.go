@@ -0,0 +1,39 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrKeyReused ... the Idempotency-Key was first used for a different request
+	ErrKeyReused = errors.New("idempotency key was already used for a different request")
+	// ErrKeyInProgress ... the first request with the key has not finished yet
+	ErrKeyInProgress = errors.New("a request with this idempotency key is still being processed")
+)
+
+// SavedResponse ... what was sent for the first request with a key, replayed for every retry
+type SavedResponse struct {
+	Status      int
+	ContentType string
+	Location    string
+	ETag        string
+	Body        []byte
+}
+
+// IdempotencyStore ... remembers Idempotency-Keys and the responses they produced until they expire. Keys belong to
+// the principal that sent them, the subject of auth.Principal, the same key from another principal is a different key
+type IdempotencyStore interface {
+	// Claim takes the key for a request with the given fingerprint. A nil response means the key is new,
+	// or had expired, and the caller should run the request and then call Complete or Release.
+	// If the key was used before its saved response comes back, unless the fingerprint differs (ErrKeyReused)
+	// or the first request is still running (ErrKeyInProgress)
+	Claim(ctx context.Context, principal, key, fingerprint string, ttl time.Duration) (*SavedResponse, error)
+	// Complete saves the response of a claimed key
+	Complete(ctx context.Context, principal, key string, resp SavedResponse) error
+	// Release gives up a claimed key without a response, so a retry runs the request again
+	Release(ctx context.Context, principal, key string) error
+	// DeleteExpired removes expired keys and returns how many there were
+	DeleteExpired(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ IdempotencyStore = (*MemoryIdempotencyStore)(nil)
+
+// MemoryIdempotencyStore ... IdempotencyStore kept in a map, only good for a single instance
+type MemoryIdempotencyStore struct {
+	mu   sync.Mutex
+	keys map[principalKey]*idempotencyKey
+}
+
+// principalKey ... keys are kept per principal, like the primary key of idempotency_keys
+type principalKey struct {
+	principal, key string
+}
+
+type idempotencyKey struct {
+	fingerprint string
+	response    *SavedResponse
+	expiresAt   time.Time
+}
+
+// NewMemoryIdempotencyStore ...
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{keys: make(map[principalKey]*idempotencyKey)}
+}
+
+// Claim ...
+func (m *MemoryIdempotencyStore) Claim(ctx context.Context, principal, key, fingerprint string, ttl time.Duration) (*SavedResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := principalKey{principal, key}
+	k, ok := m.keys[id]
+	if !ok || time.Now().After(k.expiresAt) {
+		m.keys[id] = &idempotencyKey{fingerprint: fingerprint, expiresAt: time.Now().Add(ttl)}
+		return nil, nil
+	}
+	if k.fingerprint != fingerprint {
+		return nil, ErrKeyReused
+	}
+	if k.response == nil {
+		return nil, ErrKeyInProgress
+	}
+	saved := *k.response
+	return &saved, nil
+}
+
+// Complete ...
+func (m *MemoryIdempotencyStore) Complete(ctx context.Context, principal, key string, resp SavedResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if k, ok := m.keys[principalKey{principal, key}]; ok {
+		resp.Body = append([]byte(nil), resp.Body...)
+		k.response = &resp
+	}
+	return nil
+}
+
+// Release ...
+func (m *MemoryIdempotencyStore) Release(ctx context.Context, principal, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := principalKey{principal, key}
+	if k, ok := m.keys[id]; ok && k.response == nil {
+		delete(m.keys, id)
+	}
+	return nil
+}
+
+// DeleteExpired ...
+func (m *MemoryIdempotencyStore) DeleteExpired(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	for id, k := range m.keys {
+		if time.Now().After(k.expiresAt) {
+			delete(m.keys, id)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var _ IdempotencyStore = (*PostgresIdempotencyStore)(nil)
+
+// PostgresIdempotencyStore ... IdempotencyStore backed by the idempotency_keys table, shared by every replica
+type PostgresIdempotencyStore struct {
+	db *sql.DB
+}
+
+// NewPostgresIdempotencyStore ...
+func NewPostgresIdempotencyStore(db *sql.DB) *PostgresIdempotencyStore {
+	return &PostgresIdempotencyStore{db: db}
+}
+
+// Claim ... the insert is the lock: of two concurrent requests with the same key only one gets a row back,
+// an expired key is taken over by the same statement
+func (p *PostgresIdempotencyStore) Claim(ctx context.Context, principal, key, fingerprint string, ttl time.Duration) (*SavedResponse, error) {
+	var claimed string
+	err := p.db.QueryRowContext(ctx, `
+		INSERT INTO idempotency_keys (principal, key, fingerprint, expires_at)
+		VALUES ($1, $2, $3, now() + $4::float8 * interval '1 millisecond')
+		ON CONFLICT (principal, key) DO UPDATE
+			SET fingerprint = EXCLUDED.fingerprint, status = NULL, content_type = '', location = '', etag = '', body = NULL,
+			    created_at = now(), expires_at = EXCLUDED.expires_at
+			WHERE idempotency_keys.expires_at < now()
+		RETURNING key`, principal, key, fingerprint, ttl.Milliseconds()).Scan(&claimed)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	// somebody else holds the key
+	var saved SavedResponse
+	var stored string
+	var status sql.NullInt64
+	err = p.db.QueryRowContext(ctx, "SELECT fingerprint, status, content_type, location, etag, body FROM idempotency_keys WHERE principal=$1 AND key=$2",
+		principal, key).
+		Scan(&stored, &status, &saved.ContentType, &saved.Location, &saved.ETag, &saved.Body)
+	if errors.Is(err, sql.ErrNoRows) {
+		// released between the two statements, the client can simply retry
+		return nil, ErrKeyInProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stored != fingerprint {
+		return nil, ErrKeyReused
+	}
+	if !status.Valid {
+		return nil, ErrKeyInProgress
+	}
+	saved.Status = int(status.Int64)
+	return &saved, nil
+}
+
+// Complete ...
+func (p *PostgresIdempotencyStore) Complete(ctx context.Context, principal, key string, resp SavedResponse) error {
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE idempotency_keys SET status=$1, content_type=$2, location=$3, etag=$4, body=$5
+		WHERE principal=$6 AND key=$7`, resp.Status, resp.ContentType, resp.Location, resp.ETag, resp.Body, principal, key)
+	return err
+}
+
+// Release ...
+func (p *PostgresIdempotencyStore) Release(ctx context.Context, principal, key string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE principal=$1 AND key=$2 AND status IS NULL", principal, key)
+	return err
+}
+
+// DeleteExpired ...
+func (p *PostgresIdempotencyStore) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := p.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at < now()")
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"cmp"
+	"strings"
+)
+
+// helpers shared by the in-memory stores
+
+// paginate ... applies Offset and Limit to an already filtered and sorted slice, a negative Offset is refused
+// like Postgres refuses it
+func paginate[T any](all []T, opts ListOptions) ([]T, error) {
+	if opts.Offset < 0 {
+		return nil, ErrInvalidOffset
+	}
+	if opts.Offset >= len(all) {
+		return nil, nil
+	}
+	end := len(all)
+	if opts.Limit > 0 && opts.Limit < end-opts.Offset {
+		end = opts.Offset + opts.Limit
+	}
+	return all[opts.Offset:end], nil
+}
+
+// compareKeys compares two sort keys field by field honoring each direction, -1, 0 or 1
+func compareKeys(a, b []interface{}, keys []SortField) int {
+	for i, f := range keys {
+		c := compareValues(a[i], b[i])
+		if c == 0 {
+			continue
+		}
+		if f.Desc {
+			return -c
+		}
+		return c
+	}
+	return 0
+}
+
+// compareValues orders two key values, numbers may arrive as int, int64 or float64 after a cursor round trip
+// Postgres compares text with the database collation, lower-casing is close enough here
+func compareValues(a, b interface{}) int {
+	if x, ok := toFloat(a); ok {
+		if y, ok := toFloat(b); ok {
+			return cmp.Compare(x, y)
+		}
+	}
+	as, _ := a.(string)
+	bs, _ := b.(string)
+	return strings.Compare(strings.ToLower(as), strings.ToLower(bs))
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// whereBuilder collects AND-ed conditions and their positional arguments
+type whereBuilder struct {
+	conds []string
+	args  []interface{}
+}
+
+// add appends a condition, each ? in cond is replaced by the next $n placeholder
+func (b *whereBuilder) add(cond string, args ...interface{}) {
+	for _, a := range args {
+		b.args = append(b.args, a)
+		cond = strings.Replace(cond, "?", fmt.Sprintf("$%d", len(b.args)), 1)
+	}
+	b.conds = append(b.conds, cond)
+}
+
+// arg adds a bare argument (LIMIT, OFFSET ...) and returns its placeholder
+func (b *whereBuilder) arg(a interface{}) string {
+	b.args = append(b.args, a)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+// String ... "WHERE a AND b" or "" when there are no conditions
+func (b *whereBuilder) String() string {
+	if len(b.conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(b.conds, " AND ")
+}
+
+// KeysetSort ... the sort actually applied: the requested fields plus id as a tie-breaker unless already present
+func KeysetSort(sort []SortField) []SortField {
+	for _, f := range sort {
+		if f.Field == "id" {
+			return sort
+		}
+	}
+	return append(append([]SortField(nil), sort...), SortField{Field: "id"})
+}
+
+// orderBy turns sort fields into an ORDER BY clause, columns maps an API field to its SQL column
+// unknown fields are an error rather than being pasted into the query
+func orderBy(sort []SortField, columns map[string]string) (string, error) {
+	var parts []string
+	for _, f := range KeysetSort(sort) {
+		col, ok := columns[f.Field]
+		if !ok {
+			return "", fmt.Errorf("cannot sort by %q", f.Field)
+		}
+		dir := "ASC"
+		if f.Desc {
+			dir = "DESC"
+		}
+		parts = append(parts, col+" "+dir)
+	}
+	return "ORDER BY " + strings.Join(parts, ", "), nil
+}
+
+// keysetAfter adds the condition "row comes after the cursor in this sort order", for a sort (a ASC, b DESC) that is
+// (a > $1) OR (a = $2 AND b < $3), which works for any mix of directions unlike a row-value comparison
+func keysetAfter(where *whereBuilder, sort []SortField, after *Cursor, columns map[string]string) error {
+	if after == nil {
+		return nil
+	}
+	keys := KeysetSort(sort)
+	if len(after.Values) != len(keys) {
+		return ErrInvalidCursor
+	}
+
+	var ors []string
+	for i, f := range keys {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, columns[keys[j].Field]+" = "+where.arg(after.Values[j]))
+		}
+		op := " > "
+		if f.Desc {
+			op = " < "
+		}
+		col, ok := columns[f.Field]
+		if !ok {
+			return fmt.Errorf("cannot sort by %q", f.Field)
+		}
+		ands = append(ands, col+op+where.arg(after.Values[i]))
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	where.conds = append(where.conds, "("+strings.Join(ors, " OR ")+")")
+	return nil
+}
+
+// likeEscaper ... escapes LIKE wildcards so user input is matched literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
@@ -0,0 +1,141 @@
+// Package store hides the persistence layer behind small interfaces so the
+// handlers can run against Postgres in production and an in-memory
+// implementation when no database is around.
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Stores ... one implementation of every store, what the handlers are built from
+type Stores struct {
+	Students   StudentStore
+	Teachers   TeacherStore
+	Courses    CourseStore
+	Attendance AttendanceStore
+	Grades     GradeStore
+	Guardians  GuardianStore
+	// Idempotency ... Idempotency-Keys of create requests and the responses they produced
+	Idempotency IdempotencyStore
+	APIKeys     APIKeyStore
+	// Users and RefreshTokens ... staff accounts and the refresh tokens of their logins
+	Users         UserStore
+	RefreshTokens RefreshTokenStore
+}
+
+// NewPostgresStores ... every store backed by the same database
+func NewPostgresStores(db *sql.DB) Stores {
+	return Stores{
+		Students:      NewPostgresStudentStore(db),
+		Teachers:      NewPostgresTeacherStore(db),
+		Courses:       NewPostgresCourseStore(db),
+		Attendance:    NewPostgresAttendanceStore(db),
+		Grades:        NewPostgresGradeStore(db),
+		Guardians:     NewPostgresGuardianStore(db),
+		Idempotency:   NewPostgresIdempotencyStore(db),
+		APIKeys:       NewPostgresAPIKeyStore(db),
+		Users:         NewPostgresUserStore(db),
+		RefreshTokens: NewPostgresRefreshTokenStore(db),
+	}
+}
+
+// NewMemoryStores ... every store kept in memory, nothing survives a restart
+func NewMemoryStores() Stores {
+	students := NewMemoryStudentStore()
+	teachers := NewMemoryTeacherStore()
+	courses := NewMemoryCourseStore(students, teachers)
+	return Stores{
+		Students:      students,
+		Teachers:      teachers,
+		Courses:       courses,
+		Attendance:    NewMemoryAttendanceStore(students),
+		Grades:        NewMemoryGradeStore(students, courses),
+		Guardians:     NewMemoryGuardianStore(students),
+		Idempotency:   NewMemoryIdempotencyStore(),
+		APIKeys:       NewMemoryAPIKeyStore(),
+		Users:         NewMemoryUserStore(),
+		RefreshTokens: NewMemoryRefreshTokenStore(),
+	}
+}
+
+var (
+	// ErrNotFound is returned when the requested row does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidCursor ... the cursor does not line up with the requested sort
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrInvalidOffset ... ListOptions.Offset is negative
+	ErrInvalidOffset = errors.New("offset must not be negative")
+	// ErrConflict ... a unique constraint rejected the write
+	ErrConflict = errors.New("already exists")
+	// ErrInvalidReference ... a foreign key points at a row that does not exist
+	ErrInvalidReference = errors.New("references a row that does not exist")
+	// ErrInUse ... the row cannot be deleted while other rows still reference it
+	ErrInUse = errors.New("is still referenced by other rows")
+	// ErrCheckViolation ... a CHECK constraint rejected the values
+	ErrCheckViolation = errors.New("has a value the database does not accept")
+	// ErrVersionMismatch ... the row was changed since the client read it, the version it sent is stale
+	ErrVersionMismatch = errors.New("was changed by someone else")
+)
+
+// ConstraintError ... one of the errors above caused by a named database constraint
+// Error leaves the constraint out so the message is safe to show to clients, log Constraint instead
+type ConstraintError struct {
+	Err        error
+	Constraint string
+}
+
+func (e *ConstraintError) Error() string { return e.Err.Error() }
+
+// Unwrap ... errors.Is(err, ErrConflict) and friends see through it
+func (e *ConstraintError) Unwrap() error { return e.Err }
+
+func constraintError(err error, constraint string) error {
+	return &ConstraintError{Err: err, Constraint: constraint}
+}
+
+// pgError translates the driver errors callers care about into the store errors above
+func pgError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Code {
+	case "23505": // unique_violation
+		return constraintError(ErrConflict, pqErr.Constraint)
+	case "23503": // foreign_key_violation, raised for the referencing insert as well as the referenced delete
+		if strings.Contains(pqErr.Detail, "is still referenced") {
+			return constraintError(ErrInUse, pqErr.Constraint)
+		}
+		return constraintError(ErrInvalidReference, pqErr.Constraint)
+	case "23514": // check_violation
+		return constraintError(ErrCheckViolation, pqErr.Constraint)
+	}
+	return err
+}
+
+// ListOptions ... controls which slice of rows a List call returns and in which order
+type ListOptions struct {
+	Limit  int
+	Offset int
+	// Sort is applied in order, id is always added last as a tie-breaker so paging is deterministic
+	Sort []SortField
+	// After switches to keyset pagination: only rows sorting after the cursor are returned and Offset is ignored
+	After *Cursor
+	// NoCount skips the COUNT query, List then reports a total of 0
+	NoCount bool
+}
+
+// Cursor ... the sort key of the last row a client has seen, Values line up with KeysetSort(opts.Sort)
+type Cursor struct {
+	Values []interface{}
+}
+
+// SortField ... one column of a multi-column sort
+type SortField struct {
+	Field string
+	Desc  bool
+}
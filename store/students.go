@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+
+	"school_api_postgres/models"
+)
+
+// StudentSortFields ... the only fields a student list may be sorted by
+var StudentSortFields = []string{"id", "name", "age", "class"}
+
+// StudentFilter ... narrows a student list, zero values mean no filter
+type StudentFilter struct {
+	Class  *int
+	AgeMin *int
+	AgeMax *int
+	// NameContains and NamePrefix are matched case-insensitively
+	NameContains string
+	NamePrefix   string
+}
+
+// StudentStore ... everything the student handlers need from storage
+type StudentStore interface {
+	// List returns one page of students and the total number matching the filter
+	List(ctx context.Context, filter StudentFilter, opts ListOptions) ([]models.Student, int, error)
+	Get(ctx context.Context, id int) (models.Student, error)
+	Create(ctx context.Context, s models.Student) (models.Student, error)
+	CreateBatch(ctx context.Context, students []models.Student) ([]models.Student, error)
+	// Update, Modify and Delete only go ahead if the student is still at version, ErrVersionMismatch otherwise
+	// version 0 skips the check, every write bumps the version
+	Update(ctx context.Context, id int, s models.Student, version int) (models.Student, error)
+	// Modify locks the student, lets fn change it and saves the result in the same transaction
+	// an error from fn leaves the row untouched and is returned as it is
+	Modify(ctx context.Context, id int, version int, fn func(*models.Student) error) (models.Student, error)
+	Delete(ctx context.Context, id int, version int) error
+}
+
+// StudentCursor ... the cursor pointing just after s for the given sort
+func StudentCursor(s models.Student, sort []SortField) Cursor {
+	var c Cursor
+	for _, f := range KeysetSort(sort) {
+		c.Values = append(c.Values, studentFieldValue(s, f.Field))
+	}
+	return c
+}
+
+// studentFieldValue ... the value of a sortable field
+func studentFieldValue(s models.Student, field string) interface{} {
+	switch field {
+	case "id":
+		return s.ID
+	case "name":
+		return s.Name
+	case "age":
+		return s.Age
+	case "class":
+		return s.Class
+	}
+	return nil
+}
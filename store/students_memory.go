@@ -0,0 +1,195 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"school_api_postgres/models"
+)
+
+var _ StudentStore = (*MemoryStudentStore)(nil)
+
+// MemoryStudentStore ... StudentStore kept in a map, safe for concurrent use
+type MemoryStudentStore struct {
+	mu       sync.RWMutex
+	students map[int]models.Student
+	nextID   int
+}
+
+// NewMemoryStudentStore ...
+func NewMemoryStudentStore() *MemoryStudentStore {
+	return &MemoryStudentStore{
+		students: make(map[int]models.Student),
+		nextID:   1,
+	}
+}
+
+// List filters and sorts a snapshot of the map the same way the Postgres query does
+func (m *MemoryStudentStore) List(ctx context.Context, filter StudentFilter, opts ListOptions) ([]models.Student, int, error) {
+	less, err := studentLess(opts.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	m.mu.RLock()
+	all := make([]models.Student, 0, len(m.students))
+	for _, s := range m.students {
+		if studentMatches(s, filter) {
+			all = append(all, s)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(all, func(i, j int) bool { return less(all[i], all[j]) })
+
+	total := len(all)
+	if opts.NoCount {
+		total = 0
+	}
+
+	if opts.After != nil {
+		keys := KeysetSort(opts.Sort)
+		if len(opts.After.Values) != len(keys) {
+			return nil, 0, ErrInvalidCursor
+		}
+		// everything up to and including the cursor position was already seen
+		start := sort.Search(len(all), func(i int) bool {
+			return compareKeys(StudentCursor(all[i], keys).Values, opts.After.Values, keys) > 0
+		})
+		page, err := paginate(all[start:], ListOptions{Limit: opts.Limit})
+		return page, total, err
+	}
+	page, err := paginate(all, opts)
+	return page, total, err
+}
+
+// studentMatches ... in-memory version of studentWhere
+func studentMatches(s models.Student, filter StudentFilter) bool {
+	if filter.Class != nil && s.Class != *filter.Class {
+		return false
+	}
+	if filter.AgeMin != nil && s.Age < *filter.AgeMin {
+		return false
+	}
+	if filter.AgeMax != nil && s.Age > *filter.AgeMax {
+		return false
+	}
+	name := strings.ToLower(s.Name)
+	if filter.NameContains != "" && !strings.Contains(name, strings.ToLower(filter.NameContains)) {
+		return false
+	}
+	if filter.NamePrefix != "" && !strings.HasPrefix(name, strings.ToLower(filter.NamePrefix)) {
+		return false
+	}
+	return true
+}
+
+// studentLess builds a comparator for the sort fields with id as the final tie-breaker
+func studentLess(fields []SortField) (func(a, b models.Student) bool, error) {
+	for _, f := range fields {
+		if _, ok := studentColumns[f.Field]; !ok {
+			return nil, fmt.Errorf("cannot sort by %q", f.Field)
+		}
+	}
+	keys := KeysetSort(fields)
+	return func(a, b models.Student) bool {
+		return compareKeys(StudentCursor(a, keys).Values, StudentCursor(b, keys).Values, keys) < 0
+	}, nil
+}
+
+// Get ...
+func (m *MemoryStudentStore) Get(ctx context.Context, id int) (models.Student, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.students[id]
+	if !ok {
+		return models.Student{}, ErrNotFound
+	}
+	return s, nil
+}
+
+// Create ...
+func (m *MemoryStudentStore) Create(ctx context.Context, s models.Student) (models.Student, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s.ID, s.Version = m.nextID, 1
+	m.nextID++
+	m.students[s.ID] = s
+	return s, nil
+}
+
+// CreateBatch ... all or nothing like the Postgres transaction, which is trivial here since nothing can fail
+func (m *MemoryStudentStore) CreateBatch(ctx context.Context, students []models.Student) ([]models.Student, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inserted := make([]models.Student, 0, len(students))
+	for _, s := range students {
+		s.ID, s.Version = m.nextID, 1
+		m.nextID++
+		m.students[s.ID] = s
+		inserted = append(inserted, s)
+	}
+	return inserted, nil
+}
+
+// Update ...
+func (m *MemoryStudentStore) Update(ctx context.Context, id int, s models.Student, version int) (models.Student, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, err := m.atVersion(id, version)
+	if err != nil {
+		return s, err
+	}
+	s.ID, s.Version = id, current.Version+1
+	m.students[id] = s
+	return s, nil
+}
+
+// atVersion ... the stored student if it passes the version check, the caller holds the lock
+func (m *MemoryStudentStore) atVersion(id int, version int) (models.Student, error) {
+	s, ok := m.students[id]
+	if !ok {
+		return s, ErrNotFound
+	}
+	if version != 0 && s.Version != version {
+		return s, ErrVersionMismatch
+	}
+	return s, nil
+}
+
+// Modify ... the write lock is held while fn runs
+func (m *MemoryStudentStore) Modify(ctx context.Context, id int, version int, fn func(*models.Student) error) (models.Student, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, err := m.atVersion(id, version)
+	if err != nil {
+		return s, err
+	}
+	current := s.Version
+	if err := fn(&s); err != nil {
+		return s, err
+	}
+	s.ID, s.Version = id, current+1
+	m.students[id] = s
+	return s, nil
+}
+
+// Delete ...
+func (m *MemoryStudentStore) Delete(ctx context.Context, id int, version int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.atVersion(id, version); err != nil {
+		return err
+	}
+	delete(m.students, id)
+	return nil
+}
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"school_api_postgres/models"
+)
+
+func TestMemoryStudentStoreCRUD(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStudentStore()
+
+	s, err := m.Create(ctx, models.Student{Name: "Rahim", Age: 12, Class: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != 1 || s.Version != 1 {
+		t.Fatalf("created %+v, want id 1 at version 1", s)
+	}
+
+	got, err := m.Get(ctx, s.ID)
+	if err != nil || got != s {
+		t.Fatalf("Get = %+v, %v, want %+v", got, err, s)
+	}
+
+	s.Age = 13
+	updated, err := m.Update(ctx, s.ID, s, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Age != 13 || updated.Version != 2 {
+		t.Fatalf("updated %+v, want age 13 at version 2", updated)
+	}
+
+	if err := m.Delete(ctx, s.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Get(ctx, s.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete = %v, want ErrNotFound", err)
+	}
+	if err := m.Delete(ctx, s.ID, 0); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("second Delete = %v, want ErrNotFound", err)
+	}
+	if _, err := m.Update(ctx, 99, s, 0); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Update of a missing student = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryStudentStoreList(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStudentStore()
+	_, err := m.CreateBatch(ctx, []models.Student{
+		{Name: "Rahim", Age: 12, Class: 6},
+		{Name: "Karim", Age: 14, Class: 8},
+		{Name: "Rahima", Age: 13, Class: 6},
+		{Name: "Sadia", Age: 15, Class: 9},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	six, thirteen := 6, 13
+
+	tests := []struct {
+		name      string
+		filter    StudentFilter
+		opts      ListOptions
+		wantNames []string
+		wantTotal int
+	}{
+		{"all by id", StudentFilter{}, ListOptions{}, []string{"Rahim", "Karim", "Rahima", "Sadia"}, 4},
+		{"class", StudentFilter{Class: &six}, ListOptions{}, []string{"Rahim", "Rahima"}, 2},
+		{"age_min", StudentFilter{AgeMin: &thirteen}, ListOptions{}, []string{"Karim", "Rahima", "Sadia"}, 3},
+		{"name contains, any case", StudentFilter{NameContains: "RIM"}, ListOptions{}, []string{"Karim"}, 1},
+		{"name prefix", StudentFilter{NamePrefix: "rah"}, ListOptions{}, []string{"Rahim", "Rahima"}, 2},
+		{"sorted by age desc", StudentFilter{}, ListOptions{Sort: []SortField{{Field: "age", Desc: true}}},
+			[]string{"Sadia", "Karim", "Rahima", "Rahim"}, 4},
+		{"second page", StudentFilter{}, ListOptions{Limit: 2, Offset: 2}, []string{"Rahima", "Sadia"}, 4},
+		{"past the end", StudentFilter{}, ListOptions{Limit: 2, Offset: 10}, nil, 4},
+		{"limit beyond the largest int", StudentFilter{}, ListOptions{Limit: int(^uint(0) >> 1), Offset: 3}, []string{"Sadia"}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			students, total, err := m.List(ctx, tt.filter, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, s := range students {
+				names = append(names, s.Name)
+			}
+			if !equalStrings(names, tt.wantNames) || total != tt.wantTotal {
+				t.Errorf("List = %v (total %d), want %v (total %d)", names, total, tt.wantNames, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestMemoryStudentStoreNegativeOffset(t *testing.T) {
+	m := NewMemoryStudentStore()
+	m.Create(context.Background(), models.Student{Name: "Rahim", Age: 12, Class: 6})
+	if _, _, err := m.List(context.Background(), StudentFilter{}, ListOptions{Limit: 2, Offset: -2}); !errors.Is(err, ErrInvalidOffset) {
+		t.Errorf("negative offset: err = %v, want ErrInvalidOffset", err)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
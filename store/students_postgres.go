@@ -0,0 +1,233 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"school_api_postgres/models"
+)
+
+var _ StudentStore = (*PostgresStudentStore)(nil)
+
+// PostgresStudentStore ... StudentStore backed by the students table
+type PostgresStudentStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStudentStore ...
+func NewPostgresStudentStore(db *sql.DB) *PostgresStudentStore {
+	return &PostgresStudentStore{db: db}
+}
+
+// studentColumns ... API sort field -> SQL column, anything not here cannot reach the query
+var studentColumns = map[string]string{
+	"id":    "id",
+	"name":  "name",
+	"age":   "age",
+	"class": "class",
+}
+
+// List compiles the filter into a parameterized WHERE clause, the total comes from a COUNT with the same conditions
+// with opts.After set it pages by keyset instead of OFFSET
+func (p *PostgresStudentStore) List(ctx context.Context, filter StudentFilter, opts ListOptions) ([]models.Student, int, error) {
+	order, err := orderBy(opts.Sort, studentColumns)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var where whereBuilder
+	studentWhere(&where, filter)
+
+	var total int
+	if !opts.NoCount {
+		countQuery := "SELECT COUNT(*) FROM students " + where.String()
+		if err := p.db.QueryRowContext(ctx, countQuery, where.args...).Scan(&total); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	offset := opts.Offset
+	if opts.After != nil {
+		// the keyset condition replaces OFFSET, the database seeks straight to the cursor
+		if err := keysetAfter(&where, opts.Sort, opts.After, studentColumns); err != nil {
+			return nil, 0, err
+		}
+		offset = 0
+	}
+
+	query := fmt.Sprintf("SELECT id, name, age, class, version FROM students %s %s LIMIT %s OFFSET %s",
+		where.String(), order, where.arg(opts.Limit), where.arg(offset))
+
+	rows, err := p.db.QueryContext(ctx, query, where.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var students []models.Student
+	for rows.Next() {
+		var s models.Student
+		if err := rows.Scan(&s.ID, &s.Name, &s.Age, &s.Class, &s.Version); err != nil {
+			return nil, 0, err
+		}
+		students = append(students, s)
+	}
+	return students, total, rows.Err()
+}
+
+// studentWhere ... adds one condition per filter field that is set
+func studentWhere(where *whereBuilder, filter StudentFilter) {
+	if filter.Class != nil {
+		where.add("class = ?", *filter.Class)
+	}
+	if filter.AgeMin != nil {
+		where.add("age >= ?", *filter.AgeMin)
+	}
+	if filter.AgeMax != nil {
+		where.add("age <= ?", *filter.AgeMax)
+	}
+	if filter.NameContains != "" {
+		where.add(`name ILIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(filter.NameContains)+"%")
+	}
+	if filter.NamePrefix != "" {
+		where.add(`name ILIKE ? ESCAPE '\'`, likeEscaper.Replace(filter.NamePrefix)+"%")
+	}
+}
+
+// Get ...
+func (p *PostgresStudentStore) Get(ctx context.Context, id int) (models.Student, error) {
+	var s models.Student
+	err := p.db.QueryRowContext(ctx, "SELECT id, name, age, class, version FROM students WHERE id=$1", id).Scan(&s.ID, &s.Name, &s.Age, &s.Class, &s.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, ErrNotFound
+	}
+	return s, err
+}
+
+// Create ...
+func (p *PostgresStudentStore) Create(ctx context.Context, s models.Student) (models.Student, error) {
+	// database supports RETURNING (PostgreSQL) so the generated id comes back in the same round trip
+	err := p.db.QueryRowContext(ctx, "INSERT INTO students (name, age, class) VALUES ($1, $2, $3) RETURNING id, version",
+		s.Name, s.Age, s.Class).Scan(&s.ID, &s.Version)
+	return s, pgError(err)
+}
+
+// CreateBatch inserts all students in one transaction, in batches because query size matters
+func (p *PostgresStudentStore) CreateBatch(ctx context.Context, students []models.Student) ([]models.Student, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback() // Ensure the transaction is rolled back if anything goes wrong
+
+	rows := make([][]interface{}, len(students))
+	for i, s := range students {
+		rows[i] = []interface{}{s.Name, s.Age, s.Class}
+	}
+
+	inserted := append([]models.Student(nil), students...)
+	err = batchInsert(ctx, tx, "INSERT INTO students (name, age, class) VALUES %s RETURNING id, version", rows,
+		func(r *sql.Rows, i int) error { return r.Scan(&inserted[i].ID, &inserted[i].Version) })
+	if err != nil {
+		return nil, pgError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return inserted, nil
+}
+
+// Update ... the version check is part of the WHERE clause, so a concurrent update cannot slip in between
+func (p *PostgresStudentStore) Update(ctx context.Context, id int, s models.Student, version int) (models.Student, error) {
+	s.ID = id
+	err := p.db.QueryRowContext(ctx, `
+		UPDATE students SET name=$1, age=$2, class=$3, version=version+1
+		WHERE id=$4 AND ($5 = 0 OR version=$5)
+		RETURNING version`, s.Name, s.Age, s.Class, id, version).Scan(&s.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, p.missedVersion(ctx, id)
+	}
+	return s, pgError(err)
+}
+
+// missedVersion ... why a conditional write matched no row: the student is gone or at another version
+func (p *PostgresStudentStore) missedVersion(ctx context.Context, id int) error {
+	if err := studentExists(ctx, p.db, id); err != nil {
+		return err
+	}
+	return ErrVersionMismatch
+}
+
+// Modify ... SELECT ... FOR UPDATE makes a concurrent Modify wait, so two patches cannot overwrite each other
+func (p *PostgresStudentStore) Modify(ctx context.Context, id int, version int, fn func(*models.Student) error) (models.Student, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Student{}, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var s models.Student
+	err = tx.QueryRowContext(ctx, "SELECT id, name, age, class, version FROM students WHERE id=$1 FOR UPDATE", id).
+		Scan(&s.ID, &s.Name, &s.Age, &s.Class, &s.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, ErrNotFound
+	}
+	if err != nil {
+		return s, err
+	}
+	if version != 0 && s.Version != version {
+		return s, ErrVersionMismatch
+	}
+
+	if err := fn(&s); err != nil {
+		return s, err
+	}
+	s.ID = id
+
+	err = tx.QueryRowContext(ctx, "UPDATE students SET name=$1, age=$2, class=$3, version=version+1 WHERE id=$4 RETURNING version",
+		s.Name, s.Age, s.Class, id).Scan(&s.Version)
+	if err != nil {
+		return s, pgError(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return s, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return s, nil
+}
+
+// Delete ...
+func (p *PostgresStudentStore) Delete(ctx context.Context, id int, version int) error {
+	result, err := p.db.ExecContext(ctx, "DELETE FROM students WHERE id=$1 AND ($2 = 0 OR version=$2)", id, version)
+	if err != nil {
+		return pgError(err)
+	}
+	if err := expectRows(result); err != nil {
+		return p.missedVersion(ctx, id)
+	}
+	return nil
+}
+
+// expectRows turns "nothing matched the WHERE clause" into ErrNotFound
+func expectRows(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// studentExists ... ErrNotFound when there is no such student
+func studentExists(ctx context.Context, db *sql.DB, studentID int) error {
+	var exists int
+	err := db.QueryRowContext(ctx, "SELECT 1 FROM students WHERE id=$1", studentID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+
+	"school_api_postgres/models"
+)
+
+// TeacherSortFields ... the only fields a teacher list may be sorted by
+var TeacherSortFields = []string{"id", "name", "email", "hire_date"}
+
+// TeacherFilter ... narrows a teacher list, zero values mean no filter
+type TeacherFilter struct {
+	// Subject matches teachers having this speciality, case-insensitively
+	Subject string
+	// NameContains and NamePrefix are matched case-insensitively
+	NameContains string
+	NamePrefix   string
+	HiredAfter   *models.Date
+	HiredBefore  *models.Date
+}
+
+// TeacherStore ... everything the teacher handlers need from storage
+type TeacherStore interface {
+	// List returns one page of teachers and the total number matching the filter
+	List(ctx context.Context, filter TeacherFilter, opts ListOptions) ([]models.Teacher, int, error)
+	Get(ctx context.Context, id int) (models.Teacher, error)
+	Create(ctx context.Context, t models.Teacher) (models.Teacher, error)
+	CreateBatch(ctx context.Context, teachers []models.Teacher) ([]models.Teacher, error)
+	Update(ctx context.Context, id int, t models.Teacher) error
+	// Modify ... same contract as StudentStore.Modify
+	Modify(ctx context.Context, id int, fn func(*models.Teacher) error) (models.Teacher, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// TeacherCursor ... the cursor pointing just after t for the given sort
+func TeacherCursor(t models.Teacher, sort []SortField) Cursor {
+	var c Cursor
+	for _, f := range KeysetSort(sort) {
+		c.Values = append(c.Values, teacherFieldValue(t, f.Field))
+	}
+	return c
+}
+
+// teacherFieldValue ... the value of a sortable field, dates as YYYY-MM-DD so they sort as text too
+func teacherFieldValue(t models.Teacher, field string) interface{} {
+	switch field {
+	case "id":
+		return t.ID
+	case "name":
+		return t.Name
+	case "email":
+		return t.Email
+	case "hire_date":
+		return t.HireDate.String()
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"school_api_postgres/models"
+)
+
+var _ TeacherStore = (*MemoryTeacherStore)(nil)
+
+// MemoryTeacherStore ... TeacherStore kept in a map, safe for concurrent use
+type MemoryTeacherStore struct {
+	mu       sync.RWMutex
+	teachers map[int]models.Teacher
+	nextID   int
+}
+
+// NewMemoryTeacherStore ...
+func NewMemoryTeacherStore() *MemoryTeacherStore {
+	return &MemoryTeacherStore{
+		teachers: make(map[int]models.Teacher),
+		nextID:   1,
+	}
+}
+
+// List filters and sorts a snapshot of the map the same way the Postgres query does
+func (m *MemoryTeacherStore) List(ctx context.Context, filter TeacherFilter, opts ListOptions) ([]models.Teacher, int, error) {
+	for _, f := range opts.Sort {
+		if _, ok := teacherColumns[f.Field]; !ok {
+			return nil, 0, fmt.Errorf("cannot sort by %q", f.Field)
+		}
+	}
+	keys := KeysetSort(opts.Sort)
+
+	m.mu.RLock()
+	all := make([]models.Teacher, 0, len(m.teachers))
+	for _, t := range m.teachers {
+		if teacherMatches(t, filter) {
+			all = append(all, cloneTeacher(t))
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(all, func(i, j int) bool {
+		return compareKeys(TeacherCursor(all[i], keys).Values, TeacherCursor(all[j], keys).Values, keys) < 0
+	})
+
+	total := len(all)
+	if opts.NoCount {
+		total = 0
+	}
+
+	if opts.After != nil {
+		if len(opts.After.Values) != len(keys) {
+			return nil, 0, ErrInvalidCursor
+		}
+		start := sort.Search(len(all), func(i int) bool {
+			return compareKeys(TeacherCursor(all[i], keys).Values, opts.After.Values, keys) > 0
+		})
+		page, err := paginate(all[start:], ListOptions{Limit: opts.Limit})
+		return page, total, err
+	}
+	page, err := paginate(all, opts)
+	return page, total, err
+}
+
+// teacherMatches ... in-memory version of teacherWhere
+func teacherMatches(t models.Teacher, filter TeacherFilter) bool {
+	if filter.Subject != "" && !slices.ContainsFunc(t.Subjects, func(s string) bool { return strings.EqualFold(s, filter.Subject) }) {
+		return false
+	}
+	name := strings.ToLower(t.Name)
+	if filter.NameContains != "" && !strings.Contains(name, strings.ToLower(filter.NameContains)) {
+		return false
+	}
+	if filter.NamePrefix != "" && !strings.HasPrefix(name, strings.ToLower(filter.NamePrefix)) {
+		return false
+	}
+	if filter.HiredAfter != nil && t.HireDate.Before(filter.HiredAfter.Time) {
+		return false
+	}
+	if filter.HiredBefore != nil && t.HireDate.After(filter.HiredBefore.Time) {
+		return false
+	}
+	return true
+}
+
+// Get ...
+func (m *MemoryTeacherStore) Get(ctx context.Context, id int) (models.Teacher, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.teachers[id]
+	if !ok {
+		return models.Teacher{}, ErrNotFound
+	}
+	return cloneTeacher(t), nil
+}
+
+// Create ...
+func (m *MemoryTeacherStore) Create(ctx context.Context, t models.Teacher) (models.Teacher, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.emailTaken(t.Email, 0) {
+		return models.Teacher{}, constraintError(ErrConflict, "teachers_email_key")
+	}
+	t = cloneTeacher(t)
+	t.ID = m.nextID
+	m.nextID++
+	m.teachers[t.ID] = t
+	return cloneTeacher(t), nil
+}
+
+// CreateBatch ... all or nothing, a duplicate email anywhere rejects the whole batch
+func (m *MemoryTeacherStore) CreateBatch(ctx context.Context, teachers []models.Teacher) ([]models.Teacher, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	seen := make(map[string]bool)
+	for _, t := range teachers {
+		email := strings.ToLower(t.Email)
+		if seen[email] || m.emailTaken(t.Email, 0) {
+			return nil, constraintError(ErrConflict, "teachers_email_key")
+		}
+		seen[email] = true
+	}
+
+	inserted := make([]models.Teacher, 0, len(teachers))
+	for _, t := range teachers {
+		t = cloneTeacher(t)
+		t.ID = m.nextID
+		m.nextID++
+		m.teachers[t.ID] = t
+		inserted = append(inserted, cloneTeacher(t))
+	}
+	return inserted, nil
+}
+
+// Update ...
+func (m *MemoryTeacherStore) Update(ctx context.Context, id int, t models.Teacher) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.teachers[id]; !ok {
+		return ErrNotFound
+	}
+	if m.emailTaken(t.Email, id) {
+		return constraintError(ErrConflict, "teachers_email_key")
+	}
+	t = cloneTeacher(t)
+	t.ID = id
+	m.teachers[id] = t
+	return nil
+}
+
+// Modify ... the write lock is held while fn runs
+func (m *MemoryTeacherStore) Modify(ctx context.Context, id int, fn func(*models.Teacher) error) (models.Teacher, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.teachers[id]
+	if !ok {
+		return models.Teacher{}, ErrNotFound
+	}
+	t = cloneTeacher(t)
+	if err := fn(&t); err != nil {
+		return t, err
+	}
+	if m.emailTaken(t.Email, id) {
+		return t, constraintError(ErrConflict, "teachers_email_key")
+	}
+	t = cloneTeacher(t)
+	t.ID = id
+	m.teachers[id] = t
+	return t, nil
+}
+
+// Delete ...
+func (m *MemoryTeacherStore) Delete(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.teachers[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.teachers, id)
+	return nil
+}
+
+// emailTaken ... case-insensitive like the unique index, except is the id allowed to own it
+func (m *MemoryTeacherStore) emailTaken(email string, except int) bool {
+	for id, t := range m.teachers {
+		if id != except && strings.EqualFold(t.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneTeacher ... copies the subjects slice so callers cannot mutate the stored teacher
+func cloneTeacher(t models.Teacher) models.Teacher {
+	t.Subjects = nonNil(slices.Clone(t.Subjects))
+	return t
+}
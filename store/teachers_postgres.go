@@ -0,0 +1,213 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"school_api_postgres/models"
+
+	"github.com/lib/pq"
+)
+
+var _ TeacherStore = (*PostgresTeacherStore)(nil)
+
+// PostgresTeacherStore ... TeacherStore backed by the teachers table
+type PostgresTeacherStore struct {
+	db *sql.DB
+}
+
+// NewPostgresTeacherStore ...
+func NewPostgresTeacherStore(db *sql.DB) *PostgresTeacherStore {
+	return &PostgresTeacherStore{db: db}
+}
+
+// teacherColumns ... API sort field -> SQL column
+var teacherColumns = map[string]string{
+	"id":        "id",
+	"name":      "name",
+	"email":     "email",
+	"hire_date": "hire_date",
+}
+
+const teacherSelect = "SELECT id, name, email, subjects, hire_date FROM teachers"
+
+// List ... same shape as the student list: filter, sort, offset or keyset paging
+func (p *PostgresTeacherStore) List(ctx context.Context, filter TeacherFilter, opts ListOptions) ([]models.Teacher, int, error) {
+	order, err := orderBy(opts.Sort, teacherColumns)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var where whereBuilder
+	teacherWhere(&where, filter)
+
+	var total int
+	if !opts.NoCount {
+		countQuery := "SELECT COUNT(*) FROM teachers " + where.String()
+		if err := p.db.QueryRowContext(ctx, countQuery, where.args...).Scan(&total); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	offset := opts.Offset
+	if opts.After != nil {
+		if err := keysetAfter(&where, opts.Sort, opts.After, teacherColumns); err != nil {
+			return nil, 0, err
+		}
+		offset = 0
+	}
+
+	query := fmt.Sprintf("%s %s %s LIMIT %s OFFSET %s",
+		teacherSelect, where.String(), order, where.arg(opts.Limit), where.arg(offset))
+
+	rows, err := p.db.QueryContext(ctx, query, where.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var teachers []models.Teacher
+	for rows.Next() {
+		t, err := scanTeacher(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		teachers = append(teachers, t)
+	}
+	return teachers, total, rows.Err()
+}
+
+// teacherWhere ... adds one condition per filter field that is set
+func teacherWhere(where *whereBuilder, filter TeacherFilter) {
+	if filter.Subject != "" {
+		where.add("EXISTS (SELECT 1 FROM unnest(subjects) AS s WHERE lower(s) = lower(?))", filter.Subject)
+	}
+	if filter.NameContains != "" {
+		where.add(`name ILIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(filter.NameContains)+"%")
+	}
+	if filter.NamePrefix != "" {
+		where.add(`name ILIKE ? ESCAPE '\'`, likeEscaper.Replace(filter.NamePrefix)+"%")
+	}
+	if filter.HiredAfter != nil {
+		where.add("hire_date >= ?", *filter.HiredAfter)
+	}
+	if filter.HiredBefore != nil {
+		where.add("hire_date <= ?", *filter.HiredBefore)
+	}
+}
+
+// scanTeacher ... works for *sql.Row and *sql.Rows
+func scanTeacher(row interface{ Scan(...interface{}) error }) (models.Teacher, error) {
+	var t models.Teacher
+	err := row.Scan(&t.ID, &t.Name, &t.Email, pq.Array(&t.Subjects), &t.HireDate)
+	if t.Subjects == nil {
+		t.Subjects = []string{}
+	}
+	return t, err
+}
+
+// Get ...
+func (p *PostgresTeacherStore) Get(ctx context.Context, id int) (models.Teacher, error) {
+	t, err := scanTeacher(p.db.QueryRowContext(ctx, teacherSelect+" WHERE id=$1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return t, ErrNotFound
+	}
+	return t, err
+}
+
+// Create ...
+func (p *PostgresTeacherStore) Create(ctx context.Context, t models.Teacher) (models.Teacher, error) {
+	t.Subjects = nonNil(t.Subjects)
+	err := p.db.QueryRowContext(ctx, "INSERT INTO teachers (name, email, subjects, hire_date) VALUES ($1, $2, $3, $4) RETURNING id",
+		t.Name, t.Email, pq.Array(t.Subjects), t.HireDate).Scan(&t.ID)
+	return t, pgError(err)
+}
+
+// CreateBatch inserts all teachers in one transaction, in batches like the students
+func (p *PostgresTeacherStore) CreateBatch(ctx context.Context, teachers []models.Teacher) ([]models.Teacher, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	inserted := append([]models.Teacher(nil), teachers...)
+	rows := make([][]interface{}, len(inserted))
+	for i := range inserted {
+		inserted[i].Subjects = nonNil(inserted[i].Subjects)
+		rows[i] = []interface{}{inserted[i].Name, inserted[i].Email, pq.Array(inserted[i].Subjects), inserted[i].HireDate}
+	}
+
+	err = batchInsert(ctx, tx, "INSERT INTO teachers (name, email, subjects, hire_date) VALUES %s RETURNING id", rows,
+		func(r *sql.Rows, i int) error { return r.Scan(&inserted[i].ID) })
+	if err != nil {
+		return nil, pgError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return inserted, nil
+}
+
+// Update ...
+func (p *PostgresTeacherStore) Update(ctx context.Context, id int, t models.Teacher) error {
+	result, err := p.db.ExecContext(ctx, "UPDATE teachers SET name=$1, email=$2, subjects=$3, hire_date=$4 WHERE id=$5",
+		t.Name, t.Email, pq.Array(nonNil(t.Subjects)), t.HireDate, id)
+	if err != nil {
+		return pgError(err)
+	}
+	return expectRows(result)
+}
+
+// Modify ... locks the row like the student version
+func (p *PostgresTeacherStore) Modify(ctx context.Context, id int, fn func(*models.Teacher) error) (models.Teacher, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Teacher{}, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	t, err := scanTeacher(tx.QueryRowContext(ctx, teacherSelect+" WHERE id=$1 FOR UPDATE", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return t, ErrNotFound
+	}
+	if err != nil {
+		return t, err
+	}
+
+	if err := fn(&t); err != nil {
+		return t, err
+	}
+	t.ID = id
+	t.Subjects = nonNil(t.Subjects)
+
+	_, err = tx.ExecContext(ctx, "UPDATE teachers SET name=$1, email=$2, subjects=$3, hire_date=$4 WHERE id=$5",
+		t.Name, t.Email, pq.Array(t.Subjects), t.HireDate, id)
+	if err != nil {
+		return t, pgError(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return t, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return t, nil
+}
+
+// Delete ...
+func (p *PostgresTeacherStore) Delete(ctx context.Context, id int) error {
+	result, err := p.db.ExecContext(ctx, "DELETE FROM teachers WHERE id=$1", id)
+	if err != nil {
+		return pgError(err)
+	}
+	return expectRows(result)
+}
+
+// nonNil ... NOT NULL array columns want '{}' rather than NULL
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"school_api_postgres/models"
+)
+
+// ErrTokenReused ... a refresh token came back after it was used or revoked, its family is revoked now
+var ErrTokenReused = errors.New("was already used")
+
+// UserStore ... staff accounts and their failed login count
+type UserStore interface {
+	// Create ... ErrConflict if the username is taken
+	Create(ctx context.Context, u models.User) (models.User, error)
+	// List ... every user, oldest first
+	List(ctx context.Context) ([]models.User, error)
+	// Get ... ErrNotFound if there is no user with the id
+	Get(ctx context.Context, id int) (models.User, error)
+	// GetByUsername ... ErrNotFound if there is no such user
+	GetByUsername(ctx context.Context, username string) (models.User, error)
+	// LoginFailed counts a failed login, the maxFailures-th in a row locks the account until lockedUntil
+	// and starts the count again. It returns the user as it is now
+	LoginFailed(ctx context.Context, id, maxFailures int, lockedUntil time.Time) (models.User, error)
+	// LoginSucceeded ... resets the failed login count
+	LoginSucceeded(ctx context.Context, id int) error
+	// Unlock ... lifts a lockout before it runs out, ErrNotFound if there is no user with the id
+	Unlock(ctx context.Context, id int) error
+}
+
+// RefreshTokenStore ... the refresh tokens of logins, grouped in families
+type RefreshTokenStore interface {
+	// Create ...
+	Create(ctx context.Context, t models.RefreshToken) error
+	// Consume marks the token with the hash as used and returns it. ErrNotFound if there is none,
+	// ErrTokenReused if it was used or revoked before, which revokes every token of its family
+	Consume(ctx context.Context, hash string, at time.Time) (models.RefreshToken, error)
+	// RevokeFamily ... ends the login the token with the hash belongs to, unknown hashes are ignored
+	RevokeFamily(ctx context.Context, hash string) error
+	// DeleteExpired ... removes tokens past their expiry, returns how many
+	DeleteExpired(ctx context.Context) (int64, error)
+}
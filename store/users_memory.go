@@ -0,0 +1,207 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"school_api_postgres/models"
+)
+
+var (
+	_ UserStore         = (*MemoryUserStore)(nil)
+	_ RefreshTokenStore = (*MemoryRefreshTokenStore)(nil)
+)
+
+// MemoryUserStore ... UserStore kept in a map, safe for concurrent use
+type MemoryUserStore struct {
+	mu     sync.RWMutex
+	users  map[int]models.User
+	nextID int
+}
+
+// NewMemoryUserStore ...
+func NewMemoryUserStore() *MemoryUserStore {
+	return &MemoryUserStore{users: make(map[int]models.User), nextID: 1}
+}
+
+// Create ...
+func (m *MemoryUserStore) Create(ctx context.Context, u models.User) (models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, other := range m.users {
+		if other.Username == u.Username {
+			return u, constraintError(ErrConflict, "users_username_key")
+		}
+	}
+	u.ID = m.nextID
+	m.nextID++
+	u.CreatedAt = time.Now()
+	m.users[u.ID] = u
+	return u, nil
+}
+
+// List ...
+func (m *MemoryUserStore) List(ctx context.Context) ([]models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	users := make([]models.User, 0, len(m.users))
+	for _, u := range m.users {
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+// Get ...
+func (m *MemoryUserStore) Get(ctx context.Context, id int) (models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.users[id]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	return u, nil
+}
+
+// GetByUsername ...
+func (m *MemoryUserStore) GetByUsername(ctx context.Context, username string) (models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, u := range m.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return models.User{}, ErrNotFound
+}
+
+// LoginFailed ...
+func (m *MemoryUserStore) LoginFailed(ctx context.Context, id, maxFailures int, lockedUntil time.Time) (models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[id]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	u.FailedLogins++
+	if u.FailedLogins >= maxFailures {
+		u.FailedLogins = 0
+		u.LockedUntil = &lockedUntil
+	}
+	m.users[id] = u
+	return u, nil
+}
+
+// LoginSucceeded ...
+func (m *MemoryUserStore) LoginSucceeded(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if u, ok := m.users[id]; ok {
+		u.FailedLogins = 0
+		m.users[id] = u
+	}
+	return nil
+}
+
+// Unlock ...
+func (m *MemoryUserStore) Unlock(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	u.FailedLogins, u.LockedUntil = 0, nil
+	m.users[id] = u
+	return nil
+}
+
+// MemoryRefreshTokenStore ... RefreshTokenStore kept in a map keyed by token hash
+type MemoryRefreshTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]models.RefreshToken
+	nextID int
+}
+
+// NewMemoryRefreshTokenStore ...
+func NewMemoryRefreshTokenStore() *MemoryRefreshTokenStore {
+	return &MemoryRefreshTokenStore{tokens: make(map[string]models.RefreshToken), nextID: 1}
+}
+
+// Create ...
+func (m *MemoryRefreshTokenStore) Create(ctx context.Context, t models.RefreshToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.tokens[t.TokenHash]; ok {
+		return constraintError(ErrConflict, "refresh_tokens_token_hash_key")
+	}
+	t.ID = m.nextID
+	m.nextID++
+	t.CreatedAt = time.Now()
+	m.tokens[t.TokenHash] = t
+	return nil
+}
+
+// Consume ...
+func (m *MemoryRefreshTokenStore) Consume(ctx context.Context, hash string, at time.Time) (models.RefreshToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tokens[hash]
+	if !ok {
+		return models.RefreshToken{}, ErrNotFound
+	}
+	if t.UsedAt != nil || t.Revoked {
+		m.revokeFamily(t.Family)
+		return models.RefreshToken{}, ErrTokenReused
+	}
+	t.UsedAt = &at
+	m.tokens[hash] = t
+	return t, nil
+}
+
+// RevokeFamily ...
+func (m *MemoryRefreshTokenStore) RevokeFamily(ctx context.Context, hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if t, ok := m.tokens[hash]; ok {
+		m.revokeFamily(t.Family)
+	}
+	return nil
+}
+
+// revokeFamily ... the caller holds the lock
+func (m *MemoryRefreshTokenStore) revokeFamily(family string) {
+	for hash, t := range m.tokens {
+		if t.Family == family {
+			t.Revoked = true
+			m.tokens[hash] = t
+		}
+	}
+}
+
+// DeleteExpired ...
+func (m *MemoryRefreshTokenStore) DeleteExpired(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	for hash, t := range m.tokens {
+		if time.Now().After(t.ExpiresAt) {
+			delete(m.tokens, hash)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,177 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"school_api_postgres/models"
+)
+
+var (
+	_ UserStore         = (*PostgresUserStore)(nil)
+	_ RefreshTokenStore = (*PostgresRefreshTokenStore)(nil)
+)
+
+// PostgresUserStore ... UserStore backed by the users table
+type PostgresUserStore struct {
+	db *sql.DB
+}
+
+// NewPostgresUserStore ...
+func NewPostgresUserStore(db *sql.DB) *PostgresUserStore {
+	return &PostgresUserStore{db: db}
+}
+
+const userColumns = "id, username, name, password_hash, role, failed_logins, locked_until, created_at"
+
+// scanUser ... works for *sql.Row and *sql.Rows
+func scanUser(row interface{ Scan(...interface{}) error }) (models.User, error) {
+	var u models.User
+	err := row.Scan(&u.ID, &u.Username, &u.Name, &u.PasswordHash, &u.Role, &u.FailedLogins, &u.LockedUntil, &u.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return u, ErrNotFound
+	}
+	return u, err
+}
+
+// Create ...
+func (p *PostgresUserStore) Create(ctx context.Context, u models.User) (models.User, error) {
+	err := p.db.QueryRowContext(ctx, `
+		INSERT INTO users (username, name, password_hash, role)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`,
+		u.Username, u.Name, u.PasswordHash, u.Role).Scan(&u.ID, &u.CreatedAt)
+	return u, pgError(err)
+}
+
+// List ...
+func (p *PostgresUserStore) List(ctx context.Context) ([]models.User, error) {
+	rows, err := p.db.QueryContext(ctx, "SELECT "+userColumns+" FROM users ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// Get ...
+func (p *PostgresUserStore) Get(ctx context.Context, id int) (models.User, error) {
+	return scanUser(p.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id=$1", id))
+}
+
+// GetByUsername ...
+func (p *PostgresUserStore) GetByUsername(ctx context.Context, username string) (models.User, error) {
+	return scanUser(p.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE username=$1", username))
+}
+
+// LoginFailed ... one UPDATE, so parallel guesses cannot get past the limit by racing each other
+func (p *PostgresUserStore) LoginFailed(ctx context.Context, id, maxFailures int, lockedUntil time.Time) (models.User, error) {
+	return scanUser(p.db.QueryRowContext(ctx, `
+		UPDATE users SET
+			failed_logins = CASE WHEN failed_logins + 1 >= $2 THEN 0 ELSE failed_logins + 1 END,
+			locked_until = CASE WHEN failed_logins + 1 >= $2 THEN $3 ELSE locked_until END
+		WHERE id=$1
+		RETURNING `+userColumns, id, maxFailures, lockedUntil))
+}
+
+// LoginSucceeded ...
+func (p *PostgresUserStore) LoginSucceeded(ctx context.Context, id int) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE users SET failed_logins = 0 WHERE id=$1 AND failed_logins > 0", id)
+	return err
+}
+
+// Unlock ...
+func (p *PostgresUserStore) Unlock(ctx context.Context, id int) error {
+	result, err := p.db.ExecContext(ctx, "UPDATE users SET failed_logins = 0, locked_until = NULL WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+	return expectRows(result)
+}
+
+// PostgresRefreshTokenStore ... RefreshTokenStore backed by the refresh_tokens table
+type PostgresRefreshTokenStore struct {
+	db *sql.DB
+}
+
+// NewPostgresRefreshTokenStore ...
+func NewPostgresRefreshTokenStore(db *sql.DB) *PostgresRefreshTokenStore {
+	return &PostgresRefreshTokenStore{db: db}
+}
+
+// Create ...
+func (p *PostgresRefreshTokenStore) Create(ctx context.Context, t models.RefreshToken) error {
+	_, err := p.db.ExecContext(ctx,
+		"INSERT INTO refresh_tokens (user_id, family, token_hash, expires_at) VALUES ($1, $2, $3, $4)",
+		t.UserID, t.Family, t.TokenHash, t.ExpiresAt)
+	return pgError(err)
+}
+
+// Consume ... the row is locked so two refreshes with the same token cannot both get through
+func (p *PostgresRefreshTokenStore) Consume(ctx context.Context, hash string, at time.Time) (models.RefreshToken, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.RefreshToken{}, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var t models.RefreshToken
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, user_id, family, token_hash, expires_at, used_at, revoked, created_at
+		FROM refresh_tokens WHERE token_hash=$1 FOR UPDATE`, hash).
+		Scan(&t.ID, &t.UserID, &t.Family, &t.TokenHash, &t.ExpiresAt, &t.UsedAt, &t.Revoked, &t.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return t, ErrNotFound
+	}
+	if err != nil {
+		return t, err
+	}
+
+	if t.UsedAt != nil || t.Revoked {
+		if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked = TRUE WHERE family=$1", t.Family); err != nil {
+			return t, err
+		}
+		if err := tx.Commit(); err != nil {
+			return t, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return models.RefreshToken{}, ErrTokenReused
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET used_at=$1 WHERE id=$2", at, t.ID); err != nil {
+		return t, err
+	}
+	if err := tx.Commit(); err != nil {
+		return t, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	t.UsedAt = &at
+	return t, nil
+}
+
+// RevokeFamily ...
+func (p *PostgresRefreshTokenStore) RevokeFamily(ctx context.Context, hash string) error {
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE refresh_tokens SET revoked = TRUE
+		WHERE family = (SELECT family FROM refresh_tokens WHERE token_hash=$1)`, hash)
+	return err
+}
+
+// DeleteExpired ...
+func (p *PostgresRefreshTokenStore) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := p.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE expires_at < now()")
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,105 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Codes of a FieldError, stable so clients can map them to their own messages
+const (
+	CodeRequired      = "required"
+	CodeTooLong       = "too_long"
+	CodeTooShort      = "too_short"
+	CodeOutOfRange    = "out_of_range"
+	CodeInvalidFormat = "invalid_format"
+	CodeInvalidChoice = "invalid_choice"
+	// CodeInvalid ... anything that is not about one field, like an empty PATCH
+	CodeInvalid = "invalid"
+)
+
+// FieldError ... one problem with one field, Index is set for the elements of a bulk request
+type FieldError struct {
+	Index   *int   `json:"index,omitempty"`
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	if e.Index != nil {
+		return fmt.Sprintf("[%d] %s: %s", *e.Index, e.Field, e.Message)
+	}
+	return e.Field + ": " + e.Message
+}
+
+// Errors ... every field error found, the validators return it instead of stopping at the first problem
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Add records a field error
+func (e *Errors) Add(field, code, message string) {
+	*e = append(*e, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Check records err if it is not nil, a FieldError keeps its field and code
+func (e *Errors) Check(err error) {
+	if err == nil {
+		return
+	}
+	var fe FieldError
+	if errors.As(err, &fe) {
+		*e = append(*e, fe)
+		return
+	}
+	var errs Errors
+	if errors.As(err, &errs) {
+		*e = append(*e, errs...)
+		return
+	}
+	*e = append(*e, FieldError{Code: CodeInvalid, Message: err.Error()})
+}
+
+// At records every error in err as belonging to element i of a bulk request
+func (e *Errors) At(i int, err error) {
+	var found Errors
+	found.Check(err)
+	for _, fe := range found {
+		index := i
+		fe.Index = &index
+		*e = append(*e, fe)
+	}
+}
+
+// Nested records every error in err under prefix, e.g. records[2].status for the status of the third record
+func (e *Errors) Nested(prefix string, err error) {
+	var found Errors
+	found.Check(err)
+	for _, fe := range found {
+		if fe.Field != "" {
+			fe.Field = prefix + "." + fe.Field
+		} else {
+			fe.Field = prefix
+		}
+		*e = append(*e, fe)
+	}
+}
+
+// Err ... nil when nothing was found, so callers can return it directly
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func fieldError(field, code, message string) error {
+	return FieldError{Field: field, Code: code, Message: message}
+}
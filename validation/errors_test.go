@@ -0,0 +1,53 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	var errs Errors
+	if errs.Err() != nil {
+		t.Fatal("Err of no errors is not nil")
+	}
+	errs.Add("name", CodeRequired, "name is required")
+	errs.Check(nil)
+	errs.Check(fieldError("age", CodeOutOfRange, "age must be between 3 and 25"))
+	errs.Check(Errors{{Field: "class", Code: CodeOutOfRange, Message: "class must be between 1 and 12"}})
+	errs.Check(errors.New("nothing to change"))
+	errs.At(2, Errors{{Field: "score", Code: CodeOutOfRange, Message: "too high"}})
+	errs.Nested("records[1]", fieldError("status", CodeInvalidChoice, "unknown status"))
+	errs.Nested("records[3]", errors.New("not a record"))
+
+	tests := []struct {
+		got  FieldError
+		want string
+	}{
+		{errs[0], "name:required"},
+		{errs[1], "age:out_of_range"},
+		{errs[2], "class:out_of_range"},
+		// a plain error has no field of its own
+		{errs[3], ":invalid"},
+		{errs[4], "score:out_of_range"},
+		{errs[5], "records[1].status:invalid_choice"},
+		{errs[6], "records[3]:invalid"},
+	}
+	if len(errs) != len(tests) {
+		t.Fatalf("%d errors, want %d: %v", len(errs), len(tests), errs)
+	}
+	for i, tt := range tests {
+		if got := tt.got.Field + ":" + tt.got.Code; got != tt.want {
+			t.Errorf("error %d = %s, want %s", i, got, tt.want)
+		}
+	}
+	if errs[4].Index == nil || *errs[4].Index != 2 || errs[4].Error() != "[2] score: too high" {
+		t.Errorf("bulk error %v", errs[4])
+	}
+	if errs[0].Index != nil {
+		t.Errorf("a single request error has index %d", *errs[0].Index)
+	}
+	var found Errors
+	if !errors.As(errs.Err(), &found) || len(found) != len(errs) {
+		t.Errorf("Err = %v", errs.Err())
+	}
+}
@@ -0,0 +1,499 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The field rules of a model live in its `validate` struct tags, e.g. `validate:"required,min=1,max=120"`:
+//
+//	required       the field must be set: non-zero numbers, non-blank strings, non-nil pointers, non-empty lists
+//	min=N, max=N   bounds of a number, or of the length of a string or list
+//	regex=PATTERN  the string must match PATTERN, which cannot contain a comma
+//	enum=a|b|c     the string must be one of the listed values
+//	email, phone   the string must be an email address or a phone number
+//	past           the date must not be in the future
+//	dive           the rules after it apply to every element of a list
+//
+// A field that is not required and not set is not checked any further.
+// Rules that need more than one field are checks, written in Go with their limits taken from
+// the same min=,max= syntax so they can be tuned like the tags.
+// VALIDATION_RULES_FILE can replace the rules of any field or check without rebuilding the server.
+
+// rule ... one comma separated entry of a tag
+type rule struct {
+	name    string
+	param   string
+	number  float64
+	pattern *regexp.Regexp
+	choices []string
+}
+
+// ruleSet ... the rules of one field, elem holds the rules after dive
+type ruleSet struct {
+	rules []rule
+	elem  []rule
+}
+
+// fieldSpec ... a struct field with a validate tag, named like its JSON key
+type fieldSpec struct {
+	name  string
+	index int
+	typ   reflect.Type
+	tag   string
+	rules ruleSet
+}
+
+// check ... a rule spanning several fields, it only runs when those fields passed their own rules
+type check struct {
+	name     string
+	fields   []string
+	defaults string
+	params   map[string]float64
+	disabled bool
+	fn       func(v interface{}, params map[string]float64) error
+}
+
+// model ... the rules of one struct, create, put, patch and bulk requests all go through it
+type model struct {
+	name   string
+	typ    reflect.Type
+	fields []*fieldSpec
+	checks []*check
+}
+
+// registry ... every model by the name used in VALIDATION_RULES_FILE
+var registry = map[string]*model{}
+
+// register reads the tags of zero's struct type, a broken tag is a programming error so it panics
+func register(name string, zero interface{}, checks ...*check) *model {
+	m := &model{name: name, typ: reflect.TypeOf(zero), checks: checks}
+	for i := 0; i < m.typ.NumField(); i++ {
+		f := m.typ.Field(i)
+		tag, ok := f.Tag.Lookup("validate")
+		if !ok {
+			continue
+		}
+		spec := &fieldSpec{name: jsonName(f), index: i, typ: f.Type, tag: tag}
+		rules, err := parseRules(tag, f.Type)
+		if err != nil {
+			panic(fmt.Sprintf("validation: %s.%s: %v", name, spec.name, err))
+		}
+		spec.rules = rules
+		m.fields = append(m.fields, spec)
+	}
+	for _, c := range checks {
+		params, err := parseParams(c.defaults)
+		if err != nil {
+			panic(fmt.Sprintf("validation: %s check %s: %v", name, c.name, err))
+		}
+		c.params = params
+	}
+	registry[name] = m
+	return m
+}
+
+// jsonName ... the key the field has in requests, errors use it so clients can match them to their input
+func jsonName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return f.Name
+	}
+	return name
+}
+
+// validate runs the field rules and then the checks whose fields were fine
+func (m *model) validate(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Type() != m.typ {
+		panic(fmt.Sprintf("validation: %s rules got a %s", m.name, rv.Type()))
+	}
+
+	var errs Errors
+	failed := make(map[string]bool)
+	for _, f := range m.fields {
+		before := len(errs)
+		f.rules.apply(f.name, rv.Field(f.index), &errs)
+		if len(errs) > before {
+			failed[f.name] = true
+		}
+	}
+
+	for _, c := range m.checks {
+		if c.disabled || anyFailed(failed, c.fields) {
+			continue
+		}
+		errs.Check(c.fn(v, c.params))
+	}
+	return errs.Err()
+}
+
+func anyFailed(failed map[string]bool, fields []string) bool {
+	for _, f := range fields {
+		if failed[f] {
+			return true
+		}
+	}
+	return false
+}
+
+// field checks a lone value against the rules of one field, e.g. a class taken from the URL
+func (m *model) field(name string, value interface{}) error {
+	for _, f := range m.fields {
+		if f.name == name {
+			var errs Errors
+			f.rules.apply(name, reflect.ValueOf(value), &errs)
+			return errs.Err()
+		}
+	}
+	panic(fmt.Sprintf("validation: %s has no rules for %s", m.name, name))
+}
+
+// apply records at most one error for the field itself and one per failing element
+func (rs ruleSet) apply(name string, v reflect.Value, errs *Errors) {
+	if err := checkValue(name, v, rs.rules); err != nil {
+		errs.Check(err)
+		return
+	}
+	if len(rs.elem) == 0 || isZero(v) {
+		return
+	}
+	v = reflect.Indirect(v)
+	for i := 0; i < v.Len(); i++ {
+		errs.Check(checkValue(fmt.Sprintf("%s[%d]", name, i), v.Index(i), rs.elem))
+	}
+}
+
+// checkValue ... the first rule the value breaks, required is looked at before anything else
+func checkValue(name string, v reflect.Value, rules []rule) error {
+	zero := isZero(v)
+	for _, r := range rules {
+		if r.name == "required" && zero {
+			return fieldError(name, CodeRequired, name+" is required")
+		}
+	}
+	if zero {
+		return nil
+	}
+
+	v = reflect.Indirect(v)
+	for _, r := range rules {
+		if err := r.check(name, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isZero ... what counts as "not provided" for required
+func isZero(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	if v.Kind() == reflect.Ptr {
+		return v.IsNil()
+	}
+	if z, ok := v.Interface().(interface{ IsZero() bool }); ok {
+		return z.IsZero()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// check ... v is never zero here and pointers are already followed
+func (r rule) check(name string, v reflect.Value) error {
+	switch r.name {
+	case "min", "max":
+		n, unit := measure(v)
+		if r.name == "min" && n < r.number {
+			if unit != "" {
+				return fieldError(name, CodeTooShort, fmt.Sprintf("%s must be at least %g %s", name, r.number, unit))
+			}
+			return fieldError(name, CodeOutOfRange, fmt.Sprintf("%s must be at least %g", name, r.number))
+		}
+		if r.name == "max" && n > r.number {
+			if unit != "" {
+				return fieldError(name, CodeTooLong, fmt.Sprintf("%s must be at most %g %s", name, r.number, unit))
+			}
+			return fieldError(name, CodeOutOfRange, fmt.Sprintf("%s must be at most %g", name, r.number))
+		}
+	case "regex":
+		if !r.pattern.MatchString(v.String()) {
+			return fieldError(name, CodeInvalidFormat, fmt.Sprintf("%s must match %s", name, r.param))
+		}
+	case "enum":
+		for _, c := range r.choices {
+			if v.String() == c {
+				return nil
+			}
+		}
+		return fieldError(name, CodeInvalidChoice, fmt.Sprintf("%s must be one of %s, got %q", name, listChoices(r.choices), v.String()))
+	case "email":
+		return emailError(name, v.String())
+	case "phone":
+		return phoneError(name, v.String())
+	case "past":
+		if t, ok := v.Interface().(interface{ After(time.Time) bool }); ok && t.After(time.Now()) {
+			return fieldError(name, CodeOutOfRange, name+" cannot be in the future")
+		}
+	}
+	return nil
+}
+
+// measure ... the number min and max compare, with the unit when it is a length
+func measure(v reflect.Value) (float64, string) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), "characters"
+	case reflect.Slice, reflect.Map:
+		return float64(v.Len()), "items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), ""
+	}
+	return v.Float(), ""
+}
+
+// listChoices ... a, b, c or d
+func listChoices(choices []string) string {
+	if len(choices) == 1 {
+		return choices[0]
+	}
+	return strings.Join(choices[:len(choices)-1], ", ") + " or " + choices[len(choices)-1]
+}
+
+// parseRules parses a tag and makes sure every rule makes sense for the field's type
+func parseRules(tag string, typ reflect.Type) (ruleSet, error) {
+	var rs ruleSet
+	if tag == "-" {
+		return rs, nil
+	}
+	target := &rs.rules
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if part == "dive" {
+			if target == &rs.elem {
+				return rs, errors.New("dive can only be used once")
+			}
+			if typ.Kind() != reflect.Slice {
+				return rs, errors.New("dive needs a list")
+			}
+			target, typ = &rs.elem, typ.Elem()
+			continue
+		}
+		r, err := parseRule(part, typ)
+		if err != nil {
+			return rs, err
+		}
+		*target = append(*target, r)
+	}
+	return rs, nil
+}
+
+func parseRule(part string, typ reflect.Type) (rule, error) {
+	name, param, hasParam := strings.Cut(part, "=")
+	r := rule{name: name, param: param}
+	base := typ
+	if base.Kind() == reflect.Ptr {
+		base = base.Elem()
+	}
+
+	needsParam := name == "min" || name == "max" || name == "regex" || name == "enum"
+	if needsParam && (!hasParam || param == "") {
+		return r, fmt.Errorf("rule %s needs a value, like %s=...", name, name)
+	}
+	if !needsParam && hasParam {
+		return r, fmt.Errorf("rule %s does not take a value", name)
+	}
+
+	switch name {
+	case "required":
+	case "min", "max":
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return r, fmt.Errorf("rule %s: %q is not a number", name, param)
+		}
+		switch base.Kind() {
+		case reflect.String, reflect.Slice, reflect.Map, reflect.Float32, reflect.Float64,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			return r, fmt.Errorf("rule %s cannot be used on a %s", name, base)
+		}
+		r.number = n
+	case "regex":
+		pattern, err := regexp.Compile(param)
+		if err != nil {
+			return r, fmt.Errorf("rule regex: %v", err)
+		}
+		r.pattern = pattern
+	case "enum":
+		r.choices = strings.Split(param, "|")
+	case "email", "phone":
+	case "past":
+		if _, ok := reflect.Zero(base).Interface().(interface{ After(time.Time) bool }); !ok {
+			return r, fmt.Errorf("rule past cannot be used on a %s", base)
+		}
+	default:
+		return r, fmt.Errorf("unknown rule %q", name)
+	}
+
+	if (name == "regex" || name == "enum" || name == "email" || name == "phone") && base.Kind() != reflect.String {
+		return r, fmt.Errorf("rule %s cannot be used on a %s", name, base)
+	}
+	return r, nil
+}
+
+// parseParams ... the limits of a check, e.g. min=3,max=20
+func parseParams(s string) (map[string]float64, error) {
+	params := make(map[string]float64)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q should look like name=number", part)
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		params[key] = n
+	}
+	return params, nil
+}
+
+// RulesConfig ... the layout of VALIDATION_RULES_FILE, by model name:
+//
+//	{"student": {"fields": {"age": "required,min=4,max=30"}, "checks": {"age_for_class": "min=4,max=8"}}}
+//
+// a field or check set to "-" is switched off, anything left out keeps the rules from the code
+type RulesConfig map[string]struct {
+	Fields map[string]string `json:"fields"`
+	Checks map[string]string `json:"checks"`
+}
+
+// FromEnv applies VALIDATION_RULES_FILE when it is set, it has to run before the server takes requests
+func FromEnv() error {
+	path := os.Getenv("VALIDATION_RULES_FILE")
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read validation rules: %w", err)
+	}
+	if err := Configure(data); err != nil {
+		return fmt.Errorf("validation rules %s: %w", path, err)
+	}
+	return nil
+}
+
+// Configure overrides rules with a RulesConfig document, nothing changes unless the whole document is valid
+func Configure(data []byte) error {
+	var cfg RulesConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return err
+	}
+
+	var apply []func()
+	for _, modelName := range sortedKeys(cfg) {
+		m, ok := registry[modelName]
+		if !ok {
+			return fmt.Errorf("unknown model %q, expected one of %s", modelName, listChoices(sortedKeys(registry)))
+		}
+		override := cfg[modelName]
+
+		for _, fieldName := range sortedKeys(override.Fields) {
+			f := m.fieldSpec(fieldName)
+			if f == nil {
+				return fmt.Errorf("%s has no field %q", modelName, fieldName)
+			}
+			tag := override.Fields[fieldName]
+			rules, err := parseRules(tag, f.typ)
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", modelName, fieldName, err)
+			}
+			apply = append(apply, func() { f.tag, f.rules = tag, rules })
+		}
+
+		for _, checkName := range sortedKeys(override.Checks) {
+			c := m.check(checkName)
+			if c == nil {
+				return fmt.Errorf("%s has no check %q", modelName, checkName)
+			}
+			value := override.Checks[checkName]
+			if value == "-" {
+				apply = append(apply, func() { c.disabled = true })
+				continue
+			}
+			params, err := parseParams(value)
+			if err != nil {
+				return fmt.Errorf("%s check %s: %w", modelName, checkName, err)
+			}
+			for key := range params {
+				if _, ok := c.params[key]; !ok {
+					return fmt.Errorf("%s check %s has no setting %q", modelName, checkName, key)
+				}
+			}
+			apply = append(apply, func() {
+				c.disabled = false
+				for key, n := range params {
+					c.params[key] = n
+				}
+			})
+		}
+	}
+
+	for _, fn := range apply {
+		fn()
+	}
+	return nil
+}
+
+func (m *model) fieldSpec(name string) *fieldSpec {
+	for _, f := range m.fields {
+		if f.name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func (m *model) check(name string) *check {
+	for _, c := range m.checks {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,64 +1,147 @@
 package validation
 
 import (
-	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
 	"school_api_postgres/models"
+	"strings"
 )
 
+// Every Validate function reports all problems it finds at once as Errors,
+// the single-field ones (ValidateAge, ValidateEmail ...) return one FieldError.
+// The rules themselves are the validate tags on the models, see rules.go
+
+var (
+	studentRules = register("student", models.Student{}, &check{
+		name:     "age_for_class",
+		fields:   []string{"age", "class"},
+		defaults: "min=3,max=20",
+		// off unless VALIDATION_RULES_FILE sets it, students stored before it existed would fail their next PUT or PATCH
+		disabled: true,
+		fn:       ageForClass,
+	})
+	teacherRules    = register("teacher", models.Teacher{})
+	courseRules     = register("course", models.Course{})
+	guardianRules   = register("guardian", models.Guardian{}, &check{name: "phone_or_email", fn: phoneOrEmail})
+	attendanceRules = register("attendance", models.AttendanceRecord{})
+	examRules       = register("exam", models.Exam{})
+)
+
+// ValidateStudent checks every field of a student
 func ValidateStudent(s models.Student) error {
+	return studentRules.validate(s)
+}
 
-	if s.Name == "" {
-		return errors.New("name is required")
+// ageForClass ... a student is expected to be between min and max years older than their class number
+func ageForClass(v interface{}, params map[string]float64) error {
+	s := v.(models.Student)
+	diff := float64(s.Age - s.Class)
+	if diff < params["min"] || diff > params["max"] {
+		return fieldError("age", CodeOutOfRange, fmt.Sprintf("age %d is not plausible for class %d, expected %g to %g",
+			s.Age, s.Class, float64(s.Class)+params["min"], float64(s.Class)+params["max"]))
 	}
+	return nil
+}
 
-	if s.Age == 0 {
-		return errors.New("provide age")
-	}
+// ValidateAge checks if the age is valid
+func ValidateAge(age int) error {
+	return studentRules.field("age", age)
+}
 
-	if s.Age < 0 || s.Age > 120 {
+// ValidateClass checks if the class is valid
+func ValidateClass(class int) error {
+	return studentRules.field("class", class)
+}
 
-		if s.Age < 0 {
-			return errors.New("age must be a positive number")
-		}
-		if s.Age > 120 {
-			return errors.New("age is too high")
-		}
-	}
+// ValidateTeacher checks every field of a teacher
+func ValidateTeacher(t models.Teacher) error {
+	return teacherRules.validate(t)
+}
+
+// ValidateEmail checks that the value is a bare address like name@example.com
+func ValidateEmail(email string) error {
+	return emailError("email", email)
+}
 
-	if s.Class == 0 {
-		return errors.New("provide class")
+func emailError(field, email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || !strings.Contains(email[strings.LastIndex(email, "@")+1:], ".") {
+		return fieldError(field, CodeInvalidFormat, field+" is not a valid address")
 	}
+	if len(email) > 254 {
+		return fieldError(field, CodeTooLong, field+" is too long")
+	}
+	return nil
+}
 
-	if s.Class < 1 || s.Class > 10 {
-		if s.Class < 0 {
-			return errors.New("class must be a positive number")
-		}
-		if s.Class > 10 {
-			return errors.New("class is too high")
-		}
+// ValidateCourse checks a course before it is stored
+func ValidateCourse(c models.Course) error {
+	return courseRules.validate(c)
+}
+
+// ValidateAttendanceRecord checks one record of a class register
+func ValidateAttendanceRecord(rec models.AttendanceRecord) error {
+	return attendanceRules.validate(rec)
+}
+
+// ValidateAttendanceStatus ...
+func ValidateAttendanceStatus(status string) error {
+	return attendanceRules.field("status", status)
+}
+
+// ValidateAttendanceDate ... attendance can be corrected afterwards but not recorded ahead of time
+func ValidateAttendanceDate(d models.Date) error {
+	if d.IsZero() {
+		return fieldError("date", CodeRequired, "date is required")
 	}
+	return attendanceRules.field("date", d)
+}
 
-	return nil
+// ValidateExam checks an exam before it is stored
+func ValidateExam(e models.Exam) error {
+	return examRules.validate(e)
 }
 
-// validateAge checks if the age is valid
-func ValidateAge(age int) error {
-	if age < 0 {
-		return errors.New("age must be a positive number")
+// ValidateScore ... a score between 0 and the exam's max_score
+func ValidateScore(score, maxScore float64) error {
+	if score < 0 {
+		return fieldError("score", CodeOutOfRange, "score must not be negative")
 	}
-	if age > 120 {
-		return errors.New("age is too high")
+	if score > maxScore {
+		return fieldError("score", CodeOutOfRange, fmt.Sprintf("score must not be greater than the exam's max_score of %g", maxScore))
 	}
 	return nil
 }
 
-// validateClass checks if the class is valid
-func ValidateClass(class int) error {
-	if class < 0 {
-		return errors.New("class must be a positive number")
+// phonePattern ... an optional leading + and 7 to 15 digits, the E.164 limits
+var phonePattern = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+
+// phoneSeparators ... what people type between digit groups, ignored when checking a number
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "")
+
+// ValidatePhone accepts numbers like +8801712345678 or 01712-345678
+func ValidatePhone(phone string) error {
+	return phoneError("phone", phone)
+}
+
+func phoneError(field, phone string) error {
+	if len(phone) > 30 || !phonePattern.MatchString(phoneSeparators.Replace(phone)) {
+		return fieldError(field, CodeInvalidFormat, field+" must be 7 to 15 digits with an optional leading +")
 	}
-	if class > 10 {
-		return errors.New("class is too high")
+	return nil
+}
+
+// ValidateGuardian checks a guardian before it is stored
+func ValidateGuardian(g models.Guardian) error {
+	return guardianRules.validate(g)
+}
+
+// phoneOrEmail ... a guardian needs a phone or an email to be of any use
+func phoneOrEmail(v interface{}, _ map[string]float64) error {
+	g := v.(models.Guardian)
+	if strings.TrimSpace(g.Phone) == "" && strings.TrimSpace(g.Email) == "" {
+		return fieldError("phone", CodeRequired, "provide phone or email")
 	}
 	return nil
 }
@@ -0,0 +1,168 @@
+package validation
+
+import (
+	"errors"
+	"maps"
+	"testing"
+
+	"school_api_postgres/models"
+)
+
+// keepRules puts every rule back the way it was once the test is done, Configure changes them for the whole package
+func keepRules(t *testing.T) {
+	t.Helper()
+	type fieldState struct {
+		tag   string
+		rules ruleSet
+	}
+	type checkState struct {
+		params   map[string]float64
+		disabled bool
+	}
+	fields := map[*fieldSpec]fieldState{}
+	checks := map[*check]checkState{}
+	for _, m := range registry {
+		for _, f := range m.fields {
+			fields[f] = fieldState{f.tag, f.rules}
+		}
+		for _, c := range m.checks {
+			checks[c] = checkState{maps.Clone(c.params), c.disabled}
+		}
+	}
+	t.Cleanup(func() {
+		for f, s := range fields {
+			f.tag, f.rules = s.tag, s.rules
+		}
+		for c, s := range checks {
+			c.params, c.disabled = s.params, s.disabled
+		}
+	})
+}
+
+// codes ... field:code of every FieldError in err
+func codes(err error) []string {
+	var errs Errors
+	if !errors.As(err, &errs) {
+		if err != nil {
+			return []string{err.Error()}
+		}
+		return nil
+	}
+	var out []string
+	for _, fe := range errs {
+		out = append(out, fe.Field+":"+fe.Code)
+	}
+	return out
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestValidateStudent(t *testing.T) {
+	tests := []struct {
+		name    string
+		student models.Student
+		want    []string
+	}{
+		{"valid", models.Student{Name: "Rahim", Age: 12, Class: 6}, nil},
+		{"missing name", models.Student{Age: 12, Class: 6}, []string{"name:required"}},
+		{"class above 10", models.Student{Name: "Rahim", Age: 12, Class: 11}, []string{"class:out_of_range"}},
+		{"every field at once", models.Student{Age: 121}, []string{"name:required", "age:out_of_range", "class:required"}},
+		{"implausible age passes while age_for_class is off", models.Student{Name: "Rahim", Age: 60, Class: 2}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := codes(ValidateStudent(tt.student)); !equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateGuardian(t *testing.T) {
+	tests := []struct {
+		name     string
+		guardian models.Guardian
+		want     []string
+	}{
+		{"phone only", models.Guardian{Name: "Salma", Relationship: "mother", Phone: "+8801711000000"}, nil},
+		{"email only", models.Guardian{Name: "Salma", Relationship: "mother", Email: "salma@example.com"}, nil},
+		{"neither", models.Guardian{Name: "Salma", Relationship: "mother"}, []string{"phone:required"}},
+		{"bad email", models.Guardian{Name: "Salma", Relationship: "mother", Email: "salma@"}, []string{"email:invalid_format"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := codes(ValidateGuardian(tt.guardian)); !equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		student models.Student
+		want    []string
+	}{
+		{"raised class limit", `{"student": {"fields": {"class": "required,min=1,max=12"}}}`,
+			models.Student{Name: "Rahim", Age: 17, Class: 12}, nil},
+		{"age_for_class switched on with its defaults", `{"student": {"checks": {"age_for_class": ""}}}`,
+			models.Student{Name: "Rahim", Age: 60, Class: 2}, []string{"age:out_of_range"}},
+		{"age_for_class switched on with settings", `{"student": {"checks": {"age_for_class": "min=4,max=6"}}}`,
+			models.Student{Name: "Rahim", Age: 9, Class: 6}, []string{"age:out_of_range"}},
+		{"age_for_class switched off", `{"student": {"checks": {"age_for_class": "-"}}}`,
+			models.Student{Name: "Rahim", Age: 60, Class: 2}, nil},
+		{"field switched off", `{"student": {"fields": {"name": "-"}}}`,
+			models.Student{Age: 12, Class: 6}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keepRules(t)
+			if err := Configure([]byte(tt.doc)); err != nil {
+				t.Fatal(err)
+			}
+			if got := codes(ValidateStudent(tt.student)); !equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigureRejects(t *testing.T) {
+	keepRules(t)
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{"not json", `{"student":`},
+		{"unknown model", `{"pupil": {"fields": {"name": "required"}}}`},
+		{"unknown field", `{"student": {"fields": {"height": "min=1"}}}`},
+		{"unknown rule", `{"student": {"fields": {"name": "required,shiny"}}}`},
+		{"bad rule parameter", `{"student": {"fields": {"age": "min=old"}}}`},
+		{"unknown check", `{"student": {"checks": {"shoe_size": ""}}}`},
+		{"unknown check setting", `{"student": {"checks": {"age_for_class": "min=3,avg=9"}}}`},
+		{"valid part of a broken document", `{"student": {"fields": {"name": "-", "height": "min=1"}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Configure([]byte(tt.doc)); err == nil {
+				t.Error("Configure accepted the document")
+			}
+		})
+	}
+	// nothing of a refused document may stick
+	if got := codes(ValidateStudent(models.Student{Age: 12, Class: 6})); !equal(got, []string{"name:required"}) {
+		t.Errorf("after refused documents got %v, want name still required", got)
+	}
+}